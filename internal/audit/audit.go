@@ -0,0 +1,114 @@
+// Package audit はこのツールから GitHub に送った変更を監査ログとして記録する。
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 変更を送った経路。
+const (
+	SourceConsole = "console"
+	SourceApply   = "apply"
+	// SourceUndo と SourceRedo はコンソールの取り消し・やり直しで送った変更。
+	SourceUndo = "undo"
+	SourceRedo = "redo"
+	// SourceBatch は /api/batch でまとめて送った変更、SourceRollback はその失敗時に戻した変更。
+	SourceBatch    = "batch"
+	SourceRollback = "rollback"
+)
+
+// Entry は GitHub に送った変更1件の記録。
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	// API は "graphql" または "rest"。
+	API string `json:"api"`
+	// Method と Path は REST の場合のみ設定する。
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Operation は GraphQL の mutation フィールド名、または "PATCH repos/o/r/issues/1" の形式の REST 呼び出し。
+	Operation string `json:"operation"`
+	// Variables は GraphQL の variables、または REST のリクエストボディ。
+	Variables json.RawMessage `json:"variables,omitempty"`
+	// Targets は変更対象の Issue 等の ID。複合 ID（owner/repo#number）、ノード ID、REST の id が混在する。
+	Targets []string `json:"targets,omitempty"`
+	// Status は GitHub のレスポンスの HTTP ステータス。送信自体に失敗した場合は 0。
+	Status int `json:"status"`
+	// Error は GitHub がエラーを返した場合、または送信に失敗した場合のメッセージ。
+	Error string `json:"error,omitempty"`
+}
+
+// Log は Entry を JSON Lines 形式のファイルに追記する監査ログ。
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open は指定ファイルをバックエンドとする Log を返す。ファイルは最初の記録時に作成する。
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path はバックエンドのファイルパスを返す。
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append は Entry を追記する。Time が未設定なら現在時刻を設定する。
+func (l *Log) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// List は記録された Entry を記録順に返す。ファイルがなければ空を返す。
+// 書き込み途中で終了した等で壊れた行は読み飛ばす。
+func (l *Log) List() ([]Entry, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	entries := []Entry{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLog_AppendAndList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.jsonl")
+	l := Open(path)
+
+	entries, err := l.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty log, got %d entries", len(entries))
+	}
+
+	if err := l.Append(Entry{Source: SourceConsole, API: "graphql", Operation: "removeSubIssue", Variables: json.RawMessage(`{"issueId":"I_1"}`), Targets: []string{"I_1"}, Status: 200}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := l.Append(Entry{Source: SourceApply, API: "rest", Method: "PATCH", Path: "repos/o/r/issues/1", Operation: "PATCH repos/o/r/issues/1", Status: 404, Error: "Not Found"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	entries, err = l.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Operation != "removeSubIssue" || entries[0].Time.IsZero() || !slices.Equal(entries[0].Targets, []string{"I_1"}) {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Status != 404 || entries[1].Error != "Not Found" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestLog_ListSkipsBrokenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	data := `{"operation":"a","status":200}` + "\n" + `{"operation":"b","sta` + "\n" + `{"operation":"c","status":200}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Open(path).List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Operation != "a" || entries[1].Operation != "c" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestRESTTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want []string
+	}{
+		{name: "create issue", path: "repos/o/r/issues", body: `{"title":"x"}`, want: nil},
+		{name: "update issue", path: "repos/o/r/issues/12", body: `{"state":"closed"}`, want: []string{"o/r#12"}},
+		{name: "add sub-issue", path: "repos/o/r/issues/1/sub_issues", body: `{"sub_issue_id":4242}`, want: []string{"o/r#1", "4242"}},
+		{name: "invalid body", path: "repos/o/r/issues/1/sub_issue", body: `{`, want: []string{"o/r#1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RESTTargets(tt.path, []byte(tt.body)); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLTargets(t *testing.T) {
+	vars := `{"input":{"projectId":"PVT_1","itemId":"PVTI_1","value":{"singleSelectOptionId":"opt"}},"issueId":"I_1","blockingIssueId":"I_2","title":"t","ids":["I_1","I_3"]}`
+	// キーの辞書順に、重複を除いて並ぶ。変更内容を表す singleSelectOptionId は含めない
+	want := []string{"I_2", "I_1", "I_3", "PVTI_1", "PVT_1"}
+	if got := GraphQLTargets([]byte(vars)); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := GraphQLTargets(nil); got != nil {
+		t.Errorf("got %v for empty variables", got)
+	}
+	fieldUpdate := `{"input":{"projectId":"PVT_1","itemId":"PVTI_1","fieldId":"PVTSSF_1","value":{"iterationId":"it"}},"clientMutationId":"c"}`
+	if got := GraphQLTargets([]byte(fieldUpdate)); !slices.Equal(got, []string{"PVTI_1", "PVT_1"}) {
+		t.Errorf("got %v for a field update", got)
+	}
+}
+
+func TestResolver_AddsCompositeIDs(t *testing.T) {
+	var queried [][]string
+	r := NewResolver(func(_ context.Context, query string, variables map[string]any, resp any) error {
+		ids := variables["ids"].([]string)
+		queried = append(queried, ids)
+		data := `{"nodes":[
+			{"id":"I_1","number":1,"repository":{"nameWithOwner":"o/r"}},
+			{"id":"PVTI_2","content":{"number":2,"repository":{"nameWithOwner":"o/r"}}},
+			{"id":"PVTI_3","content":{}},
+			null
+		]}`
+		return json.Unmarshal([]byte(data), resp)
+	})
+
+	got := r.Resolve(context.Background(), []string{"I_1", "PVTI_2", "PVT_1", "PVTI_3", "I_404"})
+	want := []string{"I_1", "o/r#1", "PVTI_2", "o/r#2", "PVT_1", "PVTI_3", "I_404"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// プロジェクト ID は問い合わせない
+	if len(queried) != 1 || !slices.Equal(queried[0], []string{"I_1", "PVTI_2", "PVTI_3", "I_404"}) {
+		t.Errorf("queried %v", queried)
+	}
+
+	// 引いた ID は問い合わせ直さない
+	r.Resolve(context.Background(), []string{"I_1", "PVTI_3"})
+	if len(queried) != 1 {
+		t.Errorf("expected resolved IDs to be remembered, queried %v", queried)
+	}
+}
+
+func TestResolver_KeepsNodeIDsOnError(t *testing.T) {
+	r := NewResolver(func(context.Context, string, map[string]any, any) error {
+		return errors.New("boom")
+	})
+	if got := r.Resolve(context.Background(), []string{"I_1"}); !slices.Equal(got, []string{"I_1"}) {
+		t.Errorf("got %v", got)
+	}
+	var nilResolver *Resolver
+	if got := nilResolver.Resolve(context.Background(), []string{"I_1"}); !slices.Equal(got, []string{"I_1"}) {
+		t.Errorf("got %v", got)
+	}
+}
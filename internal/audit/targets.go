@@ -0,0 +1,219 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// RESTTargets は REST 呼び出しのパスとボディから変更対象の ID を取り出す。
+// repos/{owner}/{repo}/issues/{number} 配下のパスは複合 ID に変換する。
+func RESTTargets(path string, body []byte) []string {
+	var targets []string
+	segments := strings.Split(path, "/")
+	if len(segments) >= 5 && segments[0] == "repos" && segments[3] == "issues" {
+		if number, err := strconv.Atoi(segments[4]); err == nil {
+			targets = append(targets, github.BuildIssueID(segments[1], segments[2], number))
+		}
+	}
+	return appendIDs(targets, body, isIDKey)
+}
+
+// GraphQLTargets は GraphQL の variables から変更対象の ID を取り出す。
+// 対象は Issue・プルリクエスト・プロジェクトとその item を指すキーの値だけで、
+// fieldId や singleSelectOptionId のような変更内容を表す ID は含めない。
+func GraphQLTargets(variables []byte) []string {
+	return appendIDs(nil, variables, isGraphQLTargetKey)
+}
+
+// appendIDs は JSON の中で、isKey が真になるキーの値を targets に追加する。
+func appendIDs(targets []string, data []byte, isKey func(string) bool) []string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return targets
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return targets
+	}
+	collectIDs(v, false, isKey, &targets)
+	return targets
+}
+
+func collectIDs(v any, isID bool, isKey func(string) bool, targets *[]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			collectIDs(v[k], isKey(k), isKey, targets)
+		}
+	case []any:
+		for _, child := range v {
+			collectIDs(child, isID, isKey, targets)
+		}
+	case string, json.Number:
+		if s := fmt.Sprint(v); isID && s != "" && !slices.Contains(*targets, s) {
+			*targets = append(*targets, s)
+		}
+	}
+}
+
+// isIDKey は REST のボディで ID を表すキー（id, *Id, *Ids, *_id, *_ids）かどうかを返す。
+func isIDKey(k string) bool {
+	return k == "id" || k == "ids" ||
+		strings.HasSuffix(k, "Id") || strings.HasSuffix(k, "Ids") ||
+		strings.HasSuffix(k, "_id") || strings.HasSuffix(k, "_ids")
+}
+
+// graphQLTargetKeys と graphQLTargetSuffixes は GraphQL の variables で変更対象を指すキー（複数形も含む）。
+var (
+	graphQLTargetKeys     = []string{"id", "issueId", "pullRequestId", "itemId", "contentId", "projectId"}
+	graphQLTargetSuffixes = []string{"IssueId", "PullRequestId", "ItemId"}
+)
+
+func isGraphQLTargetKey(k string) bool {
+	k = strings.TrimSuffix(k, "s")
+	return slices.Contains(graphQLTargetKeys, k) ||
+		slices.ContainsFunc(graphQLTargetSuffixes, func(suffix string) bool { return strings.HasSuffix(k, suffix) })
+}
+
+// resolvableNodePrefixes は複合 ID に引けるノード ID の接頭辞（Issue・プルリクエスト・プロジェクトの item）。
+var resolvableNodePrefixes = []string{"I_", "PR_", "PVTI_"}
+
+// resolveQuery はノード ID から Issue・プルリクエストの複合 ID を引くクエリ。
+// プロジェクトの item は中身の Issue・プルリクエストを引く。
+const resolveQuery = `query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    id
+    ... on Issue { number repository { nameWithOwner } }
+    ... on PullRequest { number repository { nameWithOwner } }
+    ... on ProjectV2Item {
+      content {
+        ... on Issue { number repository { nameWithOwner } }
+        ... on PullRequest { number repository { nameWithOwner } }
+      }
+    }
+  }
+}`
+
+// maxResolveIDs は 1 回のクエリで引くノード ID の上限（nodes(ids:) の上限）。
+const maxResolveIDs = 100
+
+// QueryFunc は GraphQL クエリを送り、レスポンスの data を resp にデコードする。
+// go-gh の GraphQLClient.DoWithContext と同じ形。
+type QueryFunc func(ctx context.Context, query string, variables map[string]any, resp any) error
+
+// Resolver は変更対象のノード ID を複合 ID（owner/repo#number）に引く。
+// GraphQL の mutation はノード ID しか持たないため、複合 ID でも `log --target` で探せるように両方を記録する。
+// 引いた結果は覚えておき、同じノード ID を何度も問い合わせない。
+type Resolver struct {
+	query QueryFunc
+
+	mu    sync.Mutex
+	known map[string]string
+}
+
+// NewResolver は query で GitHub に問い合わせる Resolver を返す。
+func NewResolver(query QueryFunc) *Resolver {
+	return &Resolver{query: query, known: make(map[string]string)}
+}
+
+// Resolve は targets の Issue・プルリクエスト・プロジェクトの item のノード ID の直後に、対応する複合 ID を加えて返す。
+// 問い合わせに失敗した場合や引けなかった ID はノード ID のまま残す。nil の Resolver は targets をそのまま返す。
+func (r *Resolver) Resolve(ctx context.Context, targets []string) []string {
+	if r == nil {
+		return targets
+	}
+
+	r.mu.Lock()
+	var unknown []string
+	for _, t := range targets {
+		if _, ok := r.known[t]; !ok && isResolvableNodeID(t) && !slices.Contains(unknown, t) {
+			unknown = append(unknown, t)
+		}
+	}
+	r.mu.Unlock()
+
+	for chunk := range slices.Chunk(unknown, maxResolveIDs) {
+		resolved, err := r.lookup(ctx, chunk)
+		if err != nil {
+			break
+		}
+		r.mu.Lock()
+		maps.Copy(r.known, resolved)
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(targets))
+	for _, t := range targets {
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+		if id := r.known[t]; id != "" && !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (r *Resolver) lookup(ctx context.Context, ids []string) (map[string]string, error) {
+	type content struct {
+		Number     int `json:"number"`
+		Repository struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		} `json:"repository"`
+	}
+	var resp struct {
+		Nodes []*struct {
+			ID string `json:"id"`
+			content
+			Content *content `json:"content"`
+		} `json:"nodes"`
+	}
+	if err := r.query(ctx, resolveQuery, map[string]any{"ids": ids}, &resp); err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]string, len(ids))
+	for _, n := range resp.Nodes {
+		if n == nil || n.ID == "" {
+			continue
+		}
+		c := n.content
+		if n.Content != nil {
+			c = *n.Content
+		}
+		owner, repo, ok := strings.Cut(c.Repository.NameWithOwner, "/")
+		if !ok || c.Number == 0 {
+			// プロジェクトの下書きなど、複合 ID のないノード
+			resolved[n.ID] = ""
+			continue
+		}
+		resolved[n.ID] = github.BuildIssueID(owner, repo, c.Number)
+	}
+	return resolved, nil
+}
+
+func isResolvableNodeID(id string) bool {
+	for _, prefix := range resolvableNodePrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
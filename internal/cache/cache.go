@@ -1,11 +1,11 @@
 package cache
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 )
@@ -17,19 +17,38 @@ type NodePosition struct {
 }
 
 // ProjectCache は1プロジェクト分のキャッシュデータを表す。
+// SyncedAt は items がどの時点の GitHub の更新まで反映しているかを示す同期ウォーターマーク。
+// ゼロ値なら items の鮮度は不明で、次回の同期は全件取得になる。
+// Revision は items かノード座標の内容が変わるたびに増える番号で、書き込みの競合検出に使う。
+// Store が返す ProjectCache は書き込みのたびに作り直すスナップショットなので、変更しないこと。
 type ProjectCache struct {
+	Revision      int64                   `json:"revision"`
 	Items         json.RawMessage         `json:"items"`
+	SyncedAt      time.Time               `json:"syncedAt,omitzero"`
 	NodePositions map[string]NodePosition `json:"nodePositions"`
 }
 
+// AnyRevision を渡すとリビジョンを確かめずに書き込む。
+const AnyRevision int64 = -1
+
+// ErrRevisionMismatch は書き込み時に指定したリビジョンが現在のリビジョンと異なることを示す。
+var ErrRevisionMismatch = errors.New("cache revision mismatch")
+
 // Store はインメモリキャッシュと定期ファイルフラッシュを管理する。
 type Store struct {
 	mu       sync.RWMutex
 	caches   map[string]*ProjectCache
 	cacheDir string
 	dirty    map[string]bool
+	// modTimes はこのプロセスが最後に読み書きしたキャッシュファイルの更新日時、bases はその内容。
+	// フラッシュ時に、他のプロセスが書き込んだ内容とマージする基準にする。
+	modTimes map[string]time.Time
+	bases    map[string]*ProjectCache
 	stopCh   chan struct{}
 	doneCh   chan struct{}
+
+	subMu sync.Mutex
+	subs  map[chan Change]struct{}
 }
 
 // NewStore は指定ディレクトリをバックエンドとする Store を作成する。
@@ -38,10 +57,14 @@ func NewStore(cacheDir string) *Store {
 		caches:   make(map[string]*ProjectCache),
 		cacheDir: cacheDir,
 		dirty:    make(map[string]bool),
+		modTimes: make(map[string]time.Time),
+		bases:    make(map[string]*ProjectCache),
+		subs:     make(map[chan Change]struct{}),
 	}
 }
 
 // Start は定期フラッシュ goroutine を開始する。
+// 同じ間隔で、他のプロセスが書き換えたキャッシュファイルの読み直しも行う。
 func (s *Store) Start(interval time.Duration) {
 	s.stopCh = make(chan struct{})
 	s.doneCh = make(chan struct{})
@@ -52,9 +75,10 @@ func (s *Store) Start(interval time.Duration) {
 		for {
 			select {
 			case <-ticker.C:
-				s.FlushAll()
+				_ = s.FlushAll()
+				s.reloadChanged()
 			case <-s.stopCh:
-				s.FlushAll()
+				_ = s.FlushAll()
 				return
 			}
 		}
@@ -85,43 +109,115 @@ func (s *Store) GetCache(projectID string) *ProjectCache {
 		return c
 	}
 
-	loaded, err := s.loadFromDisk(projectID)
-	if err != nil || loaded == nil {
-		c := &ProjectCache{
-			NodePositions: make(map[string]NodePosition),
-		}
-		s.caches[projectID] = c
-		return c
-	}
-	s.caches[projectID] = loaded
-	return loaded
+	return s.getOrCreate(projectID)
 }
 
 // SetItems は items を更新し dirty マークを付ける。
+// 鮮度の分からない items なので同期ウォーターマークはクリアする。
 func (s *Store) SetItems(projectID string, items json.RawMessage) {
+	s.SetSyncedItems(projectID, items, time.Time{})
+}
+
+// SetItemsIfRevision は現在のリビジョンが revision の場合だけ items を更新し、新しいリビジョンを返す。
+// リビジョンが異なる場合は現在のリビジョンと ErrRevisionMismatch を返す。
+func (s *Store) SetItemsIfRevision(projectID string, items json.RawMessage, revision int64) (int64, error) {
+	return s.setItems(projectID, items, time.Time{}, revision)
+}
+
+// SetSyncedItems は items と同期ウォーターマークを更新し dirty マークを付ける。
+// items の内容が変わった場合は購読者に通知する。
+func (s *Store) SetSyncedItems(projectID string, items json.RawMessage, syncedAt time.Time) {
+	_, _ = s.setItems(projectID, items, syncedAt, AnyRevision)
+}
+
+// SetSyncedItemsIfRevision は現在のリビジョンが revision の場合だけ items と同期ウォーターマークを更新し、新しいリビジョンを返す。
+// 同期を始めた後に他の書き込みがあった場合に、それを古い同期結果で上書きしないようにする。
+func (s *Store) SetSyncedItemsIfRevision(projectID string, items json.RawMessage, syncedAt time.Time, revision int64) (int64, error) {
+	return s.setItems(projectID, items, syncedAt, revision)
+}
+
+// SyncedItems は items と同期ウォーターマークを返す。
+func (s *Store) SyncedItems(projectID string) (json.RawMessage, time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	c := s.getOrCreate(projectID)
-	c.Items = items
-	s.dirty[projectID] = true
+	return c.Items, c.SyncedAt
 }
 
-// DeleteItems は items を nil にし dirty マークを付ける。
+// DeleteItems は items と同期ウォーターマークをクリアし dirty マークを付ける。
 func (s *Store) DeleteItems(projectID string) {
+	s.SetSyncedItems(projectID, nil, time.Time{})
+}
+
+// DeleteItemsIfRevision は現在のリビジョンが revision の場合だけ items と同期ウォーターマークをクリアする。
+func (s *Store) DeleteItemsIfRevision(projectID string, revision int64) (int64, error) {
+	return s.setItems(projectID, nil, time.Time{}, revision)
+}
+
+func (s *Store) setItems(projectID string, items json.RawMessage, syncedAt time.Time, revision int64) (int64, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	c := s.getOrCreate(projectID)
-	c.Items = nil
+	if revision != AnyRevision && c.Revision != revision {
+		s.mu.Unlock()
+		return c.Revision, ErrRevisionMismatch
+	}
+	changed := !bytes.Equal(c.Items, items)
+	next := *c
+	next.Items = items
+	next.SyncedAt = syncedAt
+	// 同じ内容の書き込み（変更のなかったバックグラウンド更新など）ではリビジョンを進めない
+	if changed {
+		next.Revision++
+	}
+	s.caches[projectID] = &next
 	s.dirty[projectID] = true
+	s.mu.Unlock()
+
+	if changed {
+		s.publish(Change{ProjectID: projectID, Revision: next.Revision, Items: true})
+	}
+	return next.Revision, nil
 }
 
 // MergeNodePositions は既存マップにマージし dirty マークを付ける。
+// 座標が変わったノードがあれば購読者に通知する。
 func (s *Store) MergeNodePositions(projectID string, positions map[string]NodePosition) {
+	_, _ = s.mergeNodePositions(projectID, positions, AnyRevision)
+}
+
+// MergeNodePositionsIfRevision は現在のリビジョンが revision の場合だけ座標をマージし、新しいリビジョンを返す。
+// リビジョンが異なる場合は現在のリビジョンと ErrRevisionMismatch を返す。
+func (s *Store) MergeNodePositionsIfRevision(projectID string, positions map[string]NodePosition, revision int64) (int64, error) {
+	return s.mergeNodePositions(projectID, positions, revision)
+}
+
+func (s *Store) mergeNodePositions(projectID string, positions map[string]NodePosition, revision int64) (int64, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	c := s.getOrCreate(projectID)
-	maps.Copy(c.NodePositions, positions)
+	if revision != AnyRevision && c.Revision != revision {
+		s.mu.Unlock()
+		return c.Revision, ErrRevisionMismatch
+	}
+	moved := make(map[string]NodePosition)
+	for id, pos := range positions {
+		if cur, ok := c.NodePositions[id]; !ok || cur != pos {
+			moved[id] = pos
+		}
+	}
+	if len(moved) == 0 {
+		s.mu.Unlock()
+		return c.Revision, nil
+	}
+	next := *c
+	next.NodePositions = maps.Clone(c.NodePositions)
+	maps.Copy(next.NodePositions, moved)
+	next.Revision++
+	s.caches[projectID] = &next
 	s.dirty[projectID] = true
+	s.mu.Unlock()
+
+	s.publish(Change{ProjectID: projectID, Revision: next.Revision, NodePositions: moved})
+	return next.Revision, nil
 }
 
 // getOrCreate はインメモリキャッシュからエントリを返すか、なければ作成する。
@@ -131,62 +227,77 @@ func (s *Store) getOrCreate(projectID string) *ProjectCache {
 		return c
 	}
 
-	loaded, err := s.loadFromDisk(projectID)
-	if err != nil || loaded == nil {
+	loaded, modTime, err := s.loadFromDisk(projectID)
+	if errors.Is(err, errCorruptCache) {
+		// 壊れたファイルを次のフラッシュで上書きしないよう、調べられるように退避しておく
+		s.quarantine(projectID)
+	}
+	if err != nil {
 		loaded = &ProjectCache{
 			NodePositions: make(map[string]NodePosition),
 		}
+	} else {
+		s.modTimes[projectID] = modTime
+		s.bases[projectID] = loaded
 	}
 	s.caches[projectID] = loaded
 	return loaded
 }
 
 // FlushAll は dirty なエントリをファイルに書き出す。
-func (s *Store) FlushAll() {
+// 前回の読み書きの後に他のプロセスがファイルを書き換えていれば、マージした内容を書き出してメモリにも反映する。
+// 書き出しに失敗したエントリは dirty のまま残し、次回リトライする。
+func (s *Store) FlushAll() error {
+	type flush struct {
+		cache, base *ProjectCache
+		seen        time.Time
+	}
 	s.mu.Lock()
-	toFlush := make(map[string]*ProjectCache)
+	toFlush := make(map[string]flush)
 	for id := range s.dirty {
-		toFlush[id] = s.caches[id]
+		toFlush[id] = flush{cache: s.caches[id], base: s.bases[id], seen: s.modTimes[id]}
 	}
 	s.dirty = make(map[string]bool)
 	s.mu.Unlock()
 
-	for id, c := range toFlush {
-		if err := s.writeToDisk(id, c); err != nil {
+	var errs []error
+	for id, f := range toFlush {
+		written, modTime, err := s.writeToDisk(id, f.cache, f.base, f.seen)
+		if err != nil {
 			// dirty マークを復元して次回リトライ
 			s.mu.Lock()
 			s.dirty[id] = true
 			s.mu.Unlock()
+			errs = append(errs, fmt.Errorf("failed to flush cache for %s: %w", id, err))
+			continue
 		}
-	}
-}
-
-func (s *Store) cacheFilePath(projectID string) string {
-	return filepath.Join(s.cacheDir, projectID+".json")
-}
 
-func (s *Store) loadFromDisk(projectID string) (*ProjectCache, error) {
-	data, err := os.ReadFile(s.cacheFilePath(projectID))
-	if err != nil {
-		return nil, err
-	}
-	var c ProjectCache
-	if err := json.Unmarshal(data, &c); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
-	}
-	if c.NodePositions == nil {
-		c.NodePositions = make(map[string]NodePosition)
-	}
-	return &c, nil
-}
+		s.mu.Lock()
+		if s.caches[id] != f.cache {
+			// 書き出している間にメモリの内容が変わった。その内容はまだマージしていないので、
+			// マージした場合は基準を元のままにして次のフラッシュでもう一度マージする
+			if written == f.cache {
+				s.modTimes[id], s.bases[id] = modTime, written
+			}
+			s.mu.Unlock()
+			continue
+		}
+		// 自分の書き込みを他のプロセスの変更と取り違えないよう更新日時を覚えておく
+		s.modTimes[id], s.bases[id] = modTime, written
+		if written == f.cache {
+			s.mu.Unlock()
+			continue
+		}
+		s.caches[id] = written
+		s.mu.Unlock()
 
-func (s *Store) writeToDisk(projectID string, c *ProjectCache) error {
-	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create cache dir: %w", err)
-	}
-	data, err := json.Marshal(c)
-	if err != nil {
-		return fmt.Errorf("failed to marshal cache: %w", err)
+		change := Change{ProjectID: id, Revision: written.Revision, Items: !bytes.Equal(f.cache.Items, written.Items)}
+		if !maps.Equal(f.cache.NodePositions, written.NodePositions) {
+			change.NodePositions = maps.Clone(written.NodePositions)
+		}
+		if change.Items || change.NodePositions != nil {
+			s.publish(change)
+		}
 	}
-	return os.WriteFile(s.cacheFilePath(projectID), data, 0o644)
+	return errors.Join(errs...)
 }
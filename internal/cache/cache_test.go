@@ -2,6 +2,11 @@ package cache
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -45,6 +50,32 @@ func TestDeleteItems_KeepsNodePositions(t *testing.T) {
 	}
 }
 
+func TestSetSyncedItems_Watermark(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	syncedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.SetSyncedItems("proj-1", json.RawMessage(`[1]`), syncedAt)
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	items, got := NewStore(dir).SyncedItems("proj-1")
+	if string(items) != `[1]` || !got.Equal(syncedAt) {
+		t.Fatalf("expected [1] synced at %v, got %s at %v", syncedAt, items, got)
+	}
+
+	// 鮮度の分からない items を書いたらウォーターマークは消える
+	s.SetItems("proj-1", json.RawMessage(`[2]`))
+	if _, got := s.SyncedItems("proj-1"); !got.IsZero() {
+		t.Fatalf("expected watermark to be cleared, got %v", got)
+	}
+	s.SetSyncedItems("proj-1", json.RawMessage(`[3]`), syncedAt)
+	s.DeleteItems("proj-1")
+	if items, got := s.SyncedItems("proj-1"); items != nil || !got.IsZero() {
+		t.Fatalf("expected items and watermark to be cleared, got %s at %v", items, got)
+	}
+}
+
 func TestMergeNodePositions(t *testing.T) {
 	s := NewStore(t.TempDir())
 	s.MergeNodePositions("proj-1", map[string]NodePosition{
@@ -103,3 +134,182 @@ func TestStop_FinalFlush(t *testing.T) {
 		t.Fatalf("expected [1], got %s", c.Items)
 	}
 }
+
+func TestFlushAll_ErrorKeepsDirty(t *testing.T) {
+	dir := t.TempDir()
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(blocked)
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+
+	if err := s.FlushAll(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// the entry stays dirty and is written once the directory becomes writable
+	if err := os.Remove(blocked); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c := NewStore(blocked).GetCache("proj-1"); string(c.Items) != "[1]" {
+		t.Fatalf("expected [1], got %s", c.Items)
+	}
+}
+
+func TestRevision_AdvancesOnContentChange(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+	s.MergeNodePositions("proj-1", map[string]NodePosition{"node-1": {X: 1, Y: 2}})
+	if rev := s.GetCache("proj-1").Revision; rev != 2 {
+		t.Fatalf("expected revision 2, got %d", rev)
+	}
+
+	// 内容の変わらない書き込みではリビジョンは進まない
+	s.SetSyncedItems("proj-1", json.RawMessage(`[1]`), time.Now())
+	s.MergeNodePositions("proj-1", map[string]NodePosition{"node-1": {X: 1, Y: 2}})
+	if rev := s.GetCache("proj-1").Revision; rev != 2 {
+		t.Fatalf("expected revision 2, got %d", rev)
+	}
+
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if rev := NewStore(dir).GetCache("proj-1").Revision; rev != 2 {
+		t.Fatalf("expected revision 2 after reload, got %d", rev)
+	}
+}
+
+func TestIfRevision_RejectsStaleWrites(t *testing.T) {
+	s := NewStore(t.TempDir())
+	rev, err := s.SetItemsIfRevision("proj-1", json.RawMessage(`[1]`), 0)
+	if err != nil || rev != 1 {
+		t.Fatalf("expected revision 1, got %d (%v)", rev, err)
+	}
+
+	if got, err := s.SetItemsIfRevision("proj-1", json.RawMessage(`[2]`), 0); !errors.Is(err, ErrRevisionMismatch) || got != 1 {
+		t.Fatalf("expected mismatch at revision 1, got %d (%v)", got, err)
+	}
+	if got, err := s.MergeNodePositionsIfRevision("proj-1", map[string]NodePosition{"node-1": {}}, 0); !errors.Is(err, ErrRevisionMismatch) || got != 1 {
+		t.Fatalf("expected mismatch at revision 1, got %d (%v)", got, err)
+	}
+	if got, err := s.DeleteItemsIfRevision("proj-1", 0); !errors.Is(err, ErrRevisionMismatch) || got != 1 {
+		t.Fatalf("expected mismatch at revision 1, got %d (%v)", got, err)
+	}
+	c := s.GetCache("proj-1")
+	if string(c.Items) != `[1]` || len(c.NodePositions) != 0 {
+		t.Fatalf("expected stale writes to be rejected, got %s %v", c.Items, c.NodePositions)
+	}
+
+	if got, err := s.DeleteItemsIfRevision("proj-1", 1); err != nil || got != 2 {
+		t.Fatalf("expected revision 2, got %d (%v)", got, err)
+	}
+}
+
+func TestIfRevision_ConcurrentWritersOneWins(t *testing.T) {
+	s := NewStore(t.TempDir())
+	const writers = 16
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	won := 0
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items := json.RawMessage(fmt.Sprintf("[%d]", i))
+			if _, err := s.SetItemsIfRevision("proj-1", items, 0); err == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrRevisionMismatch) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if won != 1 {
+		t.Fatalf("expected exactly one writer to win, got %d", won)
+	}
+	if rev := s.GetCache("proj-1").Revision; rev != 1 {
+		t.Fatalf("expected revision 1, got %d", rev)
+	}
+}
+
+func TestIfRevision_ConcurrentWritersRetryWithoutLoss(t *testing.T) {
+	s := NewStore(t.TempDir())
+	const writers = 8
+
+	// 各ライターは競合したら読み直して自分のノードを足し直す。どのノードも失われないこと。
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node := fmt.Sprintf("node-%d", i)
+			for {
+				rev := s.GetCache("proj-1").Revision
+				_, err := s.MergeNodePositionsIfRevision("proj-1", map[string]NodePosition{node: {X: float64(i)}}, rev)
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, ErrRevisionMismatch) {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	c := s.GetCache("proj-1")
+	if len(c.NodePositions) != writers || c.Revision != writers {
+		t.Fatalf("expected %d nodes at revision %d, got %v at %d", writers, writers, c.NodePositions, c.Revision)
+	}
+}
+
+func TestIfRevision_SyncInAnotherProcess(t *testing.T) {
+	dir := t.TempDir()
+	console := NewStore(dir)
+	console.SetItems("PVT_1", json.RawMessage(`[1]`))
+	if err := console.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	// ブラウザがノードを動かし、まだフラッシュしていない間に sync コマンドが items を書き込む
+	rev, err := console.MergeNodePositionsIfRevision("PVT_1", map[string]NodePosition{"node-1": {X: 1}}, console.GetCache("PVT_1").Revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := NewStore(dir)
+	c := syncer.GetCache("PVT_1")
+	syncedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := syncer.SetSyncedItemsIfRevision("PVT_1", json.RawMessage(`[1,2]`), syncedAt, c.Revision); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	// 同期を始めた後に書き込まれていれば、古い同期結果では上書きしない
+	if _, err := syncer.SetSyncedItemsIfRevision("PVT_1", json.RawMessage(`[]`), syncedAt, c.Revision); !errors.Is(err, ErrRevisionMismatch) {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+
+	if err := console.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]*Store{"console": console, "disk": NewStore(dir)} {
+		c := s.GetCache("PVT_1")
+		if string(c.Items) != `[1,2]` || !c.SyncedAt.Equal(syncedAt) || c.NodePositions["node-1"] != (NodePosition{X: 1}) {
+			t.Fatalf("%s: expected synced items and moved node, got %s %v %v", name, c.Items, c.SyncedAt, c.NodePositions)
+		}
+	}
+	// ブラウザは items が変わったことを知らないので、そのリビジョンでの書き込みは拒む
+	if got, err := console.SetItemsIfRevision("PVT_1", json.RawMessage(`[1]`), rev); !errors.Is(err, ErrRevisionMismatch) || got <= rev {
+		t.Fatalf("expected mismatch at a newer revision than %d, got %d (%v)", rev, got, err)
+	}
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/util"
+)
+
+// lockFileName はキャッシュディレクトリの読み書きを複数のプロセス（console を 2 つ起動した場合や sync コマンド）の
+// 間で直列化するアドバイザリロックのファイル名。キャッシュファイルはリネームで置き換えるため、ディレクトリで 1 つのファイルをロックする。
+const lockFileName = ".lock"
+
+// errCorruptCache はキャッシュファイルが JSON として読めないことを示す。
+var errCorruptCache = errors.New("corrupt cache file")
+
+func (s *Store) cacheFilePath(projectID string) string {
+	return filepath.Join(s.cacheDir, projectID+".json")
+}
+
+// lockDir はキャッシュディレクトリのロックを取り、解放する関数を返す。
+// exclusive でなければ共有ロックを取る。
+func (s *Store) lockDir(exclusive bool) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(s.cacheDir, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache lock: %w", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock cache dir: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// loadFromDisk はキャッシュファイルを読み込み、読み込んだファイルの更新日時とともに返す。
+// ファイルが存在しない場合は os.ErrNotExist を、JSON として読めない場合は errCorruptCache を包んだエラーを返す。
+func (s *Store) loadFromDisk(projectID string) (*ProjectCache, time.Time, error) {
+	// キャッシュディレクトリがなければ読むものもない。ロックファイルを作るためだけにディレクトリは作らない
+	if _, err := os.Stat(s.cacheDir); err != nil {
+		return nil, time.Time{}, err
+	}
+	unlock, err := s.lockDir(false)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer unlock()
+	return s.readCacheFile(projectID)
+}
+
+// readCacheFile はロックを取らずにキャッシュファイルを読み込む。
+func (s *Store) readCacheFile(projectID string) (*ProjectCache, time.Time, error) {
+	f, err := os.Open(s.cacheFilePath(projectID))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var c ProjectCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: %w", errCorruptCache, err)
+	}
+	if c.NodePositions == nil {
+		c.NodePositions = make(map[string]NodePosition)
+	}
+	return &c, info.ModTime(), nil
+}
+
+// writeToDisk はキャッシュファイルを書き出し、書き出した内容とファイルの更新日時を返す。
+// base はこのプロセスが最後に読み書きしたファイルの内容、seen はその更新日時。
+// ロックを取ってからファイルを読み直し、その後に他のプロセスが書き込んでいれば c とマージしてから書き出す。
+// 途中でプロセスが落ちても壊れたファイルが残らないよう、一時ファイルに書いてからリネームで置き換える。
+func (s *Store) writeToDisk(projectID string, c, base *ProjectCache, seen time.Time) (*ProjectCache, time.Time, error) {
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	unlock, err := s.lockDir(true)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer unlock()
+
+	if base == nil {
+		base = &ProjectCache{}
+	}
+	// 読めないファイルは、このプロセスの内容で置き換える
+	if disk, modTime, err := s.readCacheFile(projectID); err == nil && (!modTime.Equal(seen) || disk.Revision != base.Revision) {
+		c = mergeCache(base, c, disk)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to marshal cache: %w", err)
+	}
+	path := s.cacheFilePath(projectID)
+	if err := util.WriteFileAtomic(path, data, 0o644); err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return c, info.ModTime(), nil
+}
+
+// mergeCache は base から ours と disk がそれぞれ変えた内容をまとめる。
+// 座標はノードごとに、このプロセスが動かしたものを優先する。
+// items は変えた側のものを使い、両方が変えていればリビジョンの大きい方（同じなら同期の新しい方）を使う。
+func mergeCache(base, ours, disk *ProjectCache) *ProjectCache {
+	merged := &ProjectCache{
+		Items:         disk.Items,
+		SyncedAt:      disk.SyncedAt,
+		NodePositions: maps.Clone(disk.NodePositions),
+	}
+	if merged.NodePositions == nil {
+		merged.NodePositions = make(map[string]NodePosition)
+	}
+	for id, pos := range ours.NodePositions {
+		if cur, ok := base.NodePositions[id]; !ok || cur != pos {
+			merged.NodePositions[id] = pos
+		}
+	}
+
+	oursChanged := !bytes.Equal(ours.Items, base.Items) || !ours.SyncedAt.Equal(base.SyncedAt)
+	diskChanged := !bytes.Equal(disk.Items, base.Items) || !disk.SyncedAt.Equal(base.SyncedAt)
+	newer := ours.Revision > disk.Revision ||
+		ours.Revision == disk.Revision && !ours.SyncedAt.Before(disk.SyncedAt)
+	if oursChanged && (!diskChanged || newer) {
+		merged.Items, merged.SyncedAt = ours.Items, ours.SyncedAt
+	}
+	// どちらのプロセスが持つリビジョンよりも進め、古いリビジョンでの書き込みを拒めるようにする
+	merged.Revision = max(ours.Revision, disk.Revision) + 1
+	return merged
+}
+
+// quarantine は読めないキャッシュファイルを <projectID>.json.corrupt に移す。
+// ロックを取ってから読み直し、その間に他のプロセスが書き直していれば移さない。
+func (s *Store) quarantine(projectID string) {
+	unlock, err := s.lockDir(true)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	if _, _, err := s.readCacheFile(projectID); !errors.Is(err, errCorruptCache) {
+		return
+	}
+	path := s.cacheFilePath(projectID)
+	os.Rename(path, path+".corrupt")
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestFlush_ReplacesFileAtomically(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	s.SetItems("proj-1", json.RawMessage(`[1,2]`))
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	// 一時ファイルは残らない
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if want := []string{lockFileName, "proj-1.json"}; !slices.Equal(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	info, err := os.Stat(filepath.Join(dir, "proj-1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Fatalf("expected mode 0644, got %o", perm)
+	}
+	if c := NewStore(dir).GetCache("proj-1"); string(c.Items) != `[1,2]` {
+		t.Fatalf("expected [1,2], got %s", c.Items)
+	}
+}
+
+func TestLoad_QuarantinesCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "PVT_1.json")
+	// 書き込み途中で落ちたファイル
+	corrupt := []byte(`{"items":[1,2],"nodePositions":{"node-1":{"x":1`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(dir)
+	if c := s.GetCache("PVT_1"); c.Items != nil || len(c.NodePositions) != 0 {
+		t.Fatalf("expected empty cache, got %s %v", c.Items, c.NodePositions)
+	}
+	got, err := os.ReadFile(path + ".corrupt")
+	if err != nil {
+		t.Fatalf("expected corrupt file to be kept: %v", err)
+	}
+	if string(got) != string(corrupt) {
+		t.Fatalf("expected %s, got %s", corrupt, got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupt file to be moved, got %v", err)
+	}
+
+	// 退避したファイルは一覧に出ず、新しいキャッシュは普通に書ける
+	s.SetItems("PVT_1", json.RawMessage(`[3]`))
+	if err := s.FlushAll(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	entries, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ProjectID != "PVT_1" || entries[0].ItemCount != 1 {
+		t.Fatalf("expected PVT_1 with 1 item, got %+v", entries)
+	}
+}
+
+func TestFlush_ConcurrentProcessesNeverTearFiles(t *testing.T) {
+	dir := t.TempDir()
+	const writers, rounds = 4, 20
+
+	// 同じディレクトリを使う Store をプロセスに見立て、書き込みと読み込みを並行させる
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewStore(dir)
+			for r := range rounds {
+				items := make([]int, 100*(i+1)+r)
+				data, _ := json.Marshal(items)
+				s.SetItems("proj-1", data)
+				if err := s.FlushAll(); err != nil {
+					t.Errorf("flush failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(errs)
+		s := NewStore(dir)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, _, err := s.loadFromDisk("proj-1"); err != nil && !os.IsNotExist(err) {
+				errs <- fmt.Errorf("read a torn file: %w", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "proj-1.json.corrupt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no corrupt file, got %v", err)
+	}
+	if c := NewStore(dir).GetCache("proj-1"); c.ItemCount() == 0 {
+		t.Fatalf("expected items, got %s", c.Items)
+	}
+}
+
+func TestFlush_MergesWritesOfOtherProcesses(t *testing.T) {
+	dir := t.TempDir()
+	seed := NewStore(dir)
+	seed.SetItems("PVT_1", json.RawMessage(`[1]`))
+	seed.MergeNodePositions("PVT_1", map[string]NodePosition{"n1": {X: 1}})
+	if err := seed.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同じファイルを読み込んだ 2 つのプロセスが、それぞれ別の変更をする
+	a, b := NewStore(dir), NewStore(dir)
+	a.MergeNodePositions("PVT_1", map[string]NodePosition{"n2": {X: 2}})
+	b.MergeNodePositions("PVT_1", map[string]NodePosition{"n3": {X: 3}})
+	b.SetItems("PVT_1", json.RawMessage(`[1,2]`))
+	changes, unsubscribe := a.Subscribe()
+	defer unsubscribe()
+	if err := b.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	before := a.GetCache("PVT_1").Revision
+	if err := a.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]NodePosition{"n1": {X: 1}, "n2": {X: 2}, "n3": {X: 3}}
+	disk, err := NewStore(dir).Load("PVT_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(disk.Items) != `[1,2]` || !maps.Equal(disk.NodePositions, want) {
+		t.Fatalf("expected both writes on disk, got %s %v", disk.Items, disk.NodePositions)
+	}
+	// マージした内容はメモリにも反映し、購読者に通知する
+	c := a.GetCache("PVT_1")
+	if string(c.Items) != `[1,2]` || !maps.Equal(c.NodePositions, want) || c.Revision <= before {
+		t.Fatalf("expected merged cache in memory, got %+v", c)
+	}
+	select {
+	case change := <-changes:
+		if !change.Items || change.Revision != c.Revision {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	default:
+		t.Fatal("expected a change notification")
+	}
+
+	// 古い内容から書き込んだ B も、A の書き込みを消さない
+	b.MergeNodePositions("PVT_1", map[string]NodePosition{"n4": {X: 4}})
+	if err := b.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	want["n4"] = NodePosition{X: 4}
+	if c := NewStore(dir).GetCache("PVT_1"); !maps.Equal(c.NodePositions, want) {
+		t.Fatalf("expected %v, got %v", want, c.NodePositions)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows)
+
+package cache
+
+import "os"
+
+// ファイルロックのないプラットフォームでは、一時ファイルからのリネームによる書き込みの原子性だけを保証する。
+
+func lockFile(*os.File, bool) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// Entry はディスク上のキャッシュファイル1件の概要を表す。
+type Entry struct {
+	ProjectID string    `json:"projectId"`
+	Size      int64     `json:"size"`
+	ItemCount int       `json:"itemCount"`
+	ModTime   time.Time `json:"modTime"`
+}
+
+// List はディスク上のキャッシュファイルの概要をプロジェクト ID 順で返す。
+// キャッシュディレクトリには監査ログなども置くため、ファイル名がプロジェクト ID でないものは含めない。
+// キャッシュディレクトリが存在しない場合は空を返す。
+func (s *Store) List() ([]Entry, error) {
+	files, err := os.ReadDir(s.cacheDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache dir: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, f := range files {
+		projectID, ok := strings.CutSuffix(f.Name(), ".json")
+		if f.IsDir() || !ok || !github.IsProjectID(projectID) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat cache file: %w", err)
+		}
+		entry := Entry{ProjectID: projectID, Size: info.Size(), ModTime: info.ModTime()}
+		// 読めないファイルも一覧には出し、件数は 0 とする
+		if c, _, err := s.loadFromDisk(projectID); err == nil {
+			entry.ItemCount = c.ItemCount()
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.ProjectID, b.ProjectID) })
+	return entries, nil
+}
+
+// Load は指定プロジェクトのキャッシュをディスクから読み込む。
+// ファイルが存在しない場合は os.ErrNotExist を包んだエラーを返す。
+func (s *Store) Load(projectID string) (*ProjectCache, error) {
+	c, _, err := s.loadFromDisk(projectID)
+	return c, err
+}
+
+// Remove は指定プロジェクトのキャッシュをメモリとディスクから削除する。
+func (s *Store) Remove(projectID string) error {
+	s.mu.Lock()
+	delete(s.caches, projectID)
+	delete(s.dirty, projectID)
+	delete(s.modTimes, projectID)
+	delete(s.bases, projectID)
+	s.mu.Unlock()
+
+	if err := os.Remove(s.cacheFilePath(projectID)); err != nil {
+		return fmt.Errorf("failed to remove cache for %s: %w", projectID, err)
+	}
+	return nil
+}
+
+// Prune は最終書き込みが olderThan より前のキャッシュを削除し、削除したプロジェクト ID を返す。
+func (s *Store) Prune(olderThan time.Duration) ([]string, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	threshold := time.Now().Add(-olderThan)
+
+	removed := []string{}
+	for _, e := range entries {
+		if !e.ModTime.Before(threshold) {
+			continue
+		}
+		if err := s.Remove(e.ProjectID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, e.ProjectID)
+	}
+	return removed, nil
+}
+
+// ItemCount は items に含まれる要素数を返す。items が配列でない場合は 0 を返す。
+func (c *ProjectCache) ItemCount() int {
+	var items []json.RawMessage
+	if err := json.Unmarshal(c.Items, &items); err != nil {
+		return 0
+	}
+	return len(items)
+}
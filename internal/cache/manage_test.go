@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("PVT_b", json.RawMessage(`[{"id":1},{"id":2}]`))
+	s.MergeNodePositions("PVT_a", map[string]NodePosition{"node-1": {X: 1, Y: 2}})
+	s.FlushAll()
+	// プロジェクト以外のファイルは一覧に出さない
+	for _, name := range []string{"notes.txt", "history.json", "PVT_c.json.corrupt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("ignored"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if entries[0].ProjectID != "PVT_a" || entries[0].ItemCount != 0 {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].ProjectID != "PVT_b" || entries[1].ItemCount != 2 || entries[1].Size == 0 {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestList_MissingDir(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "missing"))
+	entries, err := s.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+}
+
+func TestLoad_NotExist(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if _, err := s.Load("proj-1"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+	s.FlushAll()
+
+	if err := s.Remove("proj-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "proj-1.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected cache file to be removed, got %v", err)
+	}
+	if c := s.GetCache("proj-1"); c.Items != nil {
+		t.Fatalf("expected in-memory cache to be removed, got %s", c.Items)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("PVT_old", json.RawMessage(`[1]`))
+	s.SetItems("PVT_new", json.RawMessage(`[2]`))
+	s.FlushAll()
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "PVT_old.json"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(removed, []string{"PVT_old"}) {
+		t.Fatalf("expected [PVT_old] to be removed, got %v", removed)
+	}
+	if _, err := s.Load("PVT_new"); err != nil {
+		t.Fatalf("expected new cache to remain, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"bytes"
+	"maps"
+	"os"
+)
+
+// changeBufferSize は購読者ごとに溜めておける通知の数。
+const changeBufferSize = 64
+
+// Change はキャッシュの変更通知を表す。
+type Change struct {
+	ProjectID string `json:"projectId"`
+	// Revision は変更後のリビジョン。
+	Revision int64 `json:"revision"`
+	// Items は items の内容が変わったことを示す。
+	Items bool `json:"items,omitempty"`
+	// NodePositions は座標が変わったノード。他のプロセスの書き込みを読み直した場合は全ノードの座標。
+	NodePositions map[string]NodePosition `json:"nodePositions,omitempty"`
+}
+
+// Subscribe はキャッシュの変更通知を受け取るチャネルと、購読をやめる関数を返す。
+// 購読者が受け取りきれず通知が溢れた場合はチャネルを閉じる。
+// その場合はキャッシュを読み直してから購読し直すこと。
+func (s *Store) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, changeBufferSize)
+	s.subMu.Lock()
+	s.subs[ch] = struct{}{}
+	s.subMu.Unlock()
+
+	return ch, func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		if _, ok := s.subs[ch]; ok {
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Store) publish(c Change) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- c:
+		default:
+			// 通知を落とすと購読者が古い内容のままになるため、閉じて読み直させる
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// reloadChanged は他のプロセス（sync コマンドなど）が書き換えたキャッシュファイルを読み直し、購読者に通知する。
+// フラッシュしていない変更があるプロジェクトは、このプロセスの内容を優先して読み直さない。
+func (s *Store) reloadChanged() {
+	s.mu.RLock()
+	known := make(map[string]bool, len(s.caches))
+	for id := range s.caches {
+		known[id] = !s.dirty[id]
+	}
+	modTimes := maps.Clone(s.modTimes)
+	s.mu.RUnlock()
+
+	for id, clean := range known {
+		if !clean {
+			continue
+		}
+		info, err := os.Stat(s.cacheFilePath(id))
+		if err != nil || info.ModTime().Equal(modTimes[id]) {
+			continue
+		}
+		loaded, modTime, err := s.loadFromDisk(id)
+		if err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		cur, ok := s.caches[id]
+		if !ok || s.dirty[id] {
+			s.mu.Unlock()
+			continue
+		}
+		// リビジョンを書き換える前の、ファイルのままの内容を基準として覚えておく
+		base := *loaded
+		change := Change{ProjectID: id, Items: !bytes.Equal(cur.Items, loaded.Items)}
+		if !maps.Equal(cur.NodePositions, loaded.NodePositions) {
+			change.NodePositions = maps.Clone(loaded.NodePositions)
+		}
+		// 他のプロセスが同じリビジョンから書き込んだ場合も、このプロセスでリビジョンが戻ったり重なったりしないようにする
+		switch {
+		case change.Items || change.NodePositions != nil:
+			loaded.Revision = max(loaded.Revision, cur.Revision+1)
+		default:
+			loaded.Revision = max(loaded.Revision, cur.Revision)
+		}
+		change.Revision = loaded.Revision
+		s.caches[id] = loaded
+		s.modTimes[id] = modTime
+		s.bases[id] = &base
+		s.mu.Unlock()
+
+		if change.Items || change.NodePositions != nil {
+			s.publish(change)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan Change) Change {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return c
+	default:
+		t.Fatal("expected a change")
+	}
+	return Change{}
+}
+
+func TestSubscribe_NotifiesChanges(t *testing.T) {
+	s := NewStore(t.TempDir())
+	ch, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+	if c := receive(t, ch); c.ProjectID != "proj-1" || !c.Items {
+		t.Fatalf("unexpected change %+v", c)
+	}
+	// 同じ内容の書き込みは通知しない
+	s.SetSyncedItems("proj-1", json.RawMessage(`[1]`), time.Now())
+	s.MergeNodePositions("proj-1", map[string]NodePosition{"a": {X: 1, Y: 2}})
+	s.MergeNodePositions("proj-1", map[string]NodePosition{"a": {X: 1, Y: 2}, "b": {X: 3, Y: 4}})
+
+	if c := receive(t, ch); len(c.NodePositions) != 1 || c.NodePositions["a"] != (NodePosition{X: 1, Y: 2}) {
+		t.Fatalf("unexpected change %+v", c)
+	}
+	if c := receive(t, ch); len(c.NodePositions) != 1 || c.NodePositions["b"] != (NodePosition{X: 3, Y: 4}) {
+		t.Fatalf("expected only the moved node, got %+v", c)
+	}
+	select {
+	case c := <-ch:
+		t.Fatalf("unexpected change %+v", c)
+	default:
+	}
+}
+
+func TestSubscribe_ClosesOnOverflow(t *testing.T) {
+	s := NewStore(t.TempDir())
+	ch, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	for i := range changeBufferSize + 1 {
+		s.SetItems("proj-1", json.RawMessage(fmt.Sprintf("[%d]", i)))
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != changeBufferSize {
+		t.Fatalf("expected %d buffered changes before close, got %d", changeBufferSize, n)
+	}
+}
+
+func TestReloadChanged_PicksUpOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.SetItems("proj-1", json.RawMessage(`[1]`))
+	if err := s.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	ch, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	// 自分の書き込みは変更として扱わない
+	s.reloadChanged()
+	select {
+	case c := <-ch:
+		t.Fatalf("unexpected change %+v", c)
+	default:
+	}
+
+	// 別プロセス（sync コマンドなど）の書き込み
+	other := NewStore(dir)
+	other.SetItems("proj-1", json.RawMessage(`[2]`))
+	other.MergeNodePositions("proj-1", map[string]NodePosition{"a": {X: 1, Y: 2}})
+	if err := other.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	// 更新日時の粒度が粗いファイルシステムでも変更として見えるようにする
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(s.cacheFilePath("proj-1"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	s.reloadChanged()
+	c := receive(t, ch)
+	if !c.Items || c.NodePositions["a"] != (NodePosition{X: 1, Y: 2}) {
+		t.Fatalf("unexpected change %+v", c)
+	}
+	if got := s.GetCache("proj-1").Items; string(got) != `[2]` {
+		t.Fatalf("expected reloaded items [2], got %s", got)
+	}
+}
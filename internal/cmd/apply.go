@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/kmtym1998/gh-issue-treefier/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+// openDryRunJournal は console --dry-run が書き込みを記録するジャーナルを開く。
+func openDryRunJournal() (*journal.Journal, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return journal.Open(filepath.Join(dir, "dry-run-journal.jsonl"))
+}
+
+func newApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Send the changes recorded by console --dry-run to GitHub",
+		Long: "Send the changes recorded by console --dry-run to GitHub in the order they were made.\n" +
+			"IDs and issue numbers the console made up for new issues are replaced with the real ones.\n" +
+			"If a request fails, the remaining changes stay in the journal so apply can be run again.",
+		Example: "  gh issue-treefier apply --list\n" +
+			"  gh issue-treefier apply",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(cmd, args)
+		},
+	}
+
+	cmd.Flags().Bool("list", false, "Print the recorded changes without sending them")
+	cmd.Flags().Bool("discard", false, "Delete the recorded changes without sending them")
+	cmd.MarkFlagsMutuallyExclusive("list", "discard")
+
+	return cmd
+}
+
+// journalExecutor は記録したリクエストを go-gh のクライアントで送信し、監査ログに記録する。
+type journalExecutor struct {
+	rest    *api.RESTClient
+	gql     *api.GraphQLClient
+	audit   *audit.Log
+	targets *audit.Resolver
+}
+
+func (e *journalExecutor) REST(method, path string, body []byte) ([]byte, error) {
+	var r io.Reader
+	if len(body) > 0 {
+		r = bytes.NewReader(body)
+	}
+	entry := audit.Entry{
+		API:       "rest",
+		Method:    method,
+		Path:      path,
+		Operation: method + " " + path,
+		Targets:   audit.RESTTargets(path, body),
+	}
+	if len(body) > 0 {
+		entry.Variables = body
+	}
+
+	resp, err := e.rest.Request(method, path, r)
+	if err != nil {
+		e.record(entry, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	entry.Status = resp.StatusCode
+	data, err := io.ReadAll(resp.Body)
+	e.record(entry, err)
+	return data, err
+}
+
+func (e *journalExecutor) GraphQL(query string, variables map[string]any) ([]byte, error) {
+	vars, _ := json.Marshal(variables)
+	entry := audit.Entry{
+		API:       "graphql",
+		Operation: graphqlOperationName(query),
+		Targets:   e.targets.Resolve(context.Background(), audit.GraphQLTargets(vars)),
+	}
+	if len(variables) > 0 {
+		entry.Variables = vars
+	}
+
+	var resp json.RawMessage
+	err := e.gql.Do(query, variables, &resp)
+	entry.Status = http.StatusOK
+	e.record(entry, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// record は送信結果を監査ログに追記する。監査ログの書き込みに失敗しても送信結果は変えない。
+func (e *journalExecutor) record(entry audit.Entry, sendErr error) {
+	entry.Source = audit.SourceApply
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+		var httpErr *api.HTTPError
+		switch {
+		case errors.As(sendErr, &httpErr):
+			entry.Status = httpErr.StatusCode
+		case !errors.As(sendErr, new(*api.GraphQLError)):
+			entry.Status = 0
+		}
+	}
+	if err := e.audit.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// graphqlOperationName は mutation の最初のフィールド名を返す。取り出せなければ "graphql" を返す。
+func graphqlOperationName(query string) string {
+	_, body, ok := strings.Cut(query, "{")
+	if !ok {
+		return "graphql"
+	}
+	name := strings.TrimSpace(body)
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	}); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return "graphql"
+	}
+	return name
+}
+
+func runApply(cmd *cobra.Command, _ []string) error {
+	list, err := cmd.Flags().GetBool("list")
+	if err != nil {
+		return fmt.Errorf("failed to read list flag: %w", err)
+	}
+	discard, err := cmd.Flags().GetBool("discard")
+	if err != nil {
+		return fmt.Errorf("failed to read discard flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+
+	j, err := openDryRunJournal()
+	if err != nil {
+		return err
+	}
+	entries, err := j.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No recorded changes.")
+		return nil
+	}
+
+	switch {
+	case list:
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SEQ\tRECORDED\tOPERATION")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "#%d\t%s\t%s\n", e.Seq, e.Time.Local().Format("2006-01-02 15:04:05"), e.Operation)
+		}
+		return tw.Flush()
+	case discard:
+		if err := j.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Discarded %d recorded changes.\n", len(entries))
+		return nil
+	}
+
+	restClient, err := api.DefaultRESTClient()
+	if err != nil {
+		return fmt.Errorf("failed to create REST client: %w", err)
+	}
+	gqlClient, err := api.DefaultGraphQLClient()
+	if err != nil {
+		return fmt.Errorf("failed to create GraphQL client: %w", err)
+	}
+
+	auditLog, err := openAuditLog()
+	if err != nil {
+		return err
+	}
+
+	ex := &journalExecutor{rest: restClient, gql: gqlClient, audit: auditLog, targets: audit.NewResolver(gqlClient.DoWithContext)}
+	remaining, applyErr := journal.Apply(entries, ex, out)
+	if err := j.Replace(remaining); err != nil {
+		return err
+	}
+	if applyErr != nil {
+		return fmt.Errorf("%w (%d changes left in the journal)", applyErr, len(remaining))
+	}
+	fmt.Fprintf(out, "Applied %d changes.\n", len(entries))
+	return nil
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// cacheDir はキャッシュファイルを置くディレクトリを返す。
+func cacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "gh-issue-treefier"), nil
+}
+
+func newCacheStore() (*cache.Store, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewStore(dir), nil
+}
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and manage the local project cache",
+	}
+
+	cmd.AddCommand(newCacheLsCmd())
+	cmd.AddCommand(newCacheShowCmd())
+	cmd.AddCommand(newCacheClearCmd())
+	cmd.AddCommand(newCachePruneCmd())
+
+	return cmd
+}
+
+func newCacheLsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List cached projects",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheLs(cmd, args)
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func newCacheShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <project-id>",
+		Short: "Print the cached data of a project as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheShow(cmd, args)
+		},
+	}
+}
+
+func newCacheClearCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear [<project-id> | --all]",
+		Short: "Delete the cache of a project or of all projects",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheClear(cmd, args)
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Delete the cache of all projects")
+
+	return cmd
+}
+
+func newCachePruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Delete caches that have not been written for a while",
+		Example: "  gh issue-treefier cache prune --older-than 30d",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCachePrune(cmd, args)
+		},
+	}
+
+	cmd.Flags().String("older-than", "30d", "Age of the last write, e.g. 30d or 12h")
+
+	return cmd
+}
+
+// cacheListEntry は cache ls の1行分。タイトルは GitHub から取得できた場合のみ設定する。
+type cacheListEntry struct {
+	cache.Entry
+	Title string `json:"title,omitempty"`
+}
+
+func runCacheLs(cmd *cobra.Command, _ []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("failed to read json flag: %w", err)
+	}
+
+	store, err := newCacheStore()
+	if err != nil {
+		return err
+	}
+	entries, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	list := lo.Map(entries, func(e cache.Entry, _ int) cacheListEntry {
+		return cacheListEntry{Entry: e}
+	})
+	// タイトルは表示を補うためのものなので、取得できなくても一覧は出す
+	if titles := projectTitles(lo.Map(entries, func(e cache.Entry, _ int) string { return e.ProjectID })); titles != nil {
+		for i := range list {
+			list[i].Title = titles[list[i].ProjectID]
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintln(out, "No cached projects.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT ID\tSIZE\tITEMS\tLAST WRITE\tTITLE")
+	for _, e := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
+			e.ProjectID, formatSize(e.Size), e.ItemCount, e.ModTime.Format(time.DateTime), e.Title)
+	}
+	return tw.Flush()
+}
+
+func runCacheShow(cmd *cobra.Command, args []string) error {
+	if !github.IsProjectID(args[0]) {
+		return fmt.Errorf("invalid project ID %q", args[0])
+	}
+	store, err := newCacheStore()
+	if err != nil {
+		return err
+	}
+	c, err := store.Load(args[0])
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no cache for project %s", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return fmt.Errorf("failed to read all flag: %w", err)
+	}
+	if all == (len(args) == 1) {
+		return errors.New("specify either a project ID or --all")
+	}
+	if len(args) == 1 && !github.IsProjectID(args[0]) {
+		return fmt.Errorf("invalid project ID %q", args[0])
+	}
+
+	store, err := newCacheStore()
+	if err != nil {
+		return err
+	}
+
+	ids := args
+	if all {
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+		ids = lo.Map(entries, func(e cache.Entry, _ int) string { return e.ProjectID })
+	}
+
+	out := cmd.OutOrStdout()
+	for _, id := range ids {
+		err := store.Remove(id)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no cache for project %s", id)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed cache for %s\n", id)
+	}
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, _ []string) error {
+	olderThan, err := cmd.Flags().GetString("older-than")
+	if err != nil {
+		return fmt.Errorf("failed to read older-than flag: %w", err)
+	}
+	age, err := parseAge(olderThan)
+	if err != nil {
+		return err
+	}
+
+	store, err := newCacheStore()
+	if err != nil {
+		return err
+	}
+	removed, err := store.Prune(age)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	for _, id := range removed {
+		fmt.Fprintf(out, "Removed cache for %s\n", id)
+	}
+	fmt.Fprintf(out, "Pruned %d cache(s).\n", len(removed))
+	return nil
+}
+
+// projectTitles はプロジェクト ID からタイトルへの対応を返す。取得できない場合は nil を返す。
+func projectTitles(ids []string) map[string]string {
+	if len(ids) == 0 {
+		return nil
+	}
+	gw, err := newProjectGateway()
+	if err != nil {
+		return nil
+	}
+	projects, err := gw.GetProjects(ids)
+	if err != nil {
+		return nil
+	}
+	return lo.SliceToMap(projects, func(p github.Project) (string, string) { return p.ID, p.Title })
+}
+
+// parseAge は 30d のような日数指定、または time.ParseDuration 形式の期間を解釈する。
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Detect cycles in the issue dependency graph",
+		Long: "Detect cycles in the sub-issue and blocked-by dependencies of a project.\n" +
+			"Exits with status 1 when any cycle is found.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheck(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+
+	return cmd
+}
+
+func runCheck(cmd *cobra.Command, _ []string) error {
+	g, _, _, err := loadProjectGraph(cmd)
+	if err != nil {
+		return err
+	}
+
+	cycles := graph.FindCycles(g)
+	out := cmd.OutOrStdout()
+	if len(cycles) == 0 {
+		fmt.Fprintln(out, "No cycles found.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Found %d cycle(s):\n", len(cycles))
+	for _, c := range cycles {
+		fmt.Fprintf(out, "  %s -> %s\n", strings.Join(c, " -> "), c[0])
+	}
+
+	// 循環の検出は実行時エラーではないため usage は表示しない
+	cmd.SilenceUsage = true
+	return fmt.Errorf("found %d cycle(s)", len(cycles))
+}
@@ -2,27 +2,22 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net"
-	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
-
-	"path/filepath"
+	"syscall"
 	"time"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/prompter"
 	"github.com/cli/go-gh/v2/pkg/repository"
-	"github.com/cli/go-gh/v2/pkg/term"
-	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
-	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+	"github.com/kmtym1998/gh-issue-treefier/internal/journal"
 	"github.com/kmtym1998/gh-issue-treefier/internal/server"
 	"github.com/kmtym1998/gh-issue-treefier/internal/util"
-	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 )
 
@@ -35,15 +30,23 @@ func newConsoleCmd() *cobra.Command {
 		},
 	}
 
+	addProjectFlags(cmd)
+	cmd.Flags().String("host", "127.0.0.1", "Address to listen on (use 0.0.0.0 to accept connections from other machines)")
 	cmd.Flags().Int("port", 7000, "Port to listen on")
-	cmd.Flags().StringP("repo", "R", "", "Repository in OWNER/REPO format")
-	cmd.Flags().StringP("project-id", "p", "", "Project ID (skips interactive selection)")
 	cmd.Flags().Bool("no-browser", false, "Do not open the browser automatically")
+	cmd.Flags().Bool("read-only", false, "Reject every request that modifies issues or projects")
+	cmd.Flags().Bool("dry-run", false, "Record changes in a journal instead of sending them to GitHub (send them later with apply)")
+	cmd.Flags().Duration("refresh-interval", 2*time.Minute, "Interval to refresh the open projects from GitHub in the background (0 to disable)")
+	cmd.MarkFlagsMutuallyExclusive("read-only", "dry-run")
 
 	return cmd
 }
 
 func runConsole(cmd *cobra.Command, _ []string) error {
+	host, err := cmd.Flags().GetString("host")
+	if err != nil {
+		return fmt.Errorf("failed to read host flag: %w", err)
+	}
 	port, err := cmd.Flags().GetInt("port")
 	if err != nil {
 		return fmt.Errorf("failed to read port flag: %w", err)
@@ -65,30 +68,77 @@ func runConsole(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read no-browser flag: %w", err)
 	}
+	readOnly, err := cmd.Flags().GetBool("read-only")
+	if err != nil {
+		return fmt.Errorf("failed to read read-only flag: %w", err)
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("failed to read dry-run flag: %w", err)
+	}
+	refreshInterval, err := cmd.Flags().GetDuration("refresh-interval")
+	if err != nil {
+		return fmt.Errorf("failed to read refresh-interval flag: %w", err)
+	}
+	if refreshInterval < 0 {
+		return fmt.Errorf("invalid refresh-interval %s: must not be negative", refreshInterval)
+	}
 
-	ln, err := listenWithFallback(port, !cmd.Flags().Changed("port"))
+	ln, err := listenWithFallback(host, port, !cmd.Flags().Changed("port"))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 	actualPort := ln.Addr().(*net.TCPAddr).Port
 
-	homeDir, err := os.UserHomeDir()
+	cacheStore, err := newCacheStore()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
-	cacheDir := filepath.Join(homeDir, ".cache", "gh-issue-treefier")
-	cacheStore := cache.NewStore(cacheDir)
 	cacheStore.Start(5 * time.Second)
 	defer cacheStore.Stop()
 
-	srv := server.New(actualPort, cacheStore)
+	gw, err := newProjectGateway()
+	if err != nil {
+		return err
+	}
+	var dryRunJournal *journal.Journal
+	if dryRun {
+		if dryRunJournal, err = openDryRunJournal(); err != nil {
+			return err
+		}
+		fmt.Printf("Dry-run mode: changes are recorded in %s. Run `gh issue-treefier apply` to send them.\n", dryRunJournal.Path())
+	}
+
+	auditLog, err := openAuditLog()
+	if err != nil {
+		return err
+	}
+	historyStacks, err := openHistory()
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(server.Config{
+		Host:       host,
+		Port:       actualPort,
+		CacheStore: cacheStore,
+		Gateway:    gw,
+		// 開発時は固定のトークンを使えるようにする
+		Token:         os.Getenv("TREEFIER_SESSION_TOKEN"),
+		ReadOnly:      readOnly,
+		DryRunJournal: dryRunJournal,
+		AuditLog:      auditLog,
+		History:       historyStacks,
+		// キャッシュの定期フラッシュと並行して、開いているプロジェクトを GitHub から更新し続ける
+		RefreshInterval: refreshInterval,
+	})
 
 	repo, err := resolveRepo(repoOverride)
 	if err != nil {
 		return err
 	}
 
-	openURL, err := buildURL(actualPort, repo, projectID)
+	openURL, err := buildURL(host, actualPort, repo, projectID, srv.Token())
 	if err != nil {
 		return err
 	}
@@ -100,29 +150,48 @@ func runConsole(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
-	if err := srv.Start(ln); err != nil {
-		if errors.Is(err, context.Canceled) || errors.Is(err, http.ErrServerClosed) {
-			return nil
-		}
+	// Ctrl+C や SIGTERM を受けたら新規接続を止め、処理中のリクエストとキャッシュの書き出しを待って終了する
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stopNotice := context.AfterFunc(ctx, func() {
+		fmt.Fprintln(os.Stderr, "Shutting down...")
+	})
+	defer stopNotice()
+
+	if err := srv.Start(ctx, ln); err != nil {
 		return fmt.Errorf("server error: %w", err)
 	}
 
 	return nil
 }
 
+// maxHistorySteps はコンソールで取り消せる変更の最大数。
+const maxHistorySteps = 100
+
+// openHistory はコンソールの取り消し・やり直しの履歴を開く。履歴はプロジェクトごとで、
+// ページを再読み込みしても残るようキャッシュディレクトリに保存する。
+// キャッシュディレクトリ直下の *.json はプロジェクトのキャッシュとして扱われるため、サブディレクトリに置く。
+func openHistory() (*history.Stacks, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return history.OpenDir(filepath.Join(dir, "history"), maxHistorySteps), nil
+}
+
 const maxPortFallbackAttempts = 10
 
-// listenWithFallback は指定ポートでリッスンを試みる。
+// listenWithFallback は指定ホスト・ポートでリッスンを試みる。
 // fallback が true（--port 未指定）の場合、ポートが使用中なら port+1, port+2, ... と順に試行する。
 // fallback が false（--port 明示指定）の場合、指定ポートのみを試みる。
-func listenWithFallback(port int, fallback bool) (net.Listener, error) {
-	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+func listenWithFallback(host string, port int, fallback bool) (net.Listener, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
 	if err == nil || !fallback {
 		return ln, err
 	}
 
 	for i := 1; i < maxPortFallbackAttempts; i++ {
-		ln, err = net.Listen("tcp", fmt.Sprintf(":%d", port+i))
+		ln, err = net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port+i)))
 		if err == nil {
 			return ln, nil
 		}
@@ -142,55 +211,25 @@ func resolveRepo(repoOverride string) (repository.Repository, error) {
 	return repository.Repository{Owner: parts[0], Name: parts[1]}, nil
 }
 
-func buildURL(port int, repo repository.Repository, projectID string) (string, error) {
-	u := fmt.Sprintf("http://localhost:%d", port)
+// buildURL はブラウザで開く URL を組み立てる。セッショントークンはクエリパラメータで渡す。
+func buildURL(host string, port int, repo repository.Repository, projectID, token string) (string, error) {
+	// ループバックや全インターフェースで待ち受けている場合は localhost で開く
+	if ip := net.ParseIP(host); host == "" || ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	u := "http://" + net.JoinHostPort(host, strconv.Itoa(port))
 	q := url.Values{}
 	q.Set("owner", repo.Owner)
+	q.Set("token", token)
 
-	if projectID != "" {
-		q.Set("project_id", projectID)
-		return u + "?" + q.Encode(), nil
-	}
-
-	term := term.FromEnv()
-	in, ok := term.In().(*os.File)
-	if !ok {
-		return "", errors.New("failed to initialize prompter")
-	}
-	out, ok := term.Out().(*os.File)
-	if !ok {
-		return "", errors.New("failed to initialize prompter")
-	}
-	errOut, ok := term.ErrOut().(*os.File)
-	if !ok {
-		return "", errors.New("failed to initialize prompter")
-	}
-
-	gqlClient, err := api.DefaultGraphQLClient()
-	if err != nil {
-		return "", fmt.Errorf("failed to create GraphQL client: %w", err)
-	}
-	gw := github.NewProjectGateway(gqlClient)
-	projects, err := gw.ListRepoProjects(repo.Owner, repo.Name)
-	if err != nil {
-		return "", fmt.Errorf("failed to list projects: %w", err)
-	}
-	if len(projects) == 0 {
-		return "", fmt.Errorf("no projects found in repository %s/%s", repo.Owner, repo.Name)
-	}
-	p := prompter.New(in, out, errOut)
-	selected, err := p.Select(
-		"Select a project",
-		"",
-		lo.Map(projects, func(p github.Project, _ int) string {
-			return fmt.Sprintf("#%d %s", p.Number, p.Title)
-		}),
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to prompt for project: %w", err)
+	if projectID == "" {
+		selected, err := selectProject(repo)
+		if err != nil {
+			return "", err
+		}
+		projectID = selected.ID
 	}
-	selectedProject := projects[selected]
-	q.Set("project_id", selectedProject.ID)
+	q.Set("project_id", projectID)
 
 	return u + "?" + q.Encode(), nil
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/spf13/cobra"
+)
+
+func newCriticalPathCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "critical-path",
+		Short: "Show the longest chain of open blocked-by dependencies",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCriticalPath(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().String("weight-field", "", "Weight issues by a NUMBER project field (e.g. Estimate)")
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runCriticalPath(cmd *cobra.Command, _ []string) error {
+	weightField, err := cmd.Flags().GetString("weight-field")
+	if err != nil {
+		return fmt.Errorf("failed to read weight-field flag: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("failed to read json flag: %w", err)
+	}
+
+	g, gw, projectID, err := loadProjectGraph(cmd)
+	if err != nil {
+		return err
+	}
+
+	weight := graph.UnitWeight
+	if weightField != "" {
+		fields, err := gw.ListProjectFields(projectID)
+		if err != nil {
+			return fmt.Errorf("failed to list project fields: %w", err)
+		}
+		field, ok := github.FindProjectField(fields, weightField)
+		if !ok {
+			return fmt.Errorf("field %q not found in project", weightField)
+		}
+		if field.DataType != "NUMBER" {
+			return fmt.Errorf("field %q is %s, expected NUMBER", field.Name, field.DataType)
+		}
+		weight = graph.NumberFieldWeight(g, field.ID)
+	}
+
+	path, err := graph.FindCriticalPath(g, weight)
+	if errors.Is(err, graph.ErrCycle) {
+		return fmt.Errorf("%w (run `gh issue-treefier check` to find it)", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(path)
+	}
+
+	if len(path.IDs) == 0 {
+		fmt.Fprintln(out, "No open issues.")
+		return nil
+	}
+	if weightField != "" {
+		fmt.Fprintf(out, "Critical path: %d issue(s), total %s %g\n", len(path.IDs), weightField, path.Total)
+	} else {
+		fmt.Fprintf(out, "Critical path: %d issue(s)\n", len(path.IDs))
+	}
+	for _, id := range path.IDs {
+		line := "  " + id
+		if issue, ok := g.Issue(id); ok {
+			line += " " + issue.Title
+		}
+		if weightField != "" {
+			line += fmt.Sprintf(" (%g)", weight(id))
+		}
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func newExportCmd() *cobra.Command {
+	formats := strings.Join(lo.Map(graph.Formats, func(f graph.Format, _ int) string { return string(f) }), ", ")
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the issue dependency graph as DOT, Mermaid or JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().StringP("format", "f", string(graph.FormatMermaid), "Output format ("+formats+")")
+	cmd.Flags().StringP("output", "o", "", "Write to the given file instead of stdout")
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, _ []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to read format flag: %w", err)
+	}
+	if !slices.Contains(graph.Formats, graph.Format(format)) {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("failed to read output flag: %w", err)
+	}
+
+	g, _, _, err := loadProjectGraph(cmd)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return graph.Export(cmd.OutOrStdout(), g, graph.Format(format))
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := graph.Export(f, g, graph.Format(format)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/outline"
+	"github.com/spf13/cobra"
+)
+
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Create issues and dependencies from a YAML or Markdown outline",
+		Long: "Create issues and dependencies from a YAML (.yaml, .yml) or Markdown (.md) outline.\n" +
+			"Missing issues are created in the repository of --repo, nesting becomes sub-issue\n" +
+			"relationships and blocked-by annotations become blocked-by relationships.\n" +
+			"Issues already in the project with the same title are reused instead of created.",
+		Example: "  gh issue-treefier import plan.md --project-id PVT_xxx\n" +
+			"  gh issue-treefier import plan.yaml -R owner/repo --dry-run",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().Bool("dry-run", false, "Print the changes without applying them")
+
+	return cmd
+}
+
+// importExecutor は取り込み計画の実行に必要な Gateway をまとめたもの。
+type importExecutor struct {
+	*github.IssueGateway
+	*github.DependencyGateway
+	*github.ProjectGateway
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("failed to read dry-run flag: %w", err)
+	}
+	repoOverride, err := cmd.Flags().GetString("repo")
+	if err != nil {
+		return fmt.Errorf("failed to read repo flag: %w", err)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read outline: %w", err)
+	}
+	roots, err := outline.Parse(args[0], data)
+	if err != nil {
+		return err
+	}
+
+	repo, err := resolveRepo(repoOverride)
+	if err != nil {
+		return err
+	}
+	projectID, err := resolveProjectID(cmd)
+	if err != nil {
+		return err
+	}
+
+	gqlClient, err := api.DefaultGraphQLClient()
+	if err != nil {
+		return fmt.Errorf("failed to create GraphQL client: %w", err)
+	}
+	ex := &importExecutor{
+		IssueGateway:      github.NewIssueGateway(gqlClient),
+		DependencyGateway: github.NewDependencyGateway(gqlClient),
+		ProjectGateway:    github.NewProjectGateway(gqlClient),
+	}
+
+	issues, deps, err := ex.ListProjectItems(projectID)
+	if err != nil {
+		return fmt.Errorf("failed to list project items: %w", err)
+	}
+	plan, err := outline.BuildPlan(roots, projectID, repo.Owner, repo.Name, issues, deps)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if plan.Empty() {
+		fmt.Fprintln(out, "Nothing to import.")
+		return nil
+	}
+	if dryRun {
+		return plan.Describe(out)
+	}
+	return plan.Apply(ex, out)
+}
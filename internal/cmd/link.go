@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/spf13/cobra"
+)
+
+func newLinkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "link <issue> (--blocked-by <issue> | --parent <issue>)",
+		Short: "Add a blocked-by or sub-issue relationship",
+		Long: "Add a blocked-by or sub-issue relationship.\n" +
+			"Issues are given as OWNER/REPO#NUMBER, or as NUMBER in the repository of --repo.",
+		Example: "  gh issue-treefier link owner/repo#12 --blocked-by owner/repo#9\n" +
+			"  gh issue-treefier link owner/repo#12 --parent owner/repo#3",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLink(cmd, args, true)
+		},
+	}
+	addLinkFlags(cmd)
+	return cmd
+}
+
+func newUnlinkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlink <issue> (--blocked-by <issue> | --parent <issue>)",
+		Short: "Remove a blocked-by or sub-issue relationship",
+		Long: "Remove a blocked-by or sub-issue relationship.\n" +
+			"Issues are given as OWNER/REPO#NUMBER, or as NUMBER in the repository of --repo.",
+		Example: "  gh issue-treefier unlink owner/repo#12 --blocked-by owner/repo#9\n" +
+			"  gh issue-treefier unlink owner/repo#12 --parent owner/repo#3",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLink(cmd, args, false)
+		},
+	}
+	addLinkFlags(cmd)
+	return cmd
+}
+
+func addLinkFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("repo", "R", "", "Repository in OWNER/REPO format for issues given by number")
+	cmd.Flags().String("blocked-by", "", "Issue that blocks the given issue")
+	cmd.Flags().String("parent", "", "Parent issue of the given issue")
+	cmd.MarkFlagsMutuallyExclusive("blocked-by", "parent")
+	cmd.MarkFlagsOneRequired("blocked-by", "parent")
+}
+
+func runLink(cmd *cobra.Command, args []string, add bool) error {
+	repoOverride, err := cmd.Flags().GetString("repo")
+	if err != nil {
+		return fmt.Errorf("failed to read repo flag: %w", err)
+	}
+	blockedBy, err := cmd.Flags().GetString("blocked-by")
+	if err != nil {
+		return fmt.Errorf("failed to read blocked-by flag: %w", err)
+	}
+	parent, err := cmd.Flags().GetString("parent")
+	if err != nil {
+		return fmt.Errorf("failed to read parent flag: %w", err)
+	}
+
+	issueID, err := parseIssueArg(args[0], repoOverride)
+	if err != nil {
+		return err
+	}
+	otherID, err := parseIssueArg(blockedBy+parent, repoOverride)
+	if err != nil {
+		return err
+	}
+
+	gqlClient, err := api.DefaultGraphQLClient()
+	if err != nil {
+		return fmt.Errorf("failed to create GraphQL client: %w", err)
+	}
+	dg := github.NewDependencyGateway(gqlClient)
+
+	issueNodeID, err := resolveIssueNodeID(dg, issueID)
+	if err != nil {
+		return err
+	}
+	otherNodeID, err := resolveIssueNodeID(dg, otherID)
+	if err != nil {
+		return err
+	}
+
+	var msg string
+	switch {
+	case blockedBy != "" && add:
+		err = dg.AddBlockedBy(issueNodeID, otherNodeID)
+		msg = fmt.Sprintf("%s is now blocked by %s", issueID, otherID)
+	case blockedBy != "":
+		err = dg.RemoveBlockedBy(issueNodeID, otherNodeID)
+		msg = fmt.Sprintf("%s is no longer blocked by %s", issueID, otherID)
+	case add:
+		err = dg.AddSubIssue(otherNodeID, issueNodeID)
+		msg = fmt.Sprintf("%s is now a sub-issue of %s", issueID, otherID)
+	default:
+		err = dg.RemoveSubIssue(otherNodeID, issueNodeID)
+		msg = fmt.Sprintf("%s is no longer a sub-issue of %s", issueID, otherID)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), msg)
+	return nil
+}
+
+// parseIssueArg は OWNER/REPO#NUMBER 形式、または NUMBER（#NUMBER）形式の Issue 指定を複合 ID に変換する。
+// NUMBER のみの場合は --repo（未指定ならカレントリポジトリ）の Issue とみなす。
+func parseIssueArg(s, repoOverride string) (string, error) {
+	if number, err := strconv.Atoi(strings.TrimPrefix(s, "#")); err == nil {
+		if number <= 0 {
+			return "", fmt.Errorf("invalid issue %q", s)
+		}
+		repo, err := resolveRepo(repoOverride)
+		if err != nil {
+			return "", err
+		}
+		return github.BuildIssueID(repo.Owner, repo.Name, number), nil
+	}
+
+	owner, repo, number, err := github.ParseIssueID(s)
+	if err != nil {
+		return "", err
+	}
+	return github.BuildIssueID(owner, repo, number), nil
+}
+
+func resolveIssueNodeID(dg *github.DependencyGateway, id string) (string, error) {
+	owner, repo, number, err := github.ParseIssueID(id)
+	if err != nil {
+		return "", err
+	}
+	return dg.ResolveIssueNodeID(owner, repo, number)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+// openAuditLog はこのツールから GitHub に送った変更を記録する監査ログを開く。
+func openAuditLog() (*audit.Log, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return audit.Open(filepath.Join(dir, "audit.jsonl")), nil
+}
+
+func newLogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the changes this tool has sent to GitHub",
+		Long: "Show the audit log of the changes sent to GitHub from the console and by apply,\n" +
+			"oldest first. Targets are composite IDs (OWNER/REPO#NUMBER), node IDs or REST IDs.\n" +
+			"Changes sent by node ID also record the composite ID of the issue or pull request,\n" +
+			"so --target OWNER/REPO#NUMBER finds them too.",
+		Example: "  gh issue-treefier log\n" +
+			"  gh issue-treefier log --target owner/repo#12 --limit 0\n" +
+			"  gh issue-treefier log --operation removeSubIssue --json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLog(cmd, args)
+		},
+	}
+
+	cmd.Flags().Int("limit", 30, "Show only the most recent N changes (0 for all)")
+	cmd.Flags().String("target", "", "Show only changes whose targets include this ID")
+	cmd.Flags().String("operation", "", "Show only changes whose operation contains this text")
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runLog(cmd *cobra.Command, _ []string) error {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("failed to read limit flag: %w", err)
+	}
+	target, err := cmd.Flags().GetString("target")
+	if err != nil {
+		return fmt.Errorf("failed to read target flag: %w", err)
+	}
+	operation, err := cmd.Flags().GetString("operation")
+	if err != nil {
+		return fmt.Errorf("failed to read operation flag: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("failed to read json flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+
+	log, err := openAuditLog()
+	if err != nil {
+		return err
+	}
+	entries, err := log.List()
+	if err != nil {
+		return err
+	}
+
+	entries = slices.DeleteFunc(entries, func(e audit.Entry) bool {
+		if target != "" && !slices.Contains(e.Targets, target) {
+			return true
+		}
+		return operation != "" && !strings.Contains(e.Operation, operation)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No changes recorded.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSOURCE\tOPERATION\tSTATUS\tTARGETS")
+	for _, e := range entries {
+		status := strconv.Itoa(e.Status)
+		if e.Error != "" {
+			status += " " + e.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.Source,
+			e.Operation,
+			status,
+			strings.Join(e.Targets, ", "),
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func newNextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "List issues that are ready to start",
+		Long: "List open issues that have no open blockers and no open sub-issues.\n" +
+			"Issues outside the project are treated as open.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNext(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().String("sort-field", "", "Sort by a single-select or iteration project field (e.g. Priority)")
+	cmd.Flags().String("assignee", "", "Only list issues assigned to the given user (@me for yourself)")
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runNext(cmd *cobra.Command, _ []string) error {
+	sortField, err := cmd.Flags().GetString("sort-field")
+	if err != nil {
+		return fmt.Errorf("failed to read sort-field flag: %w", err)
+	}
+	assignee, err := cmd.Flags().GetString("assignee")
+	if err != nil {
+		return fmt.Errorf("failed to read assignee flag: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("failed to read json flag: %w", err)
+	}
+
+	g, gw, projectID, err := loadProjectGraph(cmd)
+	if err != nil {
+		return err
+	}
+
+	if assignee == "@me" {
+		if assignee, err = viewerLogin(); err != nil {
+			return err
+		}
+	}
+
+	ready := make([]github.Issue, 0)
+	for _, id := range graph.Ready(g) {
+		issue, _ := g.Issue(id)
+		if assignee != "" && !slices.ContainsFunc(issue.Assignees, func(a github.Assignee) bool {
+			return strings.EqualFold(a.Login, assignee)
+		}) {
+			continue
+		}
+		ready = append(ready, issue)
+	}
+
+	var field *github.ProjectField
+	if sortField != "" {
+		fields, err := gw.ListProjectFields(projectID)
+		if err != nil {
+			return fmt.Errorf("failed to list project fields: %w", err)
+		}
+		f, ok := github.FindProjectField(fields, sortField)
+		if !ok {
+			return fmt.Errorf("field %q not found in project", sortField)
+		}
+		if f.DataType != "SINGLE_SELECT" && f.DataType != "ITERATION" {
+			return fmt.Errorf("field %q is %s, expected SINGLE_SELECT or ITERATION", f.Name, f.DataType)
+		}
+		field = &f
+		sortByField(ready, f)
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ready)
+	}
+
+	if len(ready) == 0 {
+		fmt.Fprintln(out, "No issues are ready to start.")
+		return nil
+	}
+	for _, issue := range ready {
+		line := issue.ID + " " + issue.Title
+		if field != nil {
+			if i, ok := field.OptionIndex(issue.FieldValues[field.ID]); ok {
+				line += fmt.Sprintf(" [%s: %s]", field.Name, field.Options[i].Name)
+			}
+		}
+		if len(issue.Assignees) > 0 {
+			line += " " + strings.Join(lo.Map(issue.Assignees, func(a github.Assignee, _ int) string {
+				return "@" + a.Login
+			}), " ")
+		}
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// sortByField はフィールドの選択肢の並び順で Issue を並べ替える。
+// 値が未設定の Issue は末尾に置き、同順位は複合 ID 順とする。
+func sortByField(issues []github.Issue, field github.ProjectField) {
+	rank := func(issue github.Issue) int {
+		if i, ok := field.OptionIndex(issue.FieldValues[field.ID]); ok {
+			return i
+		}
+		return len(field.Options)
+	}
+	slices.SortStableFunc(issues, func(a, b github.Issue) int {
+		return cmp.Or(cmp.Compare(rank(a), rank(b)), graph.CompareIDs(a.ID, b.ID))
+	})
+}
+
+// viewerLogin は認証中のユーザーのログイン名を返す。
+func viewerLogin() (string, error) {
+	gqlClient, err := api.DefaultGraphQLClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create GraphQL client: %w", err)
+	}
+	var resp struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	if err := gqlClient.Do("{ viewer { login } }", nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to query viewer: %w", err)
+	}
+	return resp.Viewer.Login, nil
+}
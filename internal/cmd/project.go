@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/prompter"
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// addProjectFlags はプロジェクトを対象とするコマンド共通のフラグを登録する。
+func addProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("repo", "R", "", "Repository in OWNER/REPO format")
+	cmd.Flags().StringP("project-id", "p", "", "Project ID (skips interactive selection)")
+}
+
+// resolveProjectID は --project-id が指定されていればそれを返し、
+// なければ --repo（未指定ならカレントリポジトリ）のプロジェクトから対話的に選択させる。
+func resolveProjectID(cmd *cobra.Command) (string, error) {
+	projectID, err := cmd.Flags().GetString("project-id")
+	if err != nil {
+		return "", fmt.Errorf("failed to read project-id flag: %w", err)
+	}
+	if projectID != "" {
+		return projectID, nil
+	}
+
+	repoOverride, err := cmd.Flags().GetString("repo")
+	if err != nil {
+		return "", fmt.Errorf("failed to read repo flag: %w", err)
+	}
+	repo, err := resolveRepo(repoOverride)
+	if err != nil {
+		return "", err
+	}
+	selected, err := selectProject(repo)
+	if err != nil {
+		return "", err
+	}
+	return selected.ID, nil
+}
+
+// selectProject はリポジトリに紐づくプロジェクトを一覧し、プロンプトで1つ選択させる。
+func selectProject(repo repository.Repository) (github.Project, error) {
+	term := term.FromEnv()
+	in, ok := term.In().(*os.File)
+	if !ok {
+		return github.Project{}, errors.New("failed to initialize prompter")
+	}
+	out, ok := term.Out().(*os.File)
+	if !ok {
+		return github.Project{}, errors.New("failed to initialize prompter")
+	}
+	errOut, ok := term.ErrOut().(*os.File)
+	if !ok {
+		return github.Project{}, errors.New("failed to initialize prompter")
+	}
+
+	gw, err := newProjectGateway()
+	if err != nil {
+		return github.Project{}, err
+	}
+	projects, err := gw.ListRepoProjects(repo.Owner, repo.Name)
+	if err != nil {
+		return github.Project{}, fmt.Errorf("failed to list projects: %w", err)
+	}
+	if len(projects) == 0 {
+		return github.Project{}, fmt.Errorf("no projects found in repository %s/%s", repo.Owner, repo.Name)
+	}
+	p := prompter.New(in, out, errOut)
+	selected, err := p.Select(
+		"Select a project",
+		"",
+		lo.Map(projects, func(p github.Project, _ int) string {
+			return fmt.Sprintf("#%d %s", p.Number, p.Title)
+		}),
+	)
+	if err != nil {
+		return github.Project{}, fmt.Errorf("failed to prompt for project: %w", err)
+	}
+	return projects[selected], nil
+}
+
+func newProjectGateway() (*github.ProjectGateway, error) {
+	gqlClient, err := api.DefaultGraphQLClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GraphQL client: %w", err)
+	}
+	return github.NewProjectGateway(gqlClient), nil
+}
+
+// loadProjectGraph はフラグで指定されたプロジェクトの Issue を取得し、グラフを構築する。
+// プロジェクトのフィールドなども読めるよう、取得に使ったゲートウェイとプロジェクト ID も返す。
+func loadProjectGraph(cmd *cobra.Command) (*graph.Graph, *github.ProjectGateway, string, error) {
+	projectID, err := resolveProjectID(cmd)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	gw, err := newProjectGateway()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	issues, deps, err := gw.ListProjectItems(projectID)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to list project items: %w", err)
+	}
+	return graph.New(issues, deps), gw, projectID, nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/spf13/cobra"
+)
+
+func newProjectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "List projects of a user, organization and repository",
+		Long: "List the projects owned by the owner of the repository and the projects linked to the repository.\n" +
+			"With --owner, list only the projects owned by the given user or organization.",
+		Example: "  gh issue-treefier projects\n" +
+			"  gh issue-treefier projects --owner my-org --json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProjects(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringP("repo", "R", "", "Repository in OWNER/REPO format")
+	cmd.Flags().String("owner", "", "List projects of the given user or organization only")
+	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.MarkFlagsMutuallyExclusive("repo", "owner")
+
+	return cmd
+}
+
+func runProjects(cmd *cobra.Command, _ []string) error {
+	repoOverride, err := cmd.Flags().GetString("repo")
+	if err != nil {
+		return fmt.Errorf("failed to read repo flag: %w", err)
+	}
+	owner, err := cmd.Flags().GetString("owner")
+	if err != nil {
+		return fmt.Errorf("failed to read owner flag: %w", err)
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return fmt.Errorf("failed to read json flag: %w", err)
+	}
+
+	gw, err := newProjectGateway()
+	if err != nil {
+		return err
+	}
+
+	var repoProjects []github.Project
+	if owner == "" {
+		repo, err := resolveRepo(repoOverride)
+		if err != nil {
+			return err
+		}
+		owner = repo.Owner
+		if repoProjects, err = gw.ListRepoProjects(repo.Owner, repo.Name); err != nil {
+			return fmt.Errorf("failed to list projects: %w", err)
+		}
+	}
+
+	ownerType, err := gw.GetOwnerType(owner)
+	if err != nil {
+		return err
+	}
+	var ownerProjects []github.Project
+	if ownerType == github.OwnerOrganization {
+		ownerProjects, err = gw.ListOrgProjects(owner)
+	} else {
+		ownerProjects, err = gw.ListUserProjects(owner)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	// リポジトリに紐づくプロジェクトはオーナーのプロジェクトと重複しうるため ID で重複を除く
+	projects := make([]github.Project, 0, len(ownerProjects)+len(repoProjects))
+	seen := make(map[string]bool)
+	for _, p := range append(ownerProjects, repoProjects...) {
+		if seen[p.ID] {
+			continue
+		}
+		seen[p.ID] = true
+		projects = append(projects, p)
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(projects)
+	}
+
+	if len(projects) == 0 {
+		fmt.Fprintln(out, "No projects found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNUMBER\tTITLE\tSTATE\tITEMS")
+	for _, p := range projects {
+		state := "open"
+		if p.Closed {
+			state = "closed"
+		}
+		fmt.Fprintf(tw, "%s\t#%d\t%s\t%s\t%d\n", p.ID, p.Number, p.Title, state, p.ItemCount)
+	}
+	return tw.Flush()
+}
@@ -9,6 +9,19 @@ func newRootCmd() *cobra.Command {
 	}
 
 	rootCmd.AddCommand(newConsoleCmd())
+	rootCmd.AddCommand(newProjectsCmd())
+	rootCmd.AddCommand(newTreeCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newCheckCmd())
+	rootCmd.AddCommand(newNextCmd())
+	rootCmd.AddCommand(newCriticalPathCmd())
+	rootCmd.AddCommand(newLinkCmd())
+	rootCmd.AddCommand(newUnlinkCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newCacheCmd())
+	rootCmd.AddCommand(newSyncCmd())
+	rootCmd.AddCommand(newApplyCmd())
+	rootCmd.AddCommand(newLogCmd())
 
 	return rootCmd
 }
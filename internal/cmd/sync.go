@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Update the cached items of a project with the changes since the last sync",
+		Long: "Update the cached items of a project with the changes since the last sync.\n\n" +
+			"Only items updated on GitHub since the last sync are fetched in full; removed items are\n" +
+			"dropped from the cache. A project without a previous sync is fetched in full.",
+		Example: "  gh issue-treefier sync --project-id PVT_xxx\n" +
+			"  gh issue-treefier sync --project-id PVT_xxx --full",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().Bool("full", false, "Fetch all items even if the cache has been synced before")
+
+	return cmd
+}
+
+func runSync(cmd *cobra.Command, _ []string) error {
+	full, err := cmd.Flags().GetBool("full")
+	if err != nil {
+		return fmt.Errorf("failed to read full flag: %w", err)
+	}
+
+	projectID, err := resolveProjectID(cmd)
+	if err != nil {
+		return err
+	}
+	gw, err := newProjectGateway()
+	if err != nil {
+		return err
+	}
+	store, err := newCacheStore()
+	if err != nil {
+		return err
+	}
+
+	c := store.GetCache(projectID)
+	raw, since := c.Items, c.SyncedAt
+	var cached []github.ProjectItem
+	if full || since.IsZero() || json.Unmarshal(raw, &cached) != nil {
+		cached, since = nil, time.Time{}
+	}
+	synced, err := gw.SyncProjectItems(projectID, cached, since)
+	if err != nil {
+		return fmt.Errorf("failed to sync project items: %w", err)
+	}
+	items, err := json.Marshal(synced.Items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal project items: %w", err)
+	}
+	// 書き出し時に、同期している間に console などが書き込んだ内容とマージする
+	if _, err := store.SetSyncedItemsIfRevision(projectID, items, synced.SyncedAt, c.Revision); err != nil {
+		return fmt.Errorf("failed to update cache: %w", err)
+	}
+	if err := store.FlushAll(); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if synced.Full {
+		fmt.Fprintf(out, "Fetched all %d item(s).\n", len(synced.Items))
+		return nil
+	}
+	fmt.Fprintf(out, "Synced %d item(s): %d fetched, %d removed.\n", len(synced.Items), synced.Fetched, synced.Removed)
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+	"github.com/spf13/cobra"
+)
+
+func newTreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree",
+		Short: "Print the issue dependency tree of a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTree(cmd, args)
+		},
+	}
+
+	addProjectFlags(cmd)
+	cmd.Flags().String("root", "", "Start from the given issue in OWNER/REPO#NUMBER format")
+	cmd.Flags().Int("depth", 0, "Maximum depth of sub-issues to print (0 for unlimited)")
+	cmd.Flags().Bool("ascii", false, "Use ASCII characters only")
+
+	return cmd
+}
+
+func runTree(cmd *cobra.Command, _ []string) error {
+	root, err := cmd.Flags().GetString("root")
+	if err != nil {
+		return fmt.Errorf("failed to read root flag: %w", err)
+	}
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		return fmt.Errorf("failed to read depth flag: %w", err)
+	}
+	ascii, err := cmd.Flags().GetBool("ascii")
+	if err != nil {
+		return fmt.Errorf("failed to read ascii flag: %w", err)
+	}
+
+	opts := graph.TreeOptions{MaxDepth: depth, ASCII: ascii}
+	if root != "" {
+		owner, repo, number, err := github.ParseIssueID(root)
+		if err != nil {
+			return err
+		}
+		opts.Roots = []string{github.BuildIssueID(owner, repo, number)}
+	}
+
+	g, _, _, err := loadProjectGraph(cmd)
+	if err != nil {
+		return err
+	}
+	for _, id := range opts.Roots {
+		if !g.Has(id) {
+			return fmt.Errorf("issue %s is not in the project", id)
+		}
+	}
+
+	return graph.RenderTree(cmd.OutOrStdout(), g, opts)
+}
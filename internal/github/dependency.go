@@ -0,0 +1,119 @@
+package github
+
+import (
+	"fmt"
+)
+
+// DependencyGateway provides mutations for sub-issue and blocked-by relationships.
+// It issues the same GraphQL mutations as the web UI.
+type DependencyGateway struct {
+	client GQLClient
+}
+
+// NewDependencyGateway creates a new DependencyGateway with the given GraphQL client.
+func NewDependencyGateway(client GQLClient) *DependencyGateway {
+	return &DependencyGateway{client: client}
+}
+
+// ResolveIssueNodeID returns the GraphQL node ID of the given issue.
+func (dg *DependencyGateway) ResolveIssueNodeID(owner, repo string, number int) (string, error) {
+	query := `
+		query($owner: String!, $name: String!, $number: Int!) {
+			repository(owner: $owner, name: $name) {
+				issue(number: $number) { id }
+			}
+		}
+	`
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"name":   repo,
+		"number": number,
+	}
+
+	var resp struct {
+		Repository *struct {
+			Issue *struct {
+				ID string `json:"id"`
+			} `json:"issue"`
+		} `json:"repository"`
+	}
+
+	if err := dg.client.Do(query, variables, &resp); err != nil {
+		return "", fmt.Errorf("failed to query issue %s: %w", BuildIssueID(owner, repo, number), err)
+	}
+	if resp.Repository == nil || resp.Repository.Issue == nil {
+		return "", fmt.Errorf("issue %s not found", BuildIssueID(owner, repo, number))
+	}
+	return resp.Repository.Issue.ID, nil
+}
+
+const (
+	addBlockedByMutation = `
+		mutation($issueId: ID!, $blockingIssueId: ID!) {
+			addBlockedBy(input: { issueId: $issueId, blockingIssueId: $blockingIssueId }) {
+				issue { id }
+			}
+		}
+	`
+	removeBlockedByMutation = `
+		mutation($issueId: ID!, $blockingIssueId: ID!) {
+			removeBlockedBy(input: { issueId: $issueId, blockingIssueId: $blockingIssueId }) {
+				issue { id }
+			}
+		}
+	`
+	addSubIssueMutation = `
+		mutation($issueId: ID!, $subIssueId: ID!) {
+			addSubIssue(input: { issueId: $issueId, subIssueId: $subIssueId }) {
+				issue { id }
+			}
+		}
+	`
+	removeSubIssueMutation = `
+		mutation($issueId: ID!, $subIssueId: ID!) {
+			removeSubIssue(input: { issueId: $issueId, subIssueId: $subIssueId }) {
+				issue { id }
+			}
+		}
+	`
+)
+
+// AddBlockedBy marks issueID as blocked by blockingIssueID. Both are GraphQL node IDs.
+func (dg *DependencyGateway) AddBlockedBy(issueID, blockingIssueID string) error {
+	return dg.mutate("addBlockedBy", addBlockedByMutation, map[string]interface{}{
+		"issueId":         issueID,
+		"blockingIssueId": blockingIssueID,
+	})
+}
+
+// RemoveBlockedBy removes the blocked-by relationship between issueID and blockingIssueID.
+func (dg *DependencyGateway) RemoveBlockedBy(issueID, blockingIssueID string) error {
+	return dg.mutate("removeBlockedBy", removeBlockedByMutation, map[string]interface{}{
+		"issueId":         issueID,
+		"blockingIssueId": blockingIssueID,
+	})
+}
+
+// AddSubIssue adds subIssueID as a sub-issue of issueID.
+func (dg *DependencyGateway) AddSubIssue(issueID, subIssueID string) error {
+	return dg.mutate("addSubIssue", addSubIssueMutation, map[string]interface{}{
+		"issueId":    issueID,
+		"subIssueId": subIssueID,
+	})
+}
+
+// RemoveSubIssue removes subIssueID from the sub-issues of issueID.
+func (dg *DependencyGateway) RemoveSubIssue(issueID, subIssueID string) error {
+	return dg.mutate("removeSubIssue", removeSubIssueMutation, map[string]interface{}{
+		"issueId":    issueID,
+		"subIssueId": subIssueID,
+	})
+}
+
+func (dg *DependencyGateway) mutate(name, mutation string, variables map[string]interface{}) error {
+	var resp map[string]interface{}
+	if err := dg.client.Do(mutation, variables, &resp); err != nil {
+		return fmt.Errorf("failed to %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResolveIssueNodeID(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"repository": {"issue": {"id": "I_12"}}}`)},
+		},
+	}
+	dg := NewDependencyGateway(client)
+
+	id, err := dg.ResolveIssueNodeID("owner", "repo", 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "I_12" {
+		t.Errorf("id = %q, want I_12", id)
+	}
+	if v := client.calls[0].variables; v["owner"] != "owner" || v["name"] != "repo" || v["number"] != 12 {
+		t.Errorf("unexpected variables: %v", v)
+	}
+}
+
+func TestResolveIssueNodeID_NotFound(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"repository": {"issue": null}}`)},
+		},
+	}
+	dg := NewDependencyGateway(client)
+
+	if _, err := dg.ResolveIssueNodeID("owner", "repo", 999); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDependencyMutations(t *testing.T) {
+	tests := []struct {
+		name         string
+		call         func(dg *DependencyGateway) error
+		wantMutation string
+		wantVars     map[string]interface{}
+	}{
+		{
+			name:         "addBlockedBy",
+			call:         func(dg *DependencyGateway) error { return dg.AddBlockedBy("I_1", "I_2") },
+			wantMutation: "addBlockedBy(",
+			wantVars:     map[string]interface{}{"issueId": "I_1", "blockingIssueId": "I_2"},
+		},
+		{
+			name:         "removeBlockedBy",
+			call:         func(dg *DependencyGateway) error { return dg.RemoveBlockedBy("I_1", "I_2") },
+			wantMutation: "removeBlockedBy(",
+			wantVars:     map[string]interface{}{"issueId": "I_1", "blockingIssueId": "I_2"},
+		},
+		{
+			name:         "addSubIssue",
+			call:         func(dg *DependencyGateway) error { return dg.AddSubIssue("I_1", "I_2") },
+			wantMutation: "addSubIssue(",
+			wantVars:     map[string]interface{}{"issueId": "I_1", "subIssueId": "I_2"},
+		},
+		{
+			name:         "removeSubIssue",
+			call:         func(dg *DependencyGateway) error { return dg.RemoveSubIssue("I_1", "I_2") },
+			wantMutation: "removeSubIssue(",
+			wantVars:     map[string]interface{}{"issueId": "I_1", "subIssueId": "I_2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockGQLClient{
+				responses: []mockResponse{
+					{body: []byte(`{"result": {"issue": {"id": "I_1"}}}`)},
+				},
+			}
+			if err := tt.call(NewDependencyGateway(client)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := client.calls[0]
+			if !strings.Contains(got.query, tt.wantMutation) {
+				t.Errorf("query does not contain %q: %s", tt.wantMutation, got.query)
+			}
+			for k, v := range tt.wantVars {
+				if got.variables[k] != v {
+					t.Errorf("variables[%q] = %v, want %v", k, got.variables[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestDependencyMutations_Error(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{err: fmt.Errorf("GraphQL error: already blocked")},
+		},
+	}
+	dg := NewDependencyGateway(client)
+
+	if err := dg.AddBlockedBy("I_1", "I_2"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,84 @@
+package github
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DependencyType is the kind of edge between two issues.
+type DependencyType string
+
+const (
+	// DependencySubIssue is a parent → child edge.
+	DependencySubIssue DependencyType = "sub_issue"
+	// DependencyBlockedBy is a blocker → blocked edge.
+	DependencyBlockedBy DependencyType = "blocked_by"
+)
+
+// Dependency is an edge of the issue DAG, using composite IDs (owner/repo#number).
+// For sub_issue edges Source is the parent; for blocked_by edges Source blocks Target.
+type Dependency struct {
+	Source string         `json:"source"`
+	Target string         `json:"target"`
+	Type   DependencyType `json:"type"`
+}
+
+// Label is an issue label.
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Assignee is a user assigned to an issue.
+type Assignee struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatarUrl"`
+}
+
+// Issue is an issue that belongs to a project, in the same shape the web UI uses.
+type Issue struct {
+	ID        string     `json:"id"`
+	ItemID    string     `json:"itemId,omitempty"`
+	NodeID    string     `json:"nodeId,omitempty"`
+	Number    int        `json:"number"`
+	Owner     string     `json:"owner"`
+	Repo      string     `json:"repo"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	Body      string     `json:"body"`
+	Labels    []Label    `json:"labels"`
+	Assignees []Assignee `json:"assignees"`
+	URL       string     `json:"url"`
+	// FieldValues maps a project field ID to the selected option ID or iteration ID.
+	FieldValues map[string]string `json:"fieldValues"`
+	// NumberValues maps a NUMBER project field ID to its value.
+	NumberValues map[string]float64 `json:"numberValues,omitempty"`
+}
+
+// IsOpen reports whether the issue is open.
+func (i Issue) IsOpen() bool {
+	return i.State == "open"
+}
+
+// BuildIssueID returns the composite ID in owner/repo#number form.
+func BuildIssueID(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// ParseIssueID splits a composite ID in owner/repo#number form.
+func ParseIssueID(id string) (owner, repo string, number int, err error) {
+	ownerRepo, num, ok := strings.Cut(id, "#")
+	if !ok {
+		return "", "", 0, fmt.Errorf("invalid issue %q, expected OWNER/REPO#NUMBER", id)
+	}
+	owner, repo, ok = strings.Cut(ownerRepo, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", 0, fmt.Errorf("invalid issue %q, expected OWNER/REPO#NUMBER", id)
+	}
+	number, err = strconv.Atoi(num)
+	if err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("invalid issue %q, expected OWNER/REPO#NUMBER", id)
+	}
+	return owner, repo, number, nil
+}
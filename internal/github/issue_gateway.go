@@ -0,0 +1,97 @@
+package github
+
+import (
+	"fmt"
+)
+
+// IssueGateway provides access to GitHub issues.
+type IssueGateway struct {
+	client  GQLClient
+	repoIDs map[string]string
+}
+
+// NewIssueGateway creates a new IssueGateway with the given GraphQL client.
+func NewIssueGateway(client GQLClient) *IssueGateway {
+	return &IssueGateway{client: client, repoIDs: make(map[string]string)}
+}
+
+// CreateIssue creates an issue in the given repository and returns it.
+// Only ID, NodeID, Number, Owner, Repo, Title, State and URL are populated.
+func (ig *IssueGateway) CreateIssue(owner, repo, title, body string) (Issue, error) {
+	repoID, err := ig.resolveRepositoryID(owner, repo)
+	if err != nil {
+		return Issue{}, err
+	}
+
+	mutation := `
+		mutation($repositoryId: ID!, $title: String!, $body: String) {
+			createIssue(input: { repositoryId: $repositoryId, title: $title, body: $body }) {
+				issue { id number url }
+			}
+		}
+	`
+	variables := map[string]interface{}{
+		"repositoryId": repoID,
+		"title":        title,
+		"body":         body,
+	}
+
+	var resp struct {
+		CreateIssue struct {
+			Issue struct {
+				ID     string `json:"id"`
+				Number int    `json:"number"`
+				URL    string `json:"url"`
+			} `json:"issue"`
+		} `json:"createIssue"`
+	}
+
+	if err := ig.client.Do(mutation, variables, &resp); err != nil {
+		return Issue{}, fmt.Errorf("failed to create issue in %s/%s: %w", owner, repo, err)
+	}
+
+	created := resp.CreateIssue.Issue
+	return Issue{
+		ID:     BuildIssueID(owner, repo, created.Number),
+		NodeID: created.ID,
+		Number: created.Number,
+		Owner:  owner,
+		Repo:   repo,
+		Title:  title,
+		State:  "open",
+		Body:   body,
+		URL:    created.URL,
+	}, nil
+}
+
+func (ig *IssueGateway) resolveRepositoryID(owner, repo string) (string, error) {
+	key := owner + "/" + repo
+	if id, ok := ig.repoIDs[key]; ok {
+		return id, nil
+	}
+
+	query := `
+		query($owner: String!, $name: String!) {
+			repository(owner: $owner, name: $name) { id }
+		}
+	`
+	variables := map[string]interface{}{
+		"owner": owner,
+		"name":  repo,
+	}
+
+	var resp struct {
+		Repository *struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+
+	if err := ig.client.Do(query, variables, &resp); err != nil {
+		return "", fmt.Errorf("failed to query repository %s: %w", key, err)
+	}
+	if resp.Repository == nil {
+		return "", fmt.Errorf("repository %s not found", key)
+	}
+	ig.repoIDs[key] = resp.Repository.ID
+	return resp.Repository.ID, nil
+}
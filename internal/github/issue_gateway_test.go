@@ -0,0 +1,82 @@
+package github
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCreateIssue(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"repository": {"id": "R_1"}}`)},
+			{body: []byte(`{"createIssue": {"issue": {"id": "I_10", "number": 10, "url": "https://github.com/owner/repo/issues/10"}}}`)},
+			{body: []byte(`{"createIssue": {"issue": {"id": "I_11", "number": 11, "url": "https://github.com/owner/repo/issues/11"}}}`)},
+		},
+	}
+	ig := NewIssueGateway(client)
+
+	issue, err := ig.CreateIssue("owner", "repo", "New issue", "body")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if issue.ID != "owner/repo#10" || issue.NodeID != "I_10" || issue.Number != 10 || issue.State != "open" {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if v := client.calls[1].variables; v["repositoryId"] != "R_1" || v["title"] != "New issue" || v["body"] != "body" {
+		t.Errorf("unexpected variables: %v", v)
+	}
+
+	// repository ID is cached
+	if _, err := ig.CreateIssue("owner", "repo", "Another", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.calls) != 3 {
+		t.Errorf("expected 3 calls, got %d", len(client.calls))
+	}
+}
+
+func TestCreateIssue_RepositoryNotFound(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"repository": null}`)},
+		},
+	}
+	ig := NewIssueGateway(client)
+
+	if _, err := ig.CreateIssue("owner", "missing", "title", ""); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestAddProjectItem(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"addProjectV2ItemById": {"item": {"id": "PVTI_1"}}}`)},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	itemID, err := gw.AddProjectItem("PVT_1", "I_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if itemID != "PVTI_1" {
+		t.Errorf("itemID = %q, want PVTI_1", itemID)
+	}
+	if v := client.calls[0].variables; v["projectId"] != "PVT_1" || v["contentId"] != "I_1" {
+		t.Errorf("unexpected variables: %v", v)
+	}
+}
+
+func TestAddProjectItem_Error(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{err: fmt.Errorf("GraphQL error: insufficient scopes")},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	if _, err := gw.AddProjectItem("PVT_1", "I_1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
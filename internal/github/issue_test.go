@@ -0,0 +1,42 @@
+package github
+
+import "testing"
+
+func TestParseIssueID(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		wantOwner  string
+		wantRepo   string
+		wantNumber int
+		wantErr    bool
+	}{
+		{name: "valid", id: "owner/repo#12", wantOwner: "owner", wantRepo: "repo", wantNumber: 12},
+		{name: "missing number", id: "owner/repo", wantErr: true},
+		{name: "missing repo", id: "owner#12", wantErr: true},
+		{name: "non-numeric number", id: "owner/repo#abc", wantErr: true},
+		{name: "zero number", id: "owner/repo#0", wantErr: true},
+		{name: "nested path", id: "owner/repo/x#1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, number, err := ParseIssueID(tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q, got nil", tt.id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo || number != tt.wantNumber {
+				t.Errorf("got (%q, %q, %d), want (%q, %q, %d)", owner, repo, number, tt.wantOwner, tt.wantRepo, tt.wantNumber)
+			}
+			if got := BuildIssueID(owner, repo, number); got != tt.id {
+				t.Errorf("BuildIssueID = %q, want %q", got, tt.id)
+			}
+		})
+	}
+}
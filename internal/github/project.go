@@ -2,6 +2,7 @@ package github
 
 import (
 	"fmt"
+	"regexp"
 )
 
 // GQLClient is the interface for executing GraphQL queries.
@@ -11,9 +12,19 @@ type GQLClient interface {
 
 // Project represents a GitHub ProjectV2.
 type Project struct {
-	ID     string `json:"id"`
-	Title  string `json:"title"`
-	Number int    `json:"number"`
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Number    int    `json:"number"`
+	Closed    bool   `json:"closed"`
+	ItemCount int    `json:"itemCount"`
+}
+
+// projectIDPattern matches ProjectV2 node IDs such as PVT_kwDOABCD1234.
+var projectIDPattern = regexp.MustCompile(`^PVT_[A-Za-z0-9_-]{1,128}$`)
+
+// IsProjectID reports whether id has the format of a ProjectV2 node ID.
+func IsProjectID(id string) bool {
+	return projectIDPattern.MatchString(id)
 }
 
 // ProjectGateway provides access to GitHub Projects API.
@@ -26,65 +37,219 @@ func NewProjectGateway(client GQLClient) *ProjectGateway {
 	return &ProjectGateway{client: client}
 }
 
+// OwnerType is the kind of account that owns repositories and projects.
+type OwnerType string
+
+const (
+	// OwnerUser is a personal account.
+	OwnerUser OwnerType = "User"
+	// OwnerOrganization is an organization account.
+	OwnerOrganization OwnerType = "Organization"
+)
+
+const projectNodeFields = `
+	nodes { id title number closed items { totalCount } }
+	pageInfo { hasNextPage endCursor }
+`
+
+type projectNode struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Number int    `json:"number"`
+	Closed bool   `json:"closed"`
+	Items  struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"items"`
+}
+
+func (n projectNode) toProject() Project {
+	return Project{
+		ID:        n.ID,
+		Title:     n.Title,
+		Number:    n.Number,
+		Closed:    n.Closed,
+		ItemCount: n.Items.TotalCount,
+	}
+}
+
+type projectConnection struct {
+	Nodes    []projectNode `json:"nodes"`
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+}
+
+type projectsOwner struct {
+	ProjectsV2 projectConnection `json:"projectsV2"`
+}
+
+type projectsResponse struct {
+	Repository   *projectsOwner `json:"repository"`
+	User         *projectsOwner `json:"user"`
+	Organization *projectsOwner `json:"organization"`
+}
+
 // ListRepoProjects fetches all ProjectV2 projects for the given repository
 // using the GitHub GraphQL API via gh CLI authentication.
 func (pg *ProjectGateway) ListRepoProjects(owner, name string) ([]Project, error) {
+	query := `
+		query($owner: String!, $name: String!, $first: Int!, $after: String) {
+			repository(owner: $owner, name: $name) {
+				projectsV2(first: $first, after: $after) {` + projectNodeFields + `}
+			}
+		}
+	`
+	variables := map[string]interface{}{"owner": owner, "name": name}
+	projects, err := pg.listProjects(query, variables, func(r *projectsResponse) *projectsOwner { return r.Repository })
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects for repo %s/%s: %w", owner, name, err)
+	}
+	return projects, nil
+}
+
+// ListUserProjects fetches all ProjectV2 projects owned by the given user.
+func (pg *ProjectGateway) ListUserProjects(login string) ([]Project, error) {
+	query := `
+		query($owner: String!, $first: Int!, $after: String) {
+			user(login: $owner) {
+				projectsV2(first: $first, after: $after) {` + projectNodeFields + `}
+			}
+		}
+	`
+	variables := map[string]interface{}{"owner": login}
+	projects, err := pg.listProjects(query, variables, func(r *projectsResponse) *projectsOwner { return r.User })
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects for user %s: %w", login, err)
+	}
+	return projects, nil
+}
+
+// ListOrgProjects fetches all ProjectV2 projects owned by the given organization.
+func (pg *ProjectGateway) ListOrgProjects(login string) ([]Project, error) {
+	query := `
+		query($owner: String!, $first: Int!, $after: String) {
+			organization(login: $owner) {
+				projectsV2(first: $first, after: $after) {` + projectNodeFields + `}
+			}
+		}
+	`
+	variables := map[string]interface{}{"owner": login}
+	projects, err := pg.listProjects(query, variables, func(r *projectsResponse) *projectsOwner { return r.Organization })
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects for organization %s: %w", login, err)
+	}
+	return projects, nil
+}
+
+// GetProjects fetches the ProjectV2 projects with the given node IDs.
+// IDs that do not resolve to a ProjectV2 are omitted from the result.
+func (pg *ProjectGateway) GetProjects(ids []string) ([]Project, error) {
+	if len(ids) == 0 {
+		return []Project{}, nil
+	}
+	query := `
+		query($ids: [ID!]!) {
+			nodes(ids: $ids) {
+				... on ProjectV2 { id title number closed items { totalCount } }
+			}
+		}
+	`
+	var resp struct {
+		Nodes []*projectNode `json:"nodes"`
+	}
+	if err := pg.client.Do(query, map[string]interface{}{"ids": ids}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+
+	projects := []Project{}
+	for _, n := range resp.Nodes {
+		if n == nil || n.ID == "" {
+			continue
+		}
+		projects = append(projects, n.toProject())
+	}
+	return projects, nil
+}
+
+// GetOwnerType reports whether the given login is a user or an organization.
+func (pg *ProjectGateway) GetOwnerType(login string) (OwnerType, error) {
+	query := `
+		query($login: String!) {
+			repositoryOwner(login: $login) { __typename }
+		}
+	`
+	var resp struct {
+		RepositoryOwner *struct {
+			Typename OwnerType `json:"__typename"`
+		} `json:"repositoryOwner"`
+	}
+	if err := pg.client.Do(query, map[string]interface{}{"login": login}, &resp); err != nil {
+		return "", fmt.Errorf("failed to query owner %s: %w", login, err)
+	}
+	if resp.RepositoryOwner == nil {
+		return "", fmt.Errorf("owner %s not found", login)
+	}
+	return resp.RepositoryOwner.Typename, nil
+}
+
+// listProjects runs a projectsV2 query, following pagination.
+// owner picks the connection root (repository, user or organization) out of the response.
+func (pg *ProjectGateway) listProjects(query string, variables map[string]interface{}, owner func(*projectsResponse) *projectsOwner) ([]Project, error) {
 	var projects []Project
-	var endCursor string
+	var after interface{}
 	hasNextPage := true
 
 	for hasNextPage {
-		var query string
-		variables := map[string]interface{}{
-			"owner": owner,
-			"name":  name,
-			"first": 100,
+		vars := map[string]interface{}{"first": 100, "after": after}
+		for k, v := range variables {
+			vars[k] = v
+		}
+
+		var resp projectsResponse
+		if err := pg.client.Do(query, vars, &resp); err != nil {
+			return nil, err
 		}
-		if endCursor != "" {
-			query = `
-				query($owner: String!, $name: String!, $first: Int!, $after: String!) {
-					repository(owner: $owner, name: $name) {
-						projectsV2(first: $first, after: $after) {
-							nodes { id title number }
-							pageInfo { hasNextPage endCursor }
-						}
-					}
-				}
-			`
-			variables["after"] = endCursor
-		} else {
-			query = `
-				query($owner: String!, $name: String!, $first: Int!) {
-					repository(owner: $owner, name: $name) {
-						projectsV2(first: $first) {
-							nodes { id title number }
-							pageInfo { hasNextPage endCursor }
-						}
-					}
-				}
-			`
+		root := owner(&resp)
+		if root == nil {
+			return nil, fmt.Errorf("not found")
 		}
 
-		var resp struct {
-			Repository struct {
-				ProjectsV2 struct {
-					Nodes    []Project `json:"nodes"`
-					PageInfo struct {
-						HasNextPage bool   `json:"hasNextPage"`
-						EndCursor   string `json:"endCursor"`
-					} `json:"pageInfo"`
-				} `json:"projectsV2"`
-			} `json:"repository"`
+		for _, n := range root.ProjectsV2.Nodes {
+			projects = append(projects, n.toProject())
 		}
+		hasNextPage = root.ProjectsV2.PageInfo.HasNextPage
+		after = root.ProjectsV2.PageInfo.EndCursor
+	}
+
+	return projects, nil
+}
 
-		if err := pg.client.Do(query, variables, &resp); err != nil {
-			return nil, fmt.Errorf("failed to query projects for repo %s/%s: %w", owner, name, err)
+// AddProjectItem adds the issue or pull request with the given node ID to the project
+// and returns the ID of the project item.
+func (pg *ProjectGateway) AddProjectItem(projectID, contentID string) (string, error) {
+	mutation := `
+		mutation($projectId: ID!, $contentId: ID!) {
+			addProjectV2ItemById(input: { projectId: $projectId, contentId: $contentId }) {
+				item { id }
+			}
 		}
+	`
+	variables := map[string]interface{}{
+		"projectId": projectID,
+		"contentId": contentID,
+	}
 
-		projects = append(projects, resp.Repository.ProjectsV2.Nodes...)
-		hasNextPage = resp.Repository.ProjectsV2.PageInfo.HasNextPage
-		endCursor = resp.Repository.ProjectsV2.PageInfo.EndCursor
+	var resp struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
 	}
 
-	return projects, nil
+	if err := pg.client.Do(mutation, variables, &resp); err != nil {
+		return "", fmt.Errorf("failed to add %s to project %s: %w", contentID, projectID, err)
+	}
+	return resp.AddProjectV2ItemByID.Item.ID, nil
 }
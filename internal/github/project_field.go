@@ -0,0 +1,115 @@
+package github
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ProjectField is a field of a ProjectV2.
+// Options holds the single-select options or the iterations, in the order GitHub returns them.
+type ProjectField struct {
+	ID       string               `json:"id"`
+	Name     string               `json:"name"`
+	DataType string               `json:"dataType"`
+	Options  []ProjectFieldOption `json:"options"`
+}
+
+// ProjectFieldOption is a single-select option or an iteration of a ProjectV2 field.
+type ProjectFieldOption struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// OptionIndex returns the position of the given option or iteration ID within the field.
+func (f ProjectField) OptionIndex(id string) (int, bool) {
+	i := slices.IndexFunc(f.Options, func(o ProjectFieldOption) bool { return o.ID == id })
+	return i, i >= 0
+}
+
+// FindProjectField returns the field with the given name, compared case-insensitively.
+func FindProjectField(fields []ProjectField, name string) (ProjectField, bool) {
+	i := slices.IndexFunc(fields, func(f ProjectField) bool { return strings.EqualFold(f.Name, name) })
+	if i < 0 {
+		return ProjectField{}, false
+	}
+	return fields[i], true
+}
+
+const projectFieldsQuery = `
+	query($projectId: ID!) {
+		node(id: $projectId) {
+			... on ProjectV2 {
+				fields(first: 100) {
+					nodes {
+						... on ProjectV2Field {
+							id name dataType
+						}
+						... on ProjectV2SingleSelectField {
+							id name dataType
+							options { id name color }
+						}
+						... on ProjectV2IterationField {
+							id name dataType
+							configuration {
+								iterations { id title }
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+`
+
+// ListProjectFields fetches the fields of the given ProjectV2.
+func (pg *ProjectGateway) ListProjectFields(projectID string) ([]ProjectField, error) {
+	variables := map[string]interface{}{
+		"projectId": projectID,
+	}
+
+	var resp struct {
+		Node *struct {
+			Fields struct {
+				Nodes []struct {
+					ID       string               `json:"id"`
+					Name     string               `json:"name"`
+					DataType string               `json:"dataType"`
+					Options  []ProjectFieldOption `json:"options"`
+					Config   *struct {
+						Iterations []struct {
+							ID    string `json:"id"`
+							Title string `json:"title"`
+						} `json:"iterations"`
+					} `json:"configuration"`
+				} `json:"nodes"`
+			} `json:"fields"`
+		} `json:"node"`
+	}
+
+	if err := pg.client.Do(projectFieldsQuery, variables, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query fields for project %s: %w", projectID, err)
+	}
+	if resp.Node == nil {
+		return nil, fmt.Errorf("project %s not found", projectID)
+	}
+
+	var fields []ProjectField
+	for _, n := range resp.Node.Fields.Nodes {
+		if n.ID == "" {
+			continue
+		}
+		field := ProjectField{ID: n.ID, Name: n.Name, DataType: n.DataType, Options: []ProjectFieldOption{}}
+		switch {
+		case n.DataType == "SINGLE_SELECT":
+			field.Options = append(field.Options, n.Options...)
+		case n.DataType == "ITERATION" && n.Config != nil:
+			for _, it := range n.Config.Iterations {
+				field.Options = append(field.Options, ProjectFieldOption{ID: it.ID, Name: it.Title})
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
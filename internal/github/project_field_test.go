@@ -0,0 +1,77 @@
+package github
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestListProjectFields(t *testing.T) {
+	body := []byte(`{
+		"node": {
+			"fields": {
+				"nodes": [
+					{"id": "F_TITLE", "name": "Title", "dataType": "TITLE"},
+					{"id": "F_PRI", "name": "Priority", "dataType": "SINGLE_SELECT", "options": [
+						{"id": "OPT_P0", "name": "P0", "color": "RED"},
+						{"id": "OPT_P1", "name": "P1", "color": "ORANGE"}
+					]},
+					{"id": "F_IT", "name": "Sprint", "dataType": "ITERATION", "configuration": {"iterations": [
+						{"id": "IT_1", "title": "Sprint 1"}
+					]}},
+					{}
+				]
+			}
+		}
+	}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: body},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	fields, err := gw.ListProjectFields("PVT_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 fields, got %d", len(fields))
+	}
+	if len(fields[0].Options) != 0 {
+		t.Errorf("expected no options for TITLE field, got %+v", fields[0].Options)
+	}
+
+	priority, ok := FindProjectField(fields, "priority")
+	if !ok {
+		t.Fatal("expected to find Priority field")
+	}
+	if i, ok := priority.OptionIndex("OPT_P1"); !ok || i != 1 {
+		t.Errorf("OptionIndex(OPT_P1) = (%d, %v), want (1, true)", i, ok)
+	}
+	if _, ok := priority.OptionIndex("OPT_UNKNOWN"); ok {
+		t.Error("expected unknown option not to be found")
+	}
+
+	sprint, ok := FindProjectField(fields, "Sprint")
+	if !ok || len(sprint.Options) != 1 || sprint.Options[0].Name != "Sprint 1" {
+		t.Errorf("unexpected iteration field: %+v", sprint)
+	}
+
+	if _, ok := FindProjectField(fields, "Status"); ok {
+		t.Error("expected Status field not to be found")
+	}
+}
+
+func TestListProjectFields_Error(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{err: fmt.Errorf("GraphQL error: could not resolve to a node")},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	if _, err := gw.ListProjectFields("PVT_bad"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,363 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProjectItem is a raw ProjectV2 item as returned by the GraphQL API.
+// It marshals back into the same shape the web UI stores in the cache.
+type ProjectItem struct {
+	ID          string                            `json:"id"`
+	UpdatedAt   time.Time                         `json:"updatedAt,omitzero"`
+	Content     *ProjectItemContent               `json:"content"`
+	FieldValues Connection[ProjectItemFieldValue] `json:"fieldValues"`
+}
+
+// ProjectItemContent is the Issue content of a ProjectV2 item.
+// DraftIssue and PullRequest content unmarshal with a zero Number.
+type ProjectItemContent struct {
+	ID         string                `json:"id,omitempty"`
+	Number     int                   `json:"number,omitempty"`
+	Title      string                `json:"title,omitempty"`
+	State      string                `json:"state,omitempty"`
+	Body       *string               `json:"body,omitempty"`
+	URL        string                `json:"url,omitempty"`
+	UpdatedAt  time.Time             `json:"updatedAt,omitzero"`
+	Repository *RepositoryRef        `json:"repository,omitempty"`
+	Labels     *Connection[Label]    `json:"labels,omitempty"`
+	Assignees  *Connection[Assignee] `json:"assignees,omitempty"`
+	SubIssues  *Connection[IssueRef] `json:"subIssues,omitempty"`
+	BlockedBy  *Connection[IssueRef] `json:"blockedBy,omitempty"`
+	Blocking   *Connection[IssueRef] `json:"blocking,omitempty"`
+}
+
+// ProjectItemFieldValue is a single-select, iteration or number value of a ProjectV2 item.
+type ProjectItemFieldValue struct {
+	Field *struct {
+		ID string `json:"id"`
+	} `json:"field,omitempty"`
+	OptionID    string   `json:"optionId,omitempty"`
+	IterationID string   `json:"iterationId,omitempty"`
+	Number      *float64 `json:"number,omitempty"`
+}
+
+// Connection is the nodes part of a GraphQL connection.
+// PageInfo is only set while the remaining pages have not been fetched yet.
+type Connection[T any] struct {
+	Nodes    []T       `json:"nodes"`
+	PageInfo *PageInfo `json:"pageInfo,omitempty"`
+}
+
+// PageInfo is the pagination state of a GraphQL connection.
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// RepositoryRef identifies a repository by owner login and name.
+type RepositoryRef struct {
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Name string `json:"name"`
+}
+
+// IssueRef identifies a related issue.
+type IssueRef struct {
+	Number     int           `json:"number"`
+	Repository RepositoryRef `json:"repository"`
+}
+
+// ID returns the composite ID of the referenced issue.
+func (r IssueRef) ID() string {
+	return BuildIssueID(r.Repository.Owner.Login, r.Repository.Name, r.Number)
+}
+
+// IsIssue reports whether the item content is an Issue.
+// The `... on Issue` fragment yields an empty object for DraftIssue, so Number is checked.
+func (it ProjectItem) IsIssue() bool {
+	return it.Content != nil && it.Content.Number != 0 && it.Content.Repository != nil
+}
+
+// Selections of the nested connections of a project item, shared by the items query
+// and the queries that fetch their remaining pages.
+const (
+	issueRefFields   = `number repository { owner { login } name }`
+	labelFields      = `name color`
+	assigneeFields   = `login avatarUrl`
+	fieldValueFields = `
+		... on ProjectV2ItemFieldSingleSelectValue { field { ... on ProjectV2FieldCommon { id } } optionId }
+		... on ProjectV2ItemFieldIterationValue { field { ... on ProjectV2FieldCommon { id } } iterationId }
+		... on ProjectV2ItemFieldNumberValue { field { ... on ProjectV2FieldCommon { id } } number }
+	`
+	pageInfoFields  = `pageInfo { hasNextPage endCursor }`
+	rateLimitFields = `rateLimit { limit remaining cost resetAt }`
+)
+
+// projectItemFields is the selection of a project item, shared by the items query and
+// the query that fetches changed items by ID.
+const projectItemFields = `
+	id updatedAt
+	content {
+		... on Issue {
+			id number title state body url updatedAt
+			repository { owner { login } name }
+			labels(first: 20) { ` + pageInfoFields + ` nodes { ` + labelFields + ` } }
+			assignees(first: 10) { ` + pageInfoFields + ` nodes { ` + assigneeFields + ` } }
+			subIssues(first: 50) { ` + pageInfoFields + ` nodes { ` + issueRefFields + ` } }
+			blockedBy(first: 50) { ` + pageInfoFields + ` nodes { ` + issueRefFields + ` } }
+			blocking(first: 50) { ` + pageInfoFields + ` nodes { ` + issueRefFields + ` } }
+		}
+	}
+	fieldValues(first: 20) { ` + pageInfoFields + ` nodes { ` + fieldValueFields + ` } }
+`
+
+const projectItemsQuery = `
+	query($projectId: ID!, $first: Int!, $after: String) {
+		` + rateLimitFields + `
+		node(id: $projectId) {
+			... on ProjectV2 {
+				items(first: $first, after: $after) {
+					pageInfo { hasNextPage endCursor }
+					nodes { ` + projectItemFields + ` }
+				}
+			}
+		}
+	}
+`
+
+// FetchProjectItems fetches all raw items of the given ProjectV2, following pagination,
+// including the pages of the nested connections past the first one.
+func (pg *ProjectGateway) FetchProjectItems(projectID string) ([]ProjectItem, error) {
+	items, _, err := pg.fetchProjectItems(projectID)
+	return items, err
+}
+
+func (pg *ProjectGateway) fetchProjectItems(projectID string) ([]ProjectItem, *RateLimit, error) {
+	items, rateLimit, err := pg.fetchItemPages(projectItemsQuery, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		if err := pg.fetchNestedPages(&items[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, rateLimit, nil
+}
+
+// fetchItemPages runs an items query of the given ProjectV2, following pagination.
+// It also returns the rate limit budget after the last page, if the query asked for it.
+func (pg *ProjectGateway) fetchItemPages(query, projectID string) ([]ProjectItem, *RateLimit, error) {
+	var items []ProjectItem
+	var rateLimit *RateLimit
+	var after interface{}
+	hasNextPage := true
+
+	for hasNextPage {
+		variables := map[string]interface{}{
+			"projectId": projectID,
+			"first":     100,
+			"after":     after,
+		}
+
+		var resp struct {
+			RateLimit *RateLimit `json:"rateLimit"`
+			Node      *struct {
+				Items struct {
+					Nodes    []ProjectItem `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"items"`
+			} `json:"node"`
+		}
+
+		if err := pg.client.Do(query, variables, &resp); err != nil {
+			return nil, nil, fmt.Errorf("failed to query items for project %s: %w", projectID, err)
+		}
+		if resp.Node == nil {
+			return nil, nil, fmt.Errorf("project %s not found", projectID)
+		}
+
+		items = append(items, resp.Node.Items.Nodes...)
+		if resp.RateLimit != nil {
+			rateLimit = resp.RateLimit
+		}
+		hasNextPage = resp.Node.Items.PageInfo.HasNextPage
+		after = resp.Node.Items.PageInfo.EndCursor
+	}
+	return items, rateLimit, nil
+}
+
+// fetchNestedPages fetches the remaining pages of the nested connections of an item,
+// so epics with many sub-issues or dependencies are not cut off.
+func (pg *ProjectGateway) fetchNestedPages(item *ProjectItem) error {
+	if err := fetchRemainingPages(pg.client, "ProjectV2Item", item.ID, "fieldValues", fieldValueFields, &item.FieldValues); err != nil {
+		return err
+	}
+	c := item.Content
+	if c == nil || c.ID == "" {
+		return nil
+	}
+	for _, conn := range []struct {
+		name   string
+		fields string
+		conn   *Connection[IssueRef]
+	}{
+		{"subIssues", issueRefFields, c.SubIssues},
+		{"blockedBy", issueRefFields, c.BlockedBy},
+		{"blocking", issueRefFields, c.Blocking},
+	} {
+		if err := fetchRemainingPages(pg.client, "Issue", c.ID, conn.name, conn.fields, conn.conn); err != nil {
+			return err
+		}
+	}
+	if err := fetchRemainingPages(pg.client, "Issue", c.ID, "labels", labelFields, c.Labels); err != nil {
+		return err
+	}
+	return fetchRemainingPages(pg.client, "Issue", c.ID, "assignees", assigneeFields, c.Assignees)
+}
+
+// fetchRemainingPages appends the pages of conn after the first one, fetched through
+// the node with the given ID and type, and clears its PageInfo.
+func fetchRemainingPages[T any](client GQLClient, nodeType, nodeID, name, fields string, conn *Connection[T]) error {
+	if conn == nil {
+		return nil
+	}
+	query := fmt.Sprintf(`
+		query($id: ID!, $after: String) {
+			node(id: $id) {
+				... on %s {
+					%s(first: 100, after: $after) { %s nodes { %s } }
+				}
+			}
+		}
+	`, nodeType, name, pageInfoFields, fields)
+
+	for conn.PageInfo != nil && conn.PageInfo.HasNextPage {
+		variables := map[string]interface{}{"id": nodeID, "after": conn.PageInfo.EndCursor}
+		var resp struct {
+			Node map[string]*Connection[T] `json:"node"`
+		}
+		if err := client.Do(query, variables, &resp); err != nil {
+			return fmt.Errorf("failed to query %s of %s: %w", name, nodeID, err)
+		}
+		page := resp.Node[name]
+		if page == nil {
+			return fmt.Errorf("failed to query %s of %s: node not found", name, nodeID)
+		}
+		conn.Nodes = append(conn.Nodes, page.Nodes...)
+		conn.PageInfo = page.PageInfo
+	}
+	conn.PageInfo = nil
+	return nil
+}
+
+// ListProjectItems fetches the items of the given ProjectV2 and returns
+// the issues and dependencies between them.
+func (pg *ProjectGateway) ListProjectItems(projectID string) ([]Issue, []Dependency, error) {
+	items, err := pg.FetchProjectItems(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseProjectItems(items), ParseProjectDependencies(items), nil
+}
+
+// ParseProjectItems converts raw items into issues. Non-issue items are skipped.
+func ParseProjectItems(items []ProjectItem) []Issue {
+	issues := make([]Issue, 0, len(items))
+	for _, item := range items {
+		if !item.IsIssue() {
+			continue
+		}
+		c := item.Content
+		owner := c.Repository.Owner.Login
+		repo := c.Repository.Name
+
+		fieldValues := make(map[string]string)
+		numberValues := make(map[string]float64)
+		for _, fv := range item.FieldValues.Nodes {
+			if fv.Field == nil || fv.Field.ID == "" {
+				continue
+			}
+			switch {
+			case fv.OptionID != "":
+				fieldValues[fv.Field.ID] = fv.OptionID
+			case fv.IterationID != "":
+				fieldValues[fv.Field.ID] = fv.IterationID
+			case fv.Number != nil:
+				numberValues[fv.Field.ID] = *fv.Number
+			}
+		}
+
+		issue := Issue{
+			ID:           BuildIssueID(owner, repo, c.Number),
+			ItemID:       item.ID,
+			NodeID:       c.ID,
+			Number:       c.Number,
+			Owner:        owner,
+			Repo:         repo,
+			Title:        c.Title,
+			State:        strings.ToLower(c.State),
+			URL:          c.URL,
+			Labels:       []Label{},
+			Assignees:    []Assignee{},
+			FieldValues:  fieldValues,
+			NumberValues: numberValues,
+		}
+		if c.Body != nil {
+			issue.Body = *c.Body
+		}
+		if c.Labels != nil {
+			issue.Labels = append(issue.Labels, c.Labels.Nodes...)
+		}
+		if c.Assignees != nil {
+			issue.Assignees = append(issue.Assignees, c.Assignees.Nodes...)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// ParseProjectDependencies builds dependencies from subIssues, blockedBy and blocking.
+// blockedBy and blocking describe the same edge from both sides, so duplicates are removed.
+func ParseProjectDependencies(items []ProjectItem) []Dependency {
+	deps := []Dependency{}
+	seen := make(map[Dependency]bool)
+	add := func(d Dependency) {
+		if seen[d] {
+			return
+		}
+		seen[d] = true
+		deps = append(deps, d)
+	}
+
+	for _, item := range items {
+		if !item.IsIssue() {
+			continue
+		}
+		c := item.Content
+		currentID := BuildIssueID(c.Repository.Owner.Login, c.Repository.Name, c.Number)
+
+		if c.SubIssues != nil {
+			for _, sub := range c.SubIssues.Nodes {
+				add(Dependency{Source: currentID, Target: sub.ID(), Type: DependencySubIssue})
+			}
+		}
+		if c.BlockedBy != nil {
+			for _, blocker := range c.BlockedBy.Nodes {
+				add(Dependency{Source: blocker.ID(), Target: currentID, Type: DependencyBlockedBy})
+			}
+		}
+		if c.Blocking != nil {
+			for _, blocked := range c.Blocking.Nodes {
+				add(Dependency{Source: currentID, Target: blocked.ID(), Type: DependencyBlockedBy})
+			}
+		}
+	}
+	return deps
+}
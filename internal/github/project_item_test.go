@@ -0,0 +1,242 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestListProjectItems(t *testing.T) {
+	page1 := []byte(`{
+		"node": {
+			"items": {
+				"nodes": [
+					{
+						"id": "PVTI_1",
+						"content": {
+							"id": "I_1", "number": 1, "title": "Epic", "state": "OPEN", "body": "epic body",
+							"url": "https://github.com/owner/repo/issues/1",
+							"repository": {"owner": {"login": "owner"}, "name": "repo"},
+							"labels": {"nodes": [{"name": "epic", "color": "ff0000"}]},
+							"assignees": {"nodes": [{"login": "alice", "avatarUrl": "https://example.com/a.png"}]},
+							"subIssues": {"nodes": [{"number": 2, "repository": {"owner": {"login": "owner"}, "name": "repo"}}]},
+							"blockedBy": {"nodes": []},
+							"blocking": {"nodes": []}
+						},
+						"fieldValues": {"nodes": [{"field": {"id": "F_1"}, "optionId": "OPT_1"}, {"field": {"id": "F_EST"}, "number": 3.5}, {}]}
+					},
+					{"id": "PVTI_DRAFT", "content": {}, "fieldValues": {"nodes": []}}
+				],
+				"pageInfo": {"hasNextPage": true, "endCursor": "cursor_1"}
+			}
+		}
+	}`)
+	page2 := []byte(`{
+		"node": {
+			"items": {
+				"nodes": [
+					{
+						"id": "PVTI_2",
+						"content": {
+							"id": "I_2", "number": 2, "title": "Child", "state": "CLOSED", "body": null,
+							"url": "https://github.com/owner/repo/issues/2",
+							"repository": {"owner": {"login": "owner"}, "name": "repo"},
+							"labels": {"nodes": []},
+							"assignees": {"nodes": []},
+							"subIssues": {"nodes": []},
+							"blockedBy": {"nodes": [{"number": 3, "repository": {"owner": {"login": "other"}, "name": "lib"}}]},
+							"blocking": {"nodes": []}
+						},
+						"fieldValues": {"nodes": [{"field": {"id": "F_2"}, "iterationId": "IT_1"}]}
+					},
+					{
+						"id": "PVTI_3",
+						"content": {
+							"id": "I_3", "number": 3, "title": "Lib", "state": "OPEN", "body": "",
+							"url": "https://github.com/other/lib/issues/3",
+							"repository": {"owner": {"login": "other"}, "name": "lib"},
+							"labels": {"nodes": []},
+							"assignees": {"nodes": []},
+							"subIssues": {"nodes": []},
+							"blockedBy": {"nodes": []},
+							"blocking": {"nodes": [{"number": 2, "repository": {"owner": {"login": "owner"}, "name": "repo"}}]}
+						},
+						"fieldValues": {"nodes": []}
+					}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: page1},
+			{body: page2},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	issues, deps, err := gw.ListProjectItems("PVT_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues (draft excluded), got %d", len(issues))
+	}
+	epic := issues[0]
+	if epic.ID != "owner/repo#1" || epic.ItemID != "PVTI_1" || epic.NodeID != "I_1" || epic.State != "open" {
+		t.Errorf("unexpected first issue: %+v", epic)
+	}
+	if epic.Body != "epic body" || len(epic.Labels) != 1 || len(epic.Assignees) != 1 {
+		t.Errorf("unexpected first issue details: %+v", epic)
+	}
+	if epic.FieldValues["F_1"] != "OPT_1" {
+		t.Errorf("expected field value OPT_1, got %v", epic.FieldValues)
+	}
+	if epic.NumberValues["F_EST"] != 3.5 {
+		t.Errorf("expected number value 3.5, got %v", epic.NumberValues)
+	}
+	if issues[1].State != "closed" || issues[1].Body != "" || issues[1].FieldValues["F_2"] != "IT_1" {
+		t.Errorf("unexpected second issue: %+v", issues[1])
+	}
+
+	want := []Dependency{
+		{Source: "owner/repo#1", Target: "owner/repo#2", Type: DependencySubIssue},
+		{Source: "other/lib#3", Target: "owner/repo#2", Type: DependencyBlockedBy},
+	}
+	if len(deps) != len(want) {
+		t.Fatalf("expected %d dependencies, got %d: %+v", len(want), len(deps), deps)
+	}
+	for i := range want {
+		if deps[i] != want[i] {
+			t.Errorf("deps[%d] = %+v, want %+v", i, deps[i], want[i])
+		}
+	}
+}
+
+func TestFetchProjectItems_FollowsNestedPages(t *testing.T) {
+	items := []byte(`{
+		"node": {
+			"items": {
+				"nodes": [
+					{
+						"id": "PVTI_1",
+						"content": {
+							"id": "I_1", "number": 1, "title": "Epic", "state": "OPEN",
+							"repository": {"owner": {"login": "owner"}, "name": "repo"},
+							"labels": {"nodes": [], "pageInfo": {"hasNextPage": false, "endCursor": null}},
+							"assignees": {"nodes": []},
+							"subIssues": {
+								"nodes": [{"number": 2, "repository": {"owner": {"login": "owner"}, "name": "repo"}}],
+								"pageInfo": {"hasNextPage": true, "endCursor": "sub_1"}
+							},
+							"blockedBy": {"nodes": []},
+							"blocking": {"nodes": []}
+						},
+						"fieldValues": {
+							"nodes": [{"field": {"id": "F_1"}, "optionId": "OPT_1"}],
+							"pageInfo": {"hasNextPage": true, "endCursor": "fv_1"}
+						}
+					}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+	fieldValues := []byte(`{"node": {"fieldValues": {
+		"nodes": [{"field": {"id": "F_2"}, "iterationId": "IT_1"}],
+		"pageInfo": {"hasNextPage": false, "endCursor": "fv_2"}
+	}}}`)
+	subIssues2 := []byte(`{"node": {"subIssues": {
+		"nodes": [{"number": 3, "repository": {"owner": {"login": "owner"}, "name": "repo"}}],
+		"pageInfo": {"hasNextPage": true, "endCursor": "sub_2"}
+	}}}`)
+	subIssues3 := []byte(`{"node": {"subIssues": {
+		"nodes": [{"number": 4, "repository": {"owner": {"login": "owner"}, "name": "repo"}}],
+		"pageInfo": {"hasNextPage": false, "endCursor": "sub_3"}
+	}}}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{{body: items}, {body: fieldValues}, {body: subIssues2}, {body: subIssues3}},
+	}
+	gw := NewProjectGateway(client)
+
+	got, err := gw.FetchProjectItems("PVT_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.calls) != 4 {
+		t.Fatalf("expected 4 calls, got %d", len(client.calls))
+	}
+	if v := client.calls[1].variables; v["id"] != "PVTI_1" || v["after"] != "fv_1" {
+		t.Errorf("unexpected field values page variables: %v", v)
+	}
+	if v := client.calls[3].variables; v["id"] != "I_1" || v["after"] != "sub_2" {
+		t.Errorf("unexpected sub-issues page variables: %v", v)
+	}
+
+	item := got[0]
+	if n := len(item.Content.SubIssues.Nodes); n != 3 {
+		t.Errorf("expected 3 sub-issues, got %d", n)
+	}
+	if n := len(item.FieldValues.Nodes); n != 2 {
+		t.Errorf("expected 2 field values, got %d", n)
+	}
+	// The items keep the shape the web UI caches.
+	b, _ := json.Marshal(item)
+	if strings.Contains(string(b), "pageInfo") {
+		t.Errorf("expected pageInfo to be cleared, got %s", b)
+	}
+}
+
+func TestFetchProjectItems_NotFound(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"node": null}`)},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	if _, err := gw.FetchProjectItems("PVT_missing"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFetchProjectItems_Error(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{err: fmt.Errorf("GraphQL error: something went wrong")},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	if _, err := gw.FetchProjectItems("PVT_1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestProjectItem_MarshalKeepsDraftWithoutNumber(t *testing.T) {
+	var item ProjectItem
+	if err := json.Unmarshal([]byte(`{"id":"PVTI_DRAFT","content":{},"fieldValues":{"nodes":[]}}`), &item); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if item.IsIssue() {
+		t.Fatal("expected draft item not to be an issue")
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var raw struct {
+		Content map[string]any `json:"content"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatalf("failed to parse marshaled item: %v", err)
+	}
+	if _, ok := raw.Content["number"]; ok {
+		t.Errorf("expected draft content without number, got %s", b)
+	}
+}
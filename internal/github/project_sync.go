@@ -0,0 +1,180 @@
+package github
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// projectItemStampsQuery lists only the IDs and update times of the items of a ProjectV2.
+// It is much cheaper than projectItemsQuery and tells which items changed or went away.
+const projectItemStampsQuery = `
+	query($projectId: ID!, $first: Int!, $after: String) {
+		` + rateLimitFields + `
+		node(id: $projectId) {
+			... on ProjectV2 {
+				items(first: $first, after: $after) {
+					pageInfo { hasNextPage endCursor }
+					nodes {
+						id updatedAt
+						content {
+							... on Issue { updatedAt }
+							... on PullRequest { updatedAt }
+							... on DraftIssue { updatedAt }
+						}
+					}
+				}
+			}
+		}
+	}
+`
+
+const projectItemsByIDQuery = `
+	query($ids: [ID!]!) {
+		` + rateLimitFields + `
+		nodes(ids: $ids) {
+			... on ProjectV2Item { ` + projectItemFields + ` }
+		}
+	}
+`
+
+// maxNodesPerQuery is the number of IDs GitHub accepts in a single nodes query.
+const maxNodesPerQuery = 100
+
+// ProjectSync is the result of SyncProjectItems.
+type ProjectSync struct {
+	// Items are all items of the project in project order.
+	Items []ProjectItem
+	// SyncedAt is the watermark to pass to the next sync: the latest update time seen.
+	SyncedAt time.Time
+	// Full reports whether all items were fetched because there was no watermark.
+	Full bool
+	// Fetched and Removed count the items fetched in full and dropped from the cached items.
+	Fetched int
+	Removed int
+	// RateLimit is the GraphQL budget left after the sync, if GitHub reported it.
+	RateLimit *RateLimit
+}
+
+// RateLimit is the GraphQL rate limit budget as reported by the rateLimit field.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Cost      int       `json:"cost"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// LastUpdated returns the later of the update times of the item and of its content.
+// Field value changes only touch the item, issue edits and new relations only the content.
+func (it ProjectItem) LastUpdated() time.Time {
+	t := it.UpdatedAt
+	if it.Content != nil && it.Content.UpdatedAt.After(t) {
+		t = it.Content.UpdatedAt
+	}
+	return t
+}
+
+// SyncProjectItems brings cached, the items of the given ProjectV2 as of the watermark
+// since, up to date. It lists the IDs and update times of all items, fetches in full only
+// the items updated since the watermark, oldest first, and drops the cached items that are
+// no longer in the project. Without a watermark all items are fetched.
+func (pg *ProjectGateway) SyncProjectItems(projectID string, cached []ProjectItem, since time.Time) (*ProjectSync, error) {
+	if since.IsZero() {
+		items, rateLimit, err := pg.fetchProjectItems(projectID)
+		if err != nil {
+			return nil, err
+		}
+		return &ProjectSync{Items: items, SyncedAt: latestUpdate(items), Full: true, Fetched: len(items), RateLimit: rateLimit}, nil
+	}
+
+	stamps, rateLimit, err := pg.fetchItemPages(projectItemStampsQuery, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]ProjectItem, len(cached))
+	for _, it := range cached {
+		known[it.ID] = it
+	}
+	// Items updated in the same second as the watermark are fetched again,
+	// since GitHub's timestamps cannot tell them apart.
+	var changed []ProjectItem
+	for _, s := range stamps {
+		if _, ok := known[s.ID]; !ok || !s.LastUpdated().Before(since) {
+			changed = append(changed, s)
+		}
+	}
+	slices.SortStableFunc(changed, func(a, b ProjectItem) int { return a.LastUpdated().Compare(b.LastUpdated()) })
+
+	fetched, fetchRateLimit, err := pg.fetchItemsByID(changed)
+	if err != nil {
+		return nil, err
+	}
+	if fetchRateLimit != nil {
+		rateLimit = fetchRateLimit
+	}
+
+	result := &ProjectSync{SyncedAt: since, Fetched: len(fetched), RateLimit: rateLimit}
+	present := make(map[string]bool, len(stamps))
+	for _, s := range stamps {
+		present[s.ID] = true
+		if it, ok := fetched[s.ID]; ok {
+			result.Items = append(result.Items, it)
+		} else if it, ok := known[s.ID]; ok {
+			result.Items = append(result.Items, it)
+		}
+		// A new item deleted between the two queries is left out.
+	}
+	for id := range known {
+		if !present[id] {
+			result.Removed++
+		}
+	}
+	if t := latestUpdate(stamps); t.After(result.SyncedAt) {
+		result.SyncedAt = t
+	}
+	return result, nil
+}
+
+// fetchItemsByID fetches the given items in full, in batches, keyed by item ID.
+// It also returns the rate limit budget after the last batch.
+func (pg *ProjectGateway) fetchItemsByID(items []ProjectItem) (map[string]ProjectItem, *RateLimit, error) {
+	fetched := make(map[string]ProjectItem, len(items))
+	var rateLimit *RateLimit
+	for batch := range slices.Chunk(items, maxNodesPerQuery) {
+		ids := make([]string, len(batch))
+		for i, it := range batch {
+			ids[i] = it.ID
+		}
+		var resp struct {
+			RateLimit *RateLimit     `json:"rateLimit"`
+			Nodes     []*ProjectItem `json:"nodes"`
+		}
+		if err := pg.client.Do(projectItemsByIDQuery, map[string]interface{}{"ids": ids}, &resp); err != nil {
+			return nil, nil, fmt.Errorf("failed to query changed project items: %w", err)
+		}
+		if resp.RateLimit != nil {
+			rateLimit = resp.RateLimit
+		}
+		for _, it := range resp.Nodes {
+			if it == nil || it.ID == "" {
+				continue
+			}
+			if err := pg.fetchNestedPages(it); err != nil {
+				return nil, nil, err
+			}
+			fetched[it.ID] = *it
+		}
+	}
+	return fetched, rateLimit, nil
+}
+
+func latestUpdate(items []ProjectItem) time.Time {
+	var latest time.Time
+	for _, it := range items {
+		if t := it.LastUpdated(); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,112 @@
+package github
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSyncProjectItems_FetchesChangedItems(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var cached []ProjectItem
+	json.Unmarshal([]byte(`[
+		{"id": "PVTI_1", "content": {"id": "I_1", "number": 1, "title": "Old"}, "fieldValues": {"nodes": []}},
+		{"id": "PVTI_2", "content": {"id": "I_2", "number": 2, "title": "Old"}, "fieldValues": {"nodes": []}},
+		{"id": "PVTI_GONE", "content": {}, "fieldValues": {"nodes": []}}
+	]`), &cached)
+
+	stamps := []byte(`{
+		"node": {
+			"items": {
+				"nodes": [
+					{"id": "PVTI_1", "updatedAt": "2025-12-01T00:00:00Z", "content": {"updatedAt": "2025-12-01T00:00:00Z"}},
+					{"id": "PVTI_NEW", "updatedAt": "2026-01-03T00:00:00Z", "content": {"updatedAt": "2026-01-03T00:00:00Z"}},
+					{"id": "PVTI_2", "updatedAt": "2025-12-01T00:00:00Z", "content": {"updatedAt": "2026-01-02T00:00:00Z"}}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+	changed := []byte(`{
+		"rateLimit": {"limit": 5000, "remaining": 4980, "cost": 1, "resetAt": "2026-01-03T01:00:00Z"},
+		"nodes": [
+			{"id": "PVTI_2", "content": {"id": "I_2", "number": 2, "title": "Renamed"}, "fieldValues": {"nodes": []}},
+			{"id": "PVTI_NEW", "content": {"id": "I_3", "number": 3, "title": "New"}, "fieldValues": {"nodes": []}}
+		]
+	}`)
+	client := &mockGQLClient{responses: []mockResponse{{body: stamps}, {body: changed}}}
+	gw := NewProjectGateway(client)
+
+	got, err := gw.SyncProjectItems("PVT_1", cached, since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only the changed items are fetched, oldest first.
+	if ids := client.calls[1].variables["ids"]; !slices.Equal(ids.([]string), []string{"PVTI_2", "PVTI_NEW"}) {
+		t.Errorf("fetched ids = %v", ids)
+	}
+
+	titles := make([]string, len(got.Items))
+	for i, it := range got.Items {
+		titles[i] = it.ID + ":" + it.Content.Title
+	}
+	// The items keep the project order, and the removed item is dropped.
+	if want := []string{"PVTI_1:Old", "PVTI_NEW:New", "PVTI_2:Renamed"}; !slices.Equal(titles, want) {
+		t.Errorf("items = %v, want %v", titles, want)
+	}
+	if got.Full || got.Fetched != 2 || got.Removed != 1 {
+		t.Errorf("sync = full %v, fetched %d, removed %d", got.Full, got.Fetched, got.Removed)
+	}
+	if want := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC); !got.SyncedAt.Equal(want) {
+		t.Errorf("SyncedAt = %v, want %v", got.SyncedAt, want)
+	}
+	if got.RateLimit == nil || got.RateLimit.Remaining != 4980 {
+		t.Errorf("RateLimit = %+v", got.RateLimit)
+	}
+}
+
+func TestSyncProjectItems_FullWithoutWatermark(t *testing.T) {
+	items := []byte(`{
+		"node": {
+			"items": {
+				"nodes": [
+					{"id": "PVTI_1", "updatedAt": "2026-01-01T00:00:00Z", "content": {"id": "I_1", "number": 1, "updatedAt": "2026-01-05T00:00:00Z"}, "fieldValues": {"nodes": []}}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+	client := &mockGQLClient{responses: []mockResponse{{body: items}}}
+	gw := NewProjectGateway(client)
+
+	got, err := gw.SyncProjectItems("PVT_1", nil, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Full || got.Fetched != 1 || len(got.Items) != 1 {
+		t.Errorf("sync = %+v", got)
+	}
+	if want := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC); !got.SyncedAt.Equal(want) {
+		t.Errorf("SyncedAt = %v, want %v", got.SyncedAt, want)
+	}
+}
+
+func TestSyncProjectItems_NothingChanged(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cached := []ProjectItem{{ID: "PVTI_1"}}
+	stamps := []byte(`{"node": {"items": {
+		"nodes": [{"id": "PVTI_1", "updatedAt": "2025-12-01T00:00:00Z", "content": {}}],
+		"pageInfo": {"hasNextPage": false, "endCursor": ""}
+	}}}`)
+	client := &mockGQLClient{responses: []mockResponse{{body: stamps}}}
+	gw := NewProjectGateway(client)
+
+	got, err := gw.SyncProjectItems("PVT_1", cached, since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.calls) != 1 || got.Fetched != 0 || len(got.Items) != 1 || !got.SyncedAt.Equal(since) {
+		t.Errorf("calls = %d, sync = %+v", len(client.calls), got)
+	}
+}
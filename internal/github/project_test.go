@@ -9,6 +9,12 @@ import (
 type mockGQLClient struct {
 	responses []mockResponse
 	callIndex int
+	calls     []mockCall
+}
+
+type mockCall struct {
+	query     string
+	variables map[string]interface{}
 }
 
 type mockResponse struct {
@@ -25,6 +31,7 @@ func (m *mockGQLClient) Do(query string, variables map[string]interface{}, respo
 	}
 	r := m.responses[m.callIndex]
 	m.callIndex++
+	m.calls = append(m.calls, mockCall{query: query, variables: variables})
 	if r.err != nil {
 		return r.err
 	}
@@ -153,3 +160,181 @@ func TestListRepoProjects_Error(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestListUserProjects_Pagination(t *testing.T) {
+	page1 := []byte(`{
+		"user": {
+			"projectsV2": {
+				"nodes": [
+					{"id": "PVT_1", "title": "Roadmap", "number": 1, "closed": false, "items": {"totalCount": 42}}
+				],
+				"pageInfo": {"hasNextPage": true, "endCursor": "cursor_1"}
+			}
+		}
+	}`)
+	page2 := []byte(`{
+		"user": {
+			"projectsV2": {
+				"nodes": [
+					{"id": "PVT_2", "title": "Archive", "number": 2, "closed": true, "items": {"totalCount": 3}}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: page1},
+			{body: page2},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	projects, err := gw.ListUserProjects("octocat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Project{
+		{ID: "PVT_1", Title: "Roadmap", Number: 1, Closed: false, ItemCount: 42},
+		{ID: "PVT_2", Title: "Archive", Number: 2, Closed: true, ItemCount: 3},
+	}
+	if len(projects) != len(want) {
+		t.Fatalf("expected %d projects, got %d", len(want), len(projects))
+	}
+	for i := range want {
+		if projects[i] != want[i] {
+			t.Errorf("project %d: expected %+v, got %+v", i, want[i], projects[i])
+		}
+	}
+	if got := client.calls[0].variables["owner"]; got != "octocat" {
+		t.Errorf("expected owner variable 'octocat', got %v", got)
+	}
+	if got := client.calls[1].variables["after"]; got != "cursor_1" {
+		t.Errorf("expected after variable 'cursor_1', got %v", got)
+	}
+}
+
+func TestListOrgProjects(t *testing.T) {
+	body := []byte(`{
+		"organization": {
+			"projectsV2": {
+				"nodes": [
+					{"id": "PVT_9", "title": "Org Board", "number": 9, "closed": false, "items": {"totalCount": 7}}
+				],
+				"pageInfo": {"hasNextPage": false, "endCursor": ""}
+			}
+		}
+	}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: body},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	projects, err := gw.ListOrgProjects("my-org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(projects) != 1 || projects[0].ID != "PVT_9" || projects[0].ItemCount != 7 {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+}
+
+func TestListOrgProjects_NotFound(t *testing.T) {
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: []byte(`{"organization": null}`)},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	if _, err := gw.ListOrgProjects("missing"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestGetOwnerType(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    OwnerType
+		wantErr bool
+	}{
+		{name: "user", body: `{"repositoryOwner": {"__typename": "User"}}`, want: OwnerUser},
+		{name: "organization", body: `{"repositoryOwner": {"__typename": "Organization"}}`, want: OwnerOrganization},
+		{name: "not found", body: `{"repositoryOwner": null}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockGQLClient{
+				responses: []mockResponse{
+					{body: []byte(tt.body)},
+				},
+			}
+			gw := NewProjectGateway(client)
+
+			got, err := gw.GetOwnerType("someone")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetProjects(t *testing.T) {
+	body := []byte(`{
+		"nodes": [
+			{"id": "PVT_1", "title": "Roadmap", "number": 1, "closed": false, "items": {"totalCount": 5}},
+			null,
+			{}
+		]
+	}`)
+
+	client := &mockGQLClient{
+		responses: []mockResponse{
+			{body: body},
+		},
+	}
+	gw := NewProjectGateway(client)
+
+	projects, err := gw.GetProjects([]string{"PVT_1", "PVT_gone", "I_issue"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(projects) != 1 || projects[0].ID != "PVT_1" || projects[0].Title != "Roadmap" {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+}
+
+func TestIsProjectID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "PVT_kwDOABCD1234", want: true},
+		{id: "PVT_kwHO-x_y", want: true},
+		{id: "PVT_", want: false},
+		{id: "I_kwDOABCD", want: false},
+		{id: "PVT_../../etc", want: false},
+		{id: "PVT_a/b", want: false},
+		{id: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsProjectID(tt.id); got != tt.want {
+			t.Errorf("IsProjectID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrCycle は循環があるため最長経路を求められないことを表す。
+var ErrCycle = errors.New("blocked-by dependencies contain a cycle")
+
+// WeightFunc はノードの重みを返す。
+type WeightFunc func(id string) float64
+
+// UnitWeight は全ノードの重みを 1 とする WeightFunc。経路上の Issue 数が長さになる。
+func UnitWeight(string) float64 { return 1 }
+
+// NumberFieldWeight は NUMBER フィールドの値を重みとする WeightFunc を返す。
+// 値が未設定の Issue とプロジェクト外の Issue の重みは 0 とする。
+func NumberFieldWeight(g *Graph, fieldID string) WeightFunc {
+	return func(id string) float64 {
+		return g.issues[id].NumberValues[fieldID]
+	}
+}
+
+// CriticalPath は open な blocked-by の連鎖のうち、重みの合計が最大のもの。
+// IDs はブロックしている側から順に並ぶ。
+type CriticalPath struct {
+	IDs   []string `json:"ids"`
+	Total float64  `json:"total"`
+}
+
+// FindCriticalPath は open な Issue 間の blocked-by エッジだけからなる DAG 上で、
+// ノードの重みの合計が最大となる経路を求める。同じ重みの経路が複数ある場合は
+// 複合 ID 順で先に来るノードを優先する。プロジェクト外の Issue は open として扱う。
+func FindCriticalPath(g *Graph, weight WeightFunc) (CriticalPath, error) {
+	if weight == nil {
+		weight = UnitWeight
+	}
+
+	order, err := g.openBlockedByTopologicalOrder()
+	if err != nil {
+		return CriticalPath{}, err
+	}
+
+	dist := make(map[string]float64, len(order))
+	prev := make(map[string]string, len(order))
+	for _, id := range order {
+		dist[id] = weight(id)
+	}
+	for _, id := range order {
+		for _, next := range g.blocking[id] {
+			if _, ok := dist[next]; !ok {
+				continue
+			}
+			if d := dist[id] + weight(next); d > dist[next] {
+				dist[next] = d
+				prev[next] = id
+			}
+		}
+	}
+
+	var end string
+	for _, id := range g.ids {
+		if _, ok := dist[id]; !ok {
+			continue
+		}
+		if end == "" || dist[id] > dist[end] {
+			end = id
+		}
+	}
+	if end == "" {
+		return CriticalPath{IDs: []string{}}, nil
+	}
+
+	path := []string{end}
+	for id := end; prev[id] != ""; id = prev[id] {
+		path = append(path, prev[id])
+	}
+	slices.Reverse(path)
+	return CriticalPath{IDs: path, Total: dist[end]}, nil
+}
+
+// openBlockedByTopologicalOrder は open なノードを blocked-by エッジでトポロジカルソートする。
+// 同順位のノードは複合 ID 順に並べる。
+func (g *Graph) openBlockedByTopologicalOrder() ([]string, error) {
+	inDegree := make(map[string]int)
+	for _, id := range g.ids {
+		if g.isOpen(id) {
+			inDegree[id] = 0
+		}
+	}
+	for id := range inDegree {
+		for _, next := range g.blocking[id] {
+			if g.isOpen(next) {
+				inDegree[next]++
+			}
+		}
+	}
+
+	var queue, order []string
+	for _, id := range g.ids {
+		if d, ok := inDegree[id]; ok && d == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, next := range g.blocking[id] {
+			if !g.isOpen(next) {
+				continue
+			}
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(order) != len(inDegree) {
+		return nil, ErrCycle
+	}
+	return order, nil
+}
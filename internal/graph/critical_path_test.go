@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func withEstimate(issue github.Issue, estimate float64) github.Issue {
+	issue.NumberValues = map[string]float64{"F_EST": estimate}
+	return issue
+}
+
+func newCriticalPathGraph() *Graph {
+	// 1 -> 2 -> 4 (estimates 1, 1, 1)
+	// 3 -> 4      (estimate 5)
+	// 5 (closed) -> 6
+	return New(
+		[]github.Issue{
+			withEstimate(newIssue("o/r#1", "open", "A"), 1),
+			withEstimate(newIssue("o/r#2", "open", "B"), 1),
+			withEstimate(newIssue("o/r#3", "open", "C"), 5),
+			withEstimate(newIssue("o/r#4", "open", "D"), 1),
+			withEstimate(newIssue("o/r#5", "closed", "E"), 100),
+			withEstimate(newIssue("o/r#6", "open", "F"), 1),
+		},
+		[]github.Dependency{
+			blockedBy("o/r#2", "o/r#1"),
+			blockedBy("o/r#4", "o/r#2"),
+			blockedBy("o/r#4", "o/r#3"),
+			blockedBy("o/r#6", "o/r#5"),
+			subIssue("o/r#4", "o/r#1"),
+		},
+	)
+}
+
+func TestFindCriticalPath_Unweighted(t *testing.T) {
+	got, err := FindCriticalPath(newCriticalPathGraph(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"o/r#1", "o/r#2", "o/r#4"}; !slices.Equal(got.IDs, want) || got.Total != 3 {
+		t.Errorf("got %+v, want %v with total 3", got, want)
+	}
+}
+
+func TestFindCriticalPath_Weighted(t *testing.T) {
+	g := newCriticalPathGraph()
+	got, err := FindCriticalPath(g, NumberFieldWeight(g, "F_EST"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"o/r#3", "o/r#4"}; !slices.Equal(got.IDs, want) || got.Total != 6 {
+		t.Errorf("got %+v, want %v with total 6", got, want)
+	}
+}
+
+func TestFindCriticalPath_Empty(t *testing.T) {
+	got, err := FindCriticalPath(New(nil, nil), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.IDs) != 0 || got.Total != 0 {
+		t.Errorf("expected empty path, got %+v", got)
+	}
+}
+
+func TestFindCriticalPath_Cycle(t *testing.T) {
+	g := New(nil, []github.Dependency{
+		blockedBy("o/r#1", "o/r#2"),
+		blockedBy("o/r#2", "o/r#1"),
+	})
+	if _, err := FindCriticalPath(g, nil); !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+}
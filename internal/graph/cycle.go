@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"slices"
+)
+
+// Cycle は依存関係の循環を表す。先頭のノードから辿って先頭に戻る経路で、
+// 末尾に先頭のノードは含まない。
+type Cycle []string
+
+// FindCycles は sub-issue と blocked-by のエッジを合わせたグラフの循環を検出する。
+// 強連結成分ごとに最短の循環を1つ返すため、同じ成分に含まれる循環が複数あっても1件として報告する。
+func FindCycles(g *Graph) []Cycle {
+	var cycles []Cycle
+	for _, scc := range g.stronglyConnectedComponents() {
+		if len(scc) == 1 && !slices.Contains(g.successors(scc[0]), scc[0]) {
+			continue
+		}
+		slices.SortFunc(scc, CompareIDs)
+		cycles = append(cycles, g.shortestCycle(scc[0], scc))
+	}
+	slices.SortFunc(cycles, func(a, b Cycle) int { return CompareIDs(a[0], b[0]) })
+	return cycles
+}
+
+// successors は sub-issue の子とブロックしているノードを返す。
+func (g *Graph) successors(id string) []string {
+	return append(slices.Clone(g.children[id]), g.blocking[id]...)
+}
+
+// stronglyConnectedComponents は Tarjan のアルゴリズムで強連結成分を求める。
+func (g *Graph) stronglyConnectedComponents() [][]string {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var sccs [][]string
+	next := 0
+
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = next
+		lowlink[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.successors(v) {
+			if _, seen := index[w]; !seen {
+				visit(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		if lowlink[v] == index[v] {
+			var scc []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for _, id := range g.ids {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+	return sccs
+}
+
+// shortestCycle は start から強連結成分内を幅優先探索し、start に戻る最短の経路を返す。
+func (g *Graph) shortestCycle(start string, scc []string) Cycle {
+	prev := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range g.successors(v) {
+			if !slices.Contains(scc, w) {
+				continue
+			}
+			if w == start {
+				cycle := Cycle{v}
+				for v != start {
+					v = prev[v]
+					cycle = append(cycle, v)
+				}
+				slices.Reverse(cycle)
+				return cycle
+			}
+			if _, seen := prev[w]; !seen {
+				prev[w] = v
+				queue = append(queue, w)
+			}
+		}
+	}
+	return Cycle{start}
+}
@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func TestFindCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		deps []github.Dependency
+		want []Cycle
+	}{
+		{
+			name: "acyclic",
+			deps: []github.Dependency{
+				subIssue("o/r#1", "o/r#2"),
+				blockedBy("o/r#3", "o/r#2"),
+			},
+			want: nil,
+		},
+		{
+			name: "blocked-by loop",
+			deps: []github.Dependency{
+				blockedBy("o/r#2", "o/r#1"),
+				blockedBy("o/r#3", "o/r#2"),
+				blockedBy("o/r#1", "o/r#3"),
+			},
+			want: []Cycle{{"o/r#1", "o/r#2", "o/r#3"}},
+		},
+		{
+			name: "parent blocked by its own sub-issue",
+			deps: []github.Dependency{
+				subIssue("o/r#1", "o/r#2"),
+				blockedBy("o/r#1", "o/r#2"),
+			},
+			want: []Cycle{{"o/r#1", "o/r#2"}},
+		},
+		{
+			name: "self loop",
+			deps: []github.Dependency{
+				blockedBy("o/r#4", "o/r#4"),
+			},
+			want: []Cycle{{"o/r#4"}},
+		},
+		{
+			name: "two independent cycles",
+			deps: []github.Dependency{
+				blockedBy("a/b#2", "a/b#1"),
+				blockedBy("a/b#1", "a/b#2"),
+				blockedBy("c/d#2", "c/d#1"),
+				blockedBy("c/d#1", "c/d#2"),
+			},
+			want: []Cycle{{"a/b#1", "a/b#2"}, {"c/d#1", "c/d#2"}},
+		},
+		{
+			name: "shortest cycle within a component",
+			deps: []github.Dependency{
+				blockedBy("o/r#2", "o/r#1"),
+				blockedBy("o/r#3", "o/r#2"),
+				blockedBy("o/r#4", "o/r#3"),
+				blockedBy("o/r#1", "o/r#4"),
+				blockedBy("o/r#1", "o/r#2"),
+			},
+			want: []Cycle{{"o/r#1", "o/r#2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindCycles(New(nil, tt.deps))
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("FindCycles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// Format はグラフの出力形式。
+type Format string
+
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+	FormatJSON    Format = "json"
+)
+
+// Formats は対応している出力形式の一覧。
+var Formats = []Format{FormatDOT, FormatMermaid, FormatJSON}
+
+// Export は指定された形式でグラフを書き出す。
+func Export(w io.Writer, g *Graph, format Format) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, g)
+	case FormatMermaid:
+		return WriteMermaid(w, g)
+	case FormatJSON:
+		return WriteJSON(w, g)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ExportNode は JSON 出力のノード。
+// プロジェクト外の Issue は id, owner, repo, number のみを持ち、inProject が false になる。
+type ExportNode struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	Repo      string `json:"repo"`
+	Number    int    `json:"number"`
+	Title     string `json:"title,omitempty"`
+	State     string `json:"state,omitempty"`
+	URL       string `json:"url,omitempty"`
+	InProject bool   `json:"inProject"`
+}
+
+// ExportGraph は JSON 出力のトップレベル。
+// edges の type は "sub_issue"（source が親、target が子）または
+// "blocked_by"（source が target をブロックしている）。
+type ExportGraph struct {
+	Nodes []ExportNode        `json:"nodes"`
+	Edges []github.Dependency `json:"edges"`
+}
+
+// NewExportGraph はグラフを JSON 出力用のノード・エッジに変換する。
+func NewExportGraph(g *Graph) ExportGraph {
+	out := ExportGraph{
+		Nodes: make([]ExportNode, 0, len(g.IDs())),
+		Edges: append([]github.Dependency{}, g.Dependencies()...),
+	}
+	for _, id := range g.IDs() {
+		node := ExportNode{ID: id}
+		if issue, ok := g.Issue(id); ok {
+			node.Owner, node.Repo, node.Number = issue.Owner, issue.Repo, issue.Number
+			node.Title, node.State, node.URL = issue.Title, issue.State, issue.URL
+			node.InProject = true
+		} else if owner, repo, number, err := github.ParseIssueID(id); err == nil {
+			node.Owner, node.Repo, node.Number = owner, repo, number
+		}
+		out.Nodes = append(out.Nodes, node)
+	}
+	return out
+}
+
+// WriteJSON はグラフを ExportGraph の JSON として書き出す。
+func WriteJSON(w io.Writer, g *Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewExportGraph(g))
+}
+
+// WriteDOT はグラフを Graphviz DOT として書き出す。
+// sub_issue は実線、blocked_by は破線のエッジで表し、closed な Issue は灰色で塗る。
+func WriteDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph issues {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, id := range g.IDs() {
+		label := id
+		attrs := ""
+		if issue, ok := g.Issue(id); ok {
+			label = id + "\n" + issue.Title
+			if !issue.IsOpen() {
+				attrs = `, style="rounded,filled", fillcolor=lightgray`
+			}
+		} else {
+			attrs = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", dotQuote(id), dotQuote(label), attrs)
+	}
+	for _, d := range g.Dependencies() {
+		attrs := ""
+		if d.Type == github.DependencyBlockedBy {
+			attrs = ` [style=dashed, label="blocks"]`
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(d.Source), dotQuote(d.Target), attrs)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// WriteMermaid はグラフを Mermaid の flowchart として書き出す。
+// ノード ID には複合 ID が使えないため、IDs() の順に n0, n1, ... を割り当てる。
+func WriteMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	keys := make(map[string]string, len(g.IDs()))
+	var closed []string
+	for i, id := range g.IDs() {
+		key := fmt.Sprintf("n%d", i)
+		keys[id] = key
+		label := id
+		if issue, ok := g.Issue(id); ok {
+			label = id + " " + issue.Title
+			if !issue.IsOpen() {
+				closed = append(closed, key)
+			}
+		}
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", key, mermaidEscape(label))
+	}
+	for _, d := range g.Dependencies() {
+		arrow := "-->"
+		if d.Type == github.DependencyBlockedBy {
+			arrow = "-. blocks .->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", keys[d.Source], arrow, keys[d.Target])
+	}
+	if len(closed) > 0 {
+		b.WriteString("  classDef closed fill:#eee,color:#888\n")
+		fmt.Fprintf(&b, "  class %s closed\n", strings.Join(closed, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidEscape はラベル中の Mermaid の特殊文字をエンティティコードに置き換える。
+func mermaidEscape(s string) string {
+	r := strings.NewReplacer(`#`, "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ")
+	return r.Replace(s)
+}
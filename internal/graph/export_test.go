@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func newExportGraph() *Graph {
+	return New(
+		[]github.Issue{
+			newIssue("o/r#1", "open", `Epic "A"`),
+			newIssue("o/r#2", "closed", "Done"),
+		},
+		[]github.Dependency{
+			subIssue("o/r#1", "o/r#2"),
+			blockedBy("o/r#2", "x/y#3"),
+		},
+	)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := Export(&b, newExportGraph(), FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got ExportGraph
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(got.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(got.Nodes))
+	}
+	if n := got.Nodes[0]; n.ID != "o/r#1" || !n.InProject || n.Title != `Epic "A"` || n.State != "open" {
+		t.Errorf("unexpected first node: %+v", n)
+	}
+	if n := got.Nodes[2]; n.ID != "x/y#3" || n.InProject || n.Owner != "x" || n.Repo != "y" || n.Number != 3 {
+		t.Errorf("unexpected external node: %+v", n)
+	}
+	if len(got.Edges) != 2 || got.Edges[0].Type != github.DependencySubIssue || got.Edges[1].Type != github.DependencyBlockedBy {
+		t.Errorf("unexpected edges: %+v", got.Edges)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b strings.Builder
+	if err := Export(&b, newExportGraph(), FormatDOT); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := b.String()
+	for _, want := range []string{
+		"digraph issues {",
+		`"o/r#1" [label="o/r#1\nEpic \"A\""];`,
+		`"o/r#2" [label="o/r#2\nDone", style="rounded,filled", fillcolor=lightgray];`,
+		`"x/y#3" [label="x/y#3", style=dashed];`,
+		`"o/r#1" -> "o/r#2";`,
+		`"x/y#3" -> "o/r#2" [style=dashed, label="blocks"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var b strings.Builder
+	if err := Export(&b, newExportGraph(), FormatMermaid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"flowchart LR",
+		`  n0["o/r#35;1 Epic #quot;A#quot;"]`,
+		`  n1["o/r#35;2 Done"]`,
+		`  n2["x/y#35;3"]`,
+		"  n0 --> n1",
+		"  n2 -. blocks .-> n1",
+		"  classDef closed fill:#eee,color:#888",
+		"  class n1 closed",
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("unexpected mermaid:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestExport_UnsupportedFormat(t *testing.T) {
+	var b strings.Builder
+	if err := Export(&b, newExportGraph(), Format("svg")); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,27 @@
+package graph
+
+import "slices"
+
+// Ready は着手可能な Issue を返す。
+// プロジェクト内の open な Issue のうち、open なブロッカーも open な sub-issue も持たないものが対象。
+// プロジェクト外の Issue は状態が分からないため open として扱う。
+func Ready(g *Graph) []string {
+	var ready []string
+	for _, id := range g.ids {
+		issue, ok := g.issues[id]
+		if !ok || !issue.IsOpen() {
+			continue
+		}
+		if slices.ContainsFunc(g.blockedBy[id], g.isOpen) || slices.ContainsFunc(g.children[id], g.isOpen) {
+			continue
+		}
+		ready = append(ready, id)
+	}
+	return ready
+}
+
+// isOpen はノードが open か判定する。プロジェクト外のノードは open とみなす。
+func (g *Graph) isOpen(id string) bool {
+	issue, ok := g.issues[id]
+	return !ok || issue.IsOpen()
+}
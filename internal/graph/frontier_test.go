@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func TestReady(t *testing.T) {
+	g := New(
+		[]github.Issue{
+			newIssue("o/r#1", "open", "Epic with open child"),
+			newIssue("o/r#2", "open", "Open child"),
+			newIssue("o/r#3", "open", "Blocked by open issue"),
+			newIssue("o/r#4", "open", "Blocked by closed issue"),
+			newIssue("o/r#5", "closed", "Closed blocker"),
+			newIssue("o/r#6", "open", "Epic with closed children only"),
+			newIssue("o/r#7", "closed", "Closed child"),
+			newIssue("o/r#8", "open", "Blocked by external issue"),
+		},
+		[]github.Dependency{
+			subIssue("o/r#1", "o/r#2"),
+			blockedBy("o/r#3", "o/r#2"),
+			blockedBy("o/r#4", "o/r#5"),
+			subIssue("o/r#6", "o/r#7"),
+			blockedBy("o/r#8", "x/y#1"),
+		},
+	)
+
+	want := []string{"o/r#2", "o/r#4", "o/r#6"}
+	if got := Ready(g); !slices.Equal(got, want) {
+		t.Errorf("Ready = %v, want %v", got, want)
+	}
+}
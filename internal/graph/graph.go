@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// Graph はプロジェクトの Issue と依存関係から構築した有向グラフ。
+// ノード ID は owner/repo#number 形式の複合 ID。
+// 依存関係が参照する、プロジェクト外の Issue もノードとして扱う（Issue 情報は持たない）。
+type Graph struct {
+	issues    map[string]github.Issue
+	ids       []string
+	known     map[string]bool
+	deps      []github.Dependency
+	children  map[string][]string
+	parents   map[string][]string
+	blockedBy map[string][]string
+	blocking  map[string][]string
+}
+
+// New は Issue と依存関係からグラフを構築する。
+func New(issues []github.Issue, deps []github.Dependency) *Graph {
+	g := &Graph{
+		issues:    make(map[string]github.Issue, len(issues)),
+		children:  make(map[string][]string),
+		parents:   make(map[string][]string),
+		blockedBy: make(map[string][]string),
+		blocking:  make(map[string][]string),
+		known:     make(map[string]bool),
+	}
+	addID := func(id string) {
+		if !g.known[id] {
+			g.known[id] = true
+			g.ids = append(g.ids, id)
+		}
+	}
+
+	for _, issue := range issues {
+		g.issues[issue.ID] = issue
+		addID(issue.ID)
+	}
+	for _, d := range deps {
+		g.deps = append(g.deps, d)
+		addID(d.Source)
+		addID(d.Target)
+		switch d.Type {
+		case github.DependencySubIssue:
+			g.children[d.Source] = append(g.children[d.Source], d.Target)
+			g.parents[d.Target] = append(g.parents[d.Target], d.Source)
+		case github.DependencyBlockedBy:
+			g.blocking[d.Source] = append(g.blocking[d.Source], d.Target)
+			g.blockedBy[d.Target] = append(g.blockedBy[d.Target], d.Source)
+		}
+	}
+
+	slices.SortFunc(g.ids, CompareIDs)
+	for _, m := range []map[string][]string{g.children, g.parents, g.blockedBy, g.blocking} {
+		for _, ids := range m {
+			slices.SortFunc(ids, CompareIDs)
+		}
+	}
+	return g
+}
+
+// IDs は全ノード ID を owner/repo, number の順で返す。
+func (g *Graph) IDs() []string {
+	return g.ids
+}
+
+// Issue は指定ノードの Issue を返す。プロジェクト外のノードでは ok が false になる。
+func (g *Graph) Issue(id string) (github.Issue, bool) {
+	issue, ok := g.issues[id]
+	return issue, ok
+}
+
+// Has はノードが存在するか判定する。
+func (g *Graph) Has(id string) bool {
+	return g.known[id]
+}
+
+// Dependencies は構築に使った依存関係を返す。
+func (g *Graph) Dependencies() []github.Dependency {
+	return g.deps
+}
+
+// Children は sub-issue の子を返す。
+func (g *Graph) Children(id string) []string {
+	return g.children[id]
+}
+
+// Parents は sub-issue の親を返す。
+func (g *Graph) Parents(id string) []string {
+	return g.parents[id]
+}
+
+// BlockedBy は指定ノードをブロックしているノードを返す。
+func (g *Graph) BlockedBy(id string) []string {
+	return g.blockedBy[id]
+}
+
+// Blocking は指定ノードがブロックしているノードを返す。
+func (g *Graph) Blocking(id string) []string {
+	return g.blocking[id]
+}
+
+// Roots はプロジェクト内に sub-issue の親を持たないプロジェクト内の Issue を返す。
+func (g *Graph) Roots() []string {
+	var roots []string
+	for _, id := range g.ids {
+		if _, ok := g.issues[id]; !ok {
+			continue
+		}
+		hasParent := slices.ContainsFunc(g.parents[id], func(p string) bool {
+			_, ok := g.issues[p]
+			return ok
+		})
+		if !hasParent {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// CompareIDs は複合 ID を owner/repo の辞書順、number の数値順で比較する。
+// 複合 ID として解釈できない値は文字列として比較する。
+func CompareIDs(a, b string) int {
+	ao, ar, an, aerr := github.ParseIssueID(a)
+	bo, br, bn, berr := github.ParseIssueID(b)
+	if aerr != nil || berr != nil {
+		return cmp.Compare(a, b)
+	}
+	return cmp.Or(cmp.Compare(ao, bo), cmp.Compare(ar, br), cmp.Compare(an, bn))
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func newIssue(id, state, title string) github.Issue {
+	owner, repo, number, err := github.ParseIssueID(id)
+	if err != nil {
+		panic(err)
+	}
+	return github.Issue{ID: id, Owner: owner, Repo: repo, Number: number, State: state, Title: title}
+}
+
+func subIssue(parent, child string) github.Dependency {
+	return github.Dependency{Source: parent, Target: child, Type: github.DependencySubIssue}
+}
+
+func blockedBy(blocked, blocker string) github.Dependency {
+	return github.Dependency{Source: blocker, Target: blocked, Type: github.DependencyBlockedBy}
+}
+
+func TestNew(t *testing.T) {
+	g := New(
+		[]github.Issue{
+			newIssue("o/r#10", "open", "Ten"),
+			newIssue("o/r#2", "open", "Two"),
+			newIssue("o/r#1", "open", "One"),
+		},
+		[]github.Dependency{
+			subIssue("o/r#1", "o/r#10"),
+			subIssue("o/r#1", "o/r#2"),
+			blockedBy("o/r#10", "x/y#5"),
+		},
+	)
+
+	if got, want := g.IDs(), []string{"o/r#1", "o/r#2", "o/r#10", "x/y#5"}; !slices.Equal(got, want) {
+		t.Errorf("IDs = %v, want %v", got, want)
+	}
+	if got, want := g.Children("o/r#1"), []string{"o/r#2", "o/r#10"}; !slices.Equal(got, want) {
+		t.Errorf("Children = %v, want %v", got, want)
+	}
+	if got, want := g.Parents("o/r#2"), []string{"o/r#1"}; !slices.Equal(got, want) {
+		t.Errorf("Parents = %v, want %v", got, want)
+	}
+	if got, want := g.BlockedBy("o/r#10"), []string{"x/y#5"}; !slices.Equal(got, want) {
+		t.Errorf("BlockedBy = %v, want %v", got, want)
+	}
+	if got, want := g.Blocking("x/y#5"), []string{"o/r#10"}; !slices.Equal(got, want) {
+		t.Errorf("Blocking = %v, want %v", got, want)
+	}
+	if !g.Has("x/y#5") {
+		t.Error("expected external node x/y#5 to exist")
+	}
+	if _, ok := g.Issue("x/y#5"); ok {
+		t.Error("expected external node x/y#5 to have no issue")
+	}
+	if got, want := g.Roots(), []string{"o/r#1"}; !slices.Equal(got, want) {
+		t.Errorf("Roots = %v, want %v", got, want)
+	}
+}
+
+func TestRoots_ParentOutsideProject(t *testing.T) {
+	g := New(
+		[]github.Issue{newIssue("o/r#2", "open", "Two")},
+		[]github.Dependency{subIssue("o/r#1", "o/r#2")},
+	)
+	if got, want := g.Roots(), []string{"o/r#2"}; !slices.Equal(got, want) {
+		t.Errorf("Roots = %v, want %v", got, want)
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	ids := []string{"b/a#1", "a/b#10", "a/b#9", "a/a#100", "not-an-id"}
+	slices.SortFunc(ids, CompareIDs)
+	want := []string{"a/a#100", "a/b#9", "a/b#10", "b/a#1", "not-an-id"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("sorted = %v, want %v", ids, want)
+	}
+}
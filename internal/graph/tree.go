@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// TreeOptions は RenderTree の表示オプション。
+type TreeOptions struct {
+	// Roots は起点とするノード。空の場合はプロジェクトのルートを起点とする。
+	Roots []string
+	// MaxDepth は起点から辿る最大の深さ。0 以下は無制限。
+	MaxDepth int
+	// ASCII が true の場合、罫線と状態マーカーに ASCII 文字だけを使う。
+	ASCII bool
+}
+
+type treeStyle struct {
+	branch, last, pipe, space string
+	open, closed, unknown     string
+}
+
+var (
+	unicodeTreeStyle = treeStyle{
+		branch: "├── ", last: "└── ", pipe: "│   ", space: "    ",
+		open: "○", closed: "✔", unknown: "?",
+	}
+	asciiTreeStyle = treeStyle{
+		branch: "|-- ", last: "`-- ", pipe: "|   ", space: "    ",
+		open: "[ ]", closed: "[x]", unknown: "[?]",
+	}
+)
+
+// RenderTree は sub-issue の階層をインデント付きのツリーとして書き出す。
+// 各行には状態マーカー、複合 ID、タイトル、ブロックしている Issue を表示する。
+func RenderTree(w io.Writer, g *Graph, opts TreeOptions) error {
+	style := unicodeTreeStyle
+	if opts.ASCII {
+		style = asciiTreeStyle
+	}
+	roots := opts.Roots
+	if len(roots) == 0 {
+		roots = g.Roots()
+	}
+
+	r := &treeRenderer{w: w, g: g, opts: opts, style: style}
+	for _, id := range roots {
+		if err := r.writeLine("", id, nil); err != nil {
+			return err
+		}
+		if err := r.writeChildren("", id, []string{id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type treeRenderer struct {
+	w     io.Writer
+	g     *Graph
+	opts  TreeOptions
+	style treeStyle
+}
+
+func (r *treeRenderer) writeChildren(prefix, id string, path []string) error {
+	children := r.g.Children(id)
+	if len(children) == 0 {
+		return nil
+	}
+	if r.opts.MaxDepth > 0 && len(path) > r.opts.MaxDepth {
+		return nil
+	}
+
+	for i, child := range children {
+		branch, next := r.style.branch, r.style.pipe
+		if i == len(children)-1 {
+			branch, next = r.style.last, r.style.space
+		}
+		if slices.Contains(path, child) {
+			if err := r.writeLine(prefix+branch, child, []string{"(cycle)"}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var notes []string
+		if r.opts.MaxDepth > 0 && len(path) == r.opts.MaxDepth {
+			if n := len(r.g.Children(child)); n > 0 {
+				notes = append(notes, fmt.Sprintf("(+%d sub-issues)", n))
+			}
+		}
+		if err := r.writeLine(prefix+branch, child, notes); err != nil {
+			return err
+		}
+		if err := r.writeChildren(prefix+next, child, append(path, child)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *treeRenderer) writeLine(prefix, id string, notes []string) error {
+	var b strings.Builder
+	b.WriteString(prefix)
+
+	issue, ok := r.g.Issue(id)
+	switch {
+	case !ok:
+		b.WriteString(r.style.unknown)
+	case issue.IsOpen():
+		b.WriteString(r.style.open)
+	default:
+		b.WriteString(r.style.closed)
+	}
+	b.WriteString(" ")
+	b.WriteString(id)
+	if ok && issue.Title != "" {
+		b.WriteString(" ")
+		b.WriteString(issue.Title)
+	}
+	if blockers := r.g.BlockedBy(id); len(blockers) > 0 {
+		b.WriteString(" [blocked by ")
+		b.WriteString(strings.Join(blockers, ", "))
+		b.WriteString("]")
+	}
+	for _, note := range notes {
+		b.WriteString(" ")
+		b.WriteString(note)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(r.w, b.String())
+	return err
+}
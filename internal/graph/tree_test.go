@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func newTreeGraph() *Graph {
+	return New(
+		[]github.Issue{
+			newIssue("o/r#1", "open", "Epic"),
+			newIssue("o/r#2", "closed", "Design"),
+			newIssue("o/r#3", "open", "Build"),
+			newIssue("o/r#4", "open", "Polish"),
+			newIssue("o/r#9", "open", "Standalone"),
+		},
+		[]github.Dependency{
+			subIssue("o/r#1", "o/r#2"),
+			subIssue("o/r#1", "o/r#3"),
+			subIssue("o/r#3", "o/r#4"),
+			subIssue("o/r#3", "x/y#7"),
+			blockedBy("o/r#3", "o/r#2"),
+		},
+	)
+}
+
+func TestRenderTree(t *testing.T) {
+	var b strings.Builder
+	if err := RenderTree(&b, newTreeGraph(), TreeOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"○ o/r#1 Epic",
+		"├── ✔ o/r#2 Design",
+		"└── ○ o/r#3 Build [blocked by o/r#2]",
+		"    ├── ○ o/r#4 Polish",
+		"    └── ? x/y#7",
+		"○ o/r#9 Standalone",
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRenderTree_RootDepthASCII(t *testing.T) {
+	var b strings.Builder
+	opts := TreeOptions{Roots: []string{"o/r#1"}, MaxDepth: 1, ASCII: true}
+	if err := RenderTree(&b, newTreeGraph(), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"[ ] o/r#1 Epic",
+		"|-- [x] o/r#2 Design",
+		"`-- [ ] o/r#3 Build [blocked by o/r#2] (+2 sub-issues)",
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRenderTree_Cycle(t *testing.T) {
+	g := New(
+		[]github.Issue{
+			newIssue("o/r#1", "open", "A"),
+			newIssue("o/r#2", "open", "B"),
+		},
+		[]github.Dependency{
+			subIssue("o/r#1", "o/r#2"),
+			subIssue("o/r#2", "o/r#1"),
+		},
+	)
+
+	var b strings.Builder
+	if err := RenderTree(&b, g, TreeOptions{Roots: []string{"o/r#1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"○ o/r#1 A",
+		"└── ○ o/r#2 B",
+		"    └── ○ o/r#1 A (cycle)",
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,191 @@
+// Package history はコンソールから行った変更の取り消し（undo）とやり直し（redo）の履歴を管理する。
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/util"
+)
+
+// ErrEmpty は取り消し・やり直しできる変更がないことを表す。
+var ErrEmpty = errors.New("history is empty")
+
+// Request は GitHub API へのリクエスト。
+type Request struct {
+	// API は "graphql" または "rest"。
+	API string `json:"api"`
+	// Method と Path は REST の場合のみ設定する。Path は API ルートからの相対パス。
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Body は REST のリクエストボディ、または GraphQL の {"query", "variables"}。
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Step は取り消し・やり直しできる変更1件。Do は元の変更、Undo はその逆の変更。
+type Step struct {
+	ID    int       `json:"id"`
+	Time  time.Time `json:"time"`
+	Label string    `json:"label"`
+	Do    Request   `json:"do"`
+	Undo  Request   `json:"undo"`
+}
+
+type state struct {
+	NextID int    `json:"nextId"`
+	Undo   []Step `json:"undo"`
+	Redo   []Step `json:"redo"`
+}
+
+// Stack は undo / redo のスタック。変更のたびに JSON ファイルに保存する。
+type Stack struct {
+	mu    sync.Mutex
+	path  string
+	limit int
+	state state
+}
+
+// Open は指定ファイルから Stack を読み込む。ファイルがなければ空の Stack を返す。
+// ファイルが読めない JSON の場合は <path>.corrupt に退避し、空の Stack から始める。
+// limit は undo スタックに残す変更の最大数で、古いものから捨てる。
+func Open(path string, limit int) (*Stack, error) {
+	empty := state{NextID: 1, Undo: []Step{}, Redo: []Step{}}
+	s := &Stack{path: path, limit: limit, state: empty}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		// 履歴が失われてもコンソールは使えるようにする。壊れたファイルは調べられるよう残しておく
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, fmt.Errorf("failed to set aside corrupt history: %w", err)
+		}
+		s.state = empty
+	}
+	return s, nil
+}
+
+// Stacks はプロジェクトごとの Stack を、ディレクトリの <プロジェクト ID>.json で管理する。
+// プロジェクトを指定しない変更は history.json の Stack に積む。
+type Stacks struct {
+	dir   string
+	limit int
+
+	mu     sync.Mutex
+	stacks map[string]*Stack
+}
+
+// defaultStackName はプロジェクトを指定しない変更の Stack のファイル名（拡張子を除く）。
+const defaultStackName = "history"
+
+// OpenDir は dir をバックエンドとする Stacks を返す。各 Stack は最初に使うときに読み込む。
+func OpenDir(dir string, limit int) *Stacks {
+	return &Stacks{dir: dir, limit: limit, stacks: make(map[string]*Stack)}
+}
+
+// Get はプロジェクトの Stack を返す。projectID が空ならプロジェクトを指定しない変更の Stack を返す。
+// projectID はファイル名になるため、呼び出し側で検証しておくこと。
+func (s *Stacks) Get(projectID string) (*Stack, error) {
+	name := projectID
+	if name == "" {
+		name = defaultStackName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stack, ok := s.stacks[name]; ok {
+		return stack, nil
+	}
+	stack, err := Open(filepath.Join(s.dir, name+".json"), s.limit)
+	if err != nil {
+		return nil, err
+	}
+	s.stacks[name] = stack
+	return stack, nil
+}
+
+// Push は新しい変更を undo スタックに積む。redo スタックは破棄する。
+func (s *Stack) Push(step Step) (Step, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	step.ID = s.state.NextID
+	if step.Time.IsZero() {
+		step.Time = time.Now()
+	}
+	s.state.NextID++
+	s.state.Undo = append(s.state.Undo, step)
+	if s.limit > 0 && len(s.state.Undo) > s.limit {
+		s.state.Undo = s.state.Undo[len(s.state.Undo)-s.limit:]
+	}
+	s.state.Redo = []Step{}
+	return step, s.save()
+}
+
+// Undo は最後の変更の Undo を send で送信し、成功したら redo スタックに移す。
+// 送信に失敗した場合はスタックを変更せず、対象の変更とエラーを返す。
+func (s *Stack) Undo(send func(Request) error) (Step, error) {
+	return s.move(&s.state.Undo, &s.state.Redo, func(step Step) error { return send(step.Undo) })
+}
+
+// Redo は最後に取り消した変更の Do を send で送信し、成功したら undo スタックに戻す。
+// 送信に失敗した場合はスタックを変更せず、対象の変更とエラーを返す。
+func (s *Stack) Redo(send func(Request) error) (Step, error) {
+	return s.move(&s.state.Redo, &s.state.Undo, func(step Step) error { return send(step.Do) })
+}
+
+// move は from の末尾の変更を apply し、成功したら to に移す。
+// 送信中に別の取り消し・やり直しが割り込まないよう、ロックを保持したまま送信する。
+func (s *Stack) move(from, to *[]Step, apply func(Step) error) (Step, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(*from) == 0 {
+		return Step{}, ErrEmpty
+	}
+	step := (*from)[len(*from)-1]
+	if err := apply(step); err != nil {
+		return step, err
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, step)
+	return step, s.save()
+}
+
+// Steps は undo スタックと redo スタックの変更を、それぞれ新しい順に返す。
+func (s *Stack) Steps() (undo, redo []Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return reversed(s.state.Undo), reversed(s.state.Redo)
+}
+
+func reversed(steps []Step) []Step {
+	out := make([]Step, len(steps))
+	for i, step := range steps {
+		out[len(steps)-1-i] = step
+	}
+	return out
+}
+
+func (s *Stack) save() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history dir: %w", err)
+	}
+	// 書き込み途中で落ちても、次の起動で読めないファイルが残らないようにする
+	if err := util.WriteFileAtomic(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
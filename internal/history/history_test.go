@@ -0,0 +1,192 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func step(label string) Step {
+	return Step{
+		Label: label,
+		Do:    Request{API: "graphql", Body: json.RawMessage(`{"query":"mutation { ` + label + ` }"}`)},
+		Undo:  Request{API: "rest", Method: "DELETE", Path: "repos/o/r/issues/1/sub_issue", Body: json.RawMessage(`{"sub_issue_id":1}`)},
+	}
+}
+
+func labels(steps []Step) []string {
+	out := make([]string, len(steps))
+	for i, s := range steps {
+		out[i] = s.Label
+	}
+	return out
+}
+
+func TestStack_UndoRedo(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "history.json"), 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.Push(step("a"))
+	s.Push(step("b"))
+
+	var sent []Request
+	send := func(r Request) error {
+		sent = append(sent, r)
+		return nil
+	}
+
+	got, err := s.Undo(send)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got.Label != "b" || got.ID != 2 {
+		t.Errorf("undid %+v, want b (id 2)", got)
+	}
+	if len(sent) != 1 || sent[0].Method != "DELETE" {
+		t.Errorf("sent %+v, want the Undo request", sent)
+	}
+
+	got, err = s.Redo(send)
+	if err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if got.Label != "b" || sent[1].API != "graphql" {
+		t.Errorf("redid %+v with %+v, want b with the Do request", got, sent[1])
+	}
+
+	undo, redo := s.Steps()
+	if l := labels(undo); len(l) != 2 || l[0] != "b" || l[1] != "a" {
+		t.Errorf("undo = %v, want [b a]", l)
+	}
+	if len(redo) != 0 {
+		t.Errorf("redo = %v, want empty", labels(redo))
+	}
+}
+
+func TestStack_PushClearsRedo(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "history.json"), 0)
+	s.Push(step("a"))
+	s.Undo(func(Request) error { return nil })
+	s.Push(step("b"))
+
+	if _, err := s.Redo(func(Request) error { return nil }); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Redo err = %v, want ErrEmpty", err)
+	}
+}
+
+func TestStack_FailedSendKeepsStep(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "history.json"), 0)
+	s.Push(step("a"))
+
+	boom := errors.New("boom")
+	got, err := s.Undo(func(Request) error { return boom })
+	if !errors.Is(err, boom) {
+		t.Fatalf("Undo err = %v, want boom", err)
+	}
+	if got.Label != "a" {
+		t.Errorf("Undo returned %+v, want the failed step", got)
+	}
+	if undo, redo := s.Steps(); len(undo) != 1 || len(redo) != 0 {
+		t.Errorf("undo = %v, redo = %v, want the step to stay on the undo stack", labels(undo), labels(redo))
+	}
+}
+
+func TestStack_EmptyAndLimit(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "history.json"), 2)
+	if _, err := s.Undo(func(Request) error { t.Fatal("send called"); return nil }); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Undo err = %v, want ErrEmpty", err)
+	}
+
+	for _, l := range []string{"a", "b", "c"} {
+		s.Push(step(l))
+	}
+	undo, _ := s.Steps()
+	if l := labels(undo); len(l) != 2 || l[0] != "c" || l[1] != "b" {
+		t.Errorf("undo = %v, want [c b]", l)
+	}
+}
+
+func TestStack_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	s, _ := Open(path, 0)
+	s.Push(step("a"))
+	s.Push(step("b"))
+	s.Undo(func(Request) error { return nil })
+
+	reopened, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	undo, redo := reopened.Steps()
+	if len(undo) != 1 || undo[0].Label != "a" || len(redo) != 1 || redo[0].Label != "b" {
+		t.Fatalf("undo = %v, redo = %v", labels(undo), labels(redo))
+	}
+	if string(redo[0].Undo.Body) != `{"sub_issue_id":1}` {
+		t.Errorf("request body not persisted: %s", redo[0].Undo.Body)
+	}
+	if got, _ := reopened.Push(step("c")); got.ID != 3 {
+		t.Errorf("ID after reopen = %d, want 3", got.ID)
+	}
+}
+
+func TestOpen_SetsAsideCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	corrupt := []byte(`{"nextId":3,"undo":[{"id":1`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if undo, redo := s.Steps(); len(undo) != 0 || len(redo) != 0 {
+		t.Fatalf("expected empty history, got undo = %v, redo = %v", labels(undo), labels(redo))
+	}
+	if got, err := os.ReadFile(path + ".corrupt"); err != nil || string(got) != string(corrupt) {
+		t.Fatalf("expected corrupt file to be kept, got %q (%v)", got, err)
+	}
+	if got, _ := s.Push(step("a")); got.ID != 1 {
+		t.Errorf("ID = %d, want 1", got.ID)
+	}
+	if _, err := Open(path, 0); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+}
+
+func TestStacks_KeyedByProject(t *testing.T) {
+	dir := t.TempDir()
+	stacks := OpenDir(dir, 0)
+	a, _ := stacks.Get("PVT_a")
+	b, _ := stacks.Get("PVT_b")
+	a.Push(step("a"))
+	b.Push(step("b"))
+
+	// 別のプロジェクトの変更は取り消さない
+	undone, err := a.Undo(func(Request) error { return nil })
+	if err != nil || undone.Label != "a" {
+		t.Fatalf("undo = %v (%v), want a", undone.Label, err)
+	}
+	if undo, _ := b.Steps(); len(undo) != 1 || undo[0].Label != "b" {
+		t.Fatalf("undo of PVT_b = %v, want [b]", labels(undo))
+	}
+	if again, _ := stacks.Get("PVT_a"); again != a {
+		t.Error("expected the same stack for the same project")
+	}
+
+	reopened, err := OpenDir(dir, 0).Get("PVT_b")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if undo, _ := reopened.Steps(); len(undo) != 1 || undo[0].Label != "b" {
+		t.Fatalf("undo after reopen = %v, want [b]", labels(undo))
+	}
+	for _, name := range []string{"PVT_a.json", "PVT_b.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+}
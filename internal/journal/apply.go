@@ -0,0 +1,159 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Executor は記録したリクエストを実際に GitHub API に送信する。
+type Executor interface {
+	// REST は REST API を呼び出し、レスポンスボディを返す。
+	REST(method, path string, body []byte) ([]byte, error)
+	// GraphQL は GraphQL API を呼び出し、レスポンスの data の中身を返す。
+	GraphQL(query string, variables map[string]any) ([]byte, error)
+}
+
+// Apply は entries を記録順に実行する。
+// 実行したレスポンスから仮の値に対応する実際の値を取り出し、後続の Entry のパスとボディを置き換えてから送信する。
+// 途中で失敗した場合は、未実行の Entry を解決済みの値で置き換えたうえで返す。
+func Apply(entries []Entry, ex Executor, w io.Writer) ([]Entry, error) {
+	resolved := make(map[string]any)
+	for i, e := range entries {
+		e, err := substitute(e, resolved)
+		if err != nil {
+			return remaining(entries[i:], resolved), fmt.Errorf("failed to prepare #%d %s: %w", e.Seq, e.Operation, err)
+		}
+
+		var resp []byte
+		switch e.API {
+		case APIREST:
+			resp, err = ex.REST(e.Method, e.Path, e.Body)
+		case APIGraphQL:
+			var req struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+			if err = json.Unmarshal(e.Body, &req); err == nil {
+				resp, err = ex.GraphQL(req.Query, req.Variables)
+			}
+		default:
+			err = fmt.Errorf("unknown api %q", e.API)
+		}
+		if err != nil {
+			return remaining(entries[i:], resolved), fmt.Errorf("failed to apply #%d %s: %w", e.Seq, e.Operation, err)
+		}
+		fmt.Fprintf(w, "applied  #%d %s\n", e.Seq, e.Operation)
+
+		resolvePlaceholders(e.Placeholders, resp, resolved)
+	}
+	return nil, nil
+}
+
+func remaining(entries []Entry, resolved map[string]any) []Entry {
+	rest := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		// 置き換えに失敗した Entry はそのまま残す
+		if s, err := substitute(e, resolved); err == nil {
+			e = s
+		}
+		rest = append(rest, e)
+	}
+	return rest
+}
+
+// resolvePlaceholders はレスポンスから仮の値に対応する実際の値を取り出して resolved に追加する。
+// レスポンスに値がない仮の値は解決しない。
+func resolvePlaceholders(placeholders map[string]string, resp []byte, resolved map[string]any) {
+	if len(placeholders) == 0 || len(resp) == 0 {
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	dec.UseNumber()
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return
+	}
+	for placeholder, path := range placeholders {
+		v := body
+		for key := range strings.SplitSeq(path, ".") {
+			m, ok := v.(map[string]any)
+			if !ok {
+				v = nil
+				break
+			}
+			v = m[key]
+		}
+		switch v.(type) {
+		case string, json.Number:
+			resolved[placeholder] = v
+		}
+	}
+}
+
+// substitute は Entry のパスとボディに含まれる仮の値を resolved の値で置き換える。
+// 文字列の仮の値は文字列中の部分一致も置き換え、数値の仮の値は値全体が一致する場合だけ置き換える。
+func substitute(e Entry, resolved map[string]any) (Entry, error) {
+	if len(resolved) == 0 {
+		return e, nil
+	}
+
+	if e.Path != "" {
+		segments := strings.Split(e.Path, "/")
+		for i, seg := range segments {
+			if v, ok := resolved[seg]; ok {
+				segments[i] = fmt.Sprint(v)
+			}
+		}
+		e.Path = strings.Join(segments, "/")
+	}
+
+	if len(e.Body) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(e.Body))
+		dec.UseNumber()
+		var body any
+		if err := dec.Decode(&body); err != nil {
+			return e, fmt.Errorf("invalid body: %w", err)
+		}
+		data, err := json.Marshal(substituteValue(body, resolved))
+		if err != nil {
+			return e, err
+		}
+		e.Body = data
+	}
+	return e, nil
+}
+
+func substituteValue(v any, resolved map[string]any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = substituteValue(child, resolved)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = substituteValue(child, resolved)
+		}
+		return v
+	case json.Number:
+		if r, ok := resolved[v.String()]; ok {
+			return r
+		}
+		return v
+	case string:
+		if r, ok := resolved[v]; ok {
+			return r
+		}
+		for placeholder, r := range resolved {
+			if s, ok := r.(string); ok && IsPlaceholder(placeholder) {
+				v = strings.ReplaceAll(v, placeholder, s)
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
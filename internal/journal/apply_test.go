@@ -0,0 +1,141 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+type call struct {
+	method, path string
+	body         string
+}
+
+type fakeExecutor struct {
+	calls     []call
+	responses []string
+	failAt    int // 1 始まり。0 なら失敗しない
+}
+
+func (f *fakeExecutor) respond(c call) ([]byte, error) {
+	f.calls = append(f.calls, c)
+	if len(f.calls) == f.failAt {
+		return nil, errors.New("boom")
+	}
+	return []byte(f.responses[len(f.calls)-1]), nil
+}
+
+func (f *fakeExecutor) REST(method, path string, body []byte) ([]byte, error) {
+	return f.respond(call{method: method, path: path, body: string(body)})
+}
+
+func (f *fakeExecutor) GraphQL(query string, variables map[string]any) ([]byte, error) {
+	vars, _ := json.Marshal(variables)
+	return f.respond(call{method: "GRAPHQL", path: query, body: string(vars)})
+}
+
+// recordCreateAndLink は Issue を作成し、その Issue を親の sub-issue にして Project に追加する記録を作る。
+func recordCreateAndLink(t *testing.T, j *Journal) []Entry {
+	t.Helper()
+	number, id, nodeID := j.NewIssueNumber(), j.NewDatabaseID(), j.NewNodeID()
+	itemID := j.NewNodeID()
+	n, dbID := strconv.FormatInt(number, 10), strconv.FormatInt(id, 10)
+
+	record := []Entry{
+		{
+			API: APIREST, Method: "POST", Path: "repos/o/r/issues", Operation: "POST repos/o/r/issues",
+			Body:         json.RawMessage(`{"title":"new"}`),
+			Placeholders: map[string]string{n: "number", dbID: "id", nodeID: "node_id"},
+		},
+		{
+			API: APIREST, Method: "POST", Path: "repos/o/r/issues/1/sub_issues", Operation: "POST sub_issues",
+			Body: json.RawMessage(`{"sub_issue_id":` + dbID + `}`),
+		},
+		{
+			API: APIGraphQL, Operation: "addProjectV2ItemById",
+			Body:         json.RawMessage(`{"query":"mutation($c: ID!) { addProjectV2ItemById(input: {projectId: \"PVT_1\", contentId: $c}) { item { id } } }","variables":{"c":"` + nodeID + `"}}`),
+			Placeholders: map[string]string{itemID: "addProjectV2ItemById.item.id"},
+		},
+		{
+			API: APIREST, Method: "PATCH", Path: "repos/o/r/issues/" + n, Operation: "PATCH issue",
+			Body: json.RawMessage(`{"body":"see ` + itemID + `"}`),
+		},
+	}
+	for _, e := range record {
+		if _, err := j.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	entries, err := j.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return entries
+}
+
+func TestApply_ResolvesPlaceholders(t *testing.T) {
+	j, _ := Open(t.TempDir() + "/journal.jsonl")
+	entries := recordCreateAndLink(t, j)
+
+	ex := &fakeExecutor{responses: []string{
+		`{"id": 4242, "node_id": "I_real", "number": 17}`,
+		`{}`,
+		`{"addProjectV2ItemById": {"item": {"id": "PVTI_real"}}}`,
+		`{}`,
+	}}
+	remaining, err := Apply(entries, ex, io.Discard)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected no remaining entries, got %d", len(remaining))
+	}
+
+	want := []call{
+		{method: "POST", path: "repos/o/r/issues", body: `{"title":"new"}`},
+		{method: "POST", path: "repos/o/r/issues/1/sub_issues", body: `{"sub_issue_id":4242}`},
+		{method: "GRAPHQL", path: `mutation($c: ID!) { addProjectV2ItemById(input: {projectId: "PVT_1", contentId: $c}) { item { id } } }`, body: `{"c":"I_real"}`},
+		{method: "PATCH", path: "repos/o/r/issues/17", body: `{"body":"see PVTI_real"}`},
+	}
+	if len(ex.calls) != len(want) {
+		t.Fatalf("got %d calls, want %d", len(ex.calls), len(want))
+	}
+	for i := range want {
+		if ex.calls[i] != want[i] {
+			t.Errorf("call %d = %+v, want %+v", i, ex.calls[i], want[i])
+		}
+	}
+}
+
+func TestApply_FailureReturnsRemaining(t *testing.T) {
+	j, _ := Open(t.TempDir() + "/journal.jsonl")
+	entries := recordCreateAndLink(t, j)
+
+	ex := &fakeExecutor{
+		responses: []string{`{"id": 4242, "node_id": "I_real", "number": 17}`, `{}`},
+		failAt:    3,
+	}
+	remaining, err := Apply(entries, ex, io.Discard)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(remaining) != 2 {
+		t.Fatalf("expected 2 remaining entries, got %d", len(remaining))
+	}
+	if remaining[0].Seq != 3 {
+		t.Errorf("first remaining seq = %d, want 3", remaining[0].Seq)
+	}
+	// 解決済みの仮の値は置き換えて残す
+	var req struct {
+		Variables map[string]string `json:"variables"`
+	}
+	json.Unmarshal(remaining[0].Body, &req)
+	if req.Variables["c"] != "I_real" {
+		t.Errorf("variables.c = %q, want I_real", req.Variables["c"])
+	}
+	if remaining[1].Path != "repos/o/r/issues/17" {
+		t.Errorf("path = %q, want repos/o/r/issues/17", remaining[1].Path)
+	}
+}
@@ -0,0 +1,184 @@
+// Package journal は GitHub API への書き込みリクエストを記録し、後から再実行するための仕組みを提供する。
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/util"
+)
+
+// API は記録したリクエストの送信先 API の種類。
+type API string
+
+const (
+	APIGraphQL API = "graphql"
+	APIREST    API = "rest"
+)
+
+// Entry は記録した書き込みリクエスト1件。
+type Entry struct {
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+	API  API       `json:"api"`
+	// Method と Path は REST の場合のみ設定する。Path は API ルートからの相対パス（例: repos/o/r/issues）。
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Body は REST のリクエストボディ、または GraphQL の {"query", "variables"}。
+	Body json.RawMessage `json:"body,omitempty"`
+	// Operation は表示用の操作名（GraphQL の mutation フィールド名や "POST repos/o/r/issues"）。
+	Operation string `json:"operation"`
+	// Placeholders は記録時に返した仮の値から、実際のレスポンス中の位置（ドット区切りのキー）への対応。
+	// 再実行時に実際の値を取り出し、後続のリクエストに含まれる仮の値を置き換える。
+	Placeholders map[string]string `json:"placeholders,omitempty"`
+}
+
+// Journal は Entry を JSON Lines 形式のファイルに追記して保持する。
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	nextSeq int
+	// placeholderSeq は最後に払い出した仮の値の連番。記録済みの仮の値と衝突しないよう Open 時に復元する。
+	placeholderSeq int64
+}
+
+// Open は指定ファイルをバックエンドとする Journal を開く。ファイルがなければ最初の記録時に作成する。
+func Open(path string) (*Journal, error) {
+	j := &Journal{path: path, nextSeq: 1}
+	entries, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		j.nextSeq = max(j.nextSeq, e.Seq+1)
+		for placeholder := range e.Placeholders {
+			j.placeholderSeq = max(j.placeholderSeq, placeholderSeqOf(placeholder))
+		}
+	}
+	return j, nil
+}
+
+// Path はバックエンドのファイルパスを返す。
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append は Seq と Time を設定して Entry を追記し、設定後の Entry を返す。
+func (j *Journal) Append(e Entry) (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e.Seq = j.nextSeq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return Entry{}, fmt.Errorf("failed to create journal dir: %w", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return Entry{}, fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.nextSeq++
+	return e, nil
+}
+
+// List は記録された Entry を記録順に返す。ファイルがなければ空を返す。
+func (j *Journal) List() ([]Entry, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	entries := []Entry{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Replace は記録内容を entries で置き換える。entries が空ならファイルを削除する。
+func (j *Journal) Replace(entries []Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.write(entries)
+}
+
+// Remove は指定した Seq の Entry を削除する。該当しない Seq は無視する。
+func (j *Journal) Remove(seqs ...int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.List()
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if !slices.Contains(seqs, e.Seq) {
+			kept = append(kept, e)
+		}
+	}
+	return j.write(kept)
+}
+
+func (j *Journal) write(entries []Entry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear journal: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal journal entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create journal dir: %w", err)
+	}
+	// 送信していない変更を、書き込み途中で落ちても失わないようにする
+	if err := util.WriteFileAtomic(j.path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Clear は記録をすべて削除する。
+func (j *Journal) Clear() error {
+	return j.Replace(nil)
+}
@@ -0,0 +1,136 @@
+package journal
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestJournal_AppendAndList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	entries, err := j.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty journal, got %d entries", len(entries))
+	}
+
+	for _, op := range []string{"addBlockedBy", "POST repos/o/r/issues"} {
+		e, err := j.Append(Entry{API: APIGraphQL, Body: json.RawMessage(`{"query":"mutation { x }"}`), Operation: op})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		if e.Time.IsZero() {
+			t.Error("expected Time to be set")
+		}
+	}
+
+	entries, err = j.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Seq != 1 || entries[1].Seq != 2 {
+		t.Errorf("seq = %d, %d, want 1, 2", entries[0].Seq, entries[1].Seq)
+	}
+	if entries[1].Operation != "POST repos/o/r/issues" {
+		t.Errorf("operation = %q", entries[1].Operation)
+	}
+}
+
+func TestOpen_RestoresCounters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, _ := Open(path)
+	nodeID := j.NewNodeID()
+	number := j.NewIssueNumber()
+	if _, err := j.Append(Entry{API: APIREST, Operation: "a", Placeholders: map[string]string{nodeID: "node_id"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := j.Append(Entry{API: APIREST, Operation: "b", Placeholders: map[string]string{strconv.FormatInt(number, 10): "number"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	e, err := reopened.Append(Entry{API: APIREST, Operation: "c"})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if e.Seq != 3 {
+		t.Errorf("seq = %d, want 3", e.Seq)
+	}
+	if got := reopened.NewNodeID(); got == nodeID || placeholderSeqOf(got) != 3 {
+		t.Errorf("NewNodeID = %q, want a placeholder after the recorded ones", got)
+	}
+}
+
+func TestJournal_ReplaceAndClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, _ := Open(path)
+	for range 3 {
+		j.Append(Entry{API: APIREST, Operation: "x"})
+	}
+	entries, _ := j.List()
+
+	if err := j.Replace(entries[2:]); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	got, _ := j.List()
+	if len(got) != 1 || got[0].Seq != 3 {
+		t.Fatalf("after Replace got %+v", got)
+	}
+
+	if err := j.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	got, _ = j.List()
+	if len(got) != 0 {
+		t.Fatalf("expected empty journal after Clear, got %d entries", len(got))
+	}
+	// 既に空でもエラーにならない
+	if err := j.Clear(); err != nil {
+		t.Fatalf("Clear on empty journal: %v", err)
+	}
+}
+
+func TestJournal_Remove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, _ := Open(path)
+	for range 3 {
+		j.Append(Entry{API: APIREST, Operation: "x"})
+	}
+
+	if err := j.Remove(1, 3, 42); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	got, _ := j.List()
+	if len(got) != 1 || got[0].Seq != 2 {
+		t.Fatalf("after Remove got %+v", got)
+	}
+	// Seq は削除後も振り直さない
+	e, _ := j.Append(Entry{API: APIREST, Operation: "y"})
+	if e.Seq != 4 {
+		t.Errorf("seq = %d, want 4", e.Seq)
+	}
+}
+
+func TestIsPlaceholder(t *testing.T) {
+	j, _ := Open(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if id := j.NewNodeID(); !IsPlaceholder(id) {
+		t.Errorf("IsPlaceholder(%q) = false", id)
+	}
+	if IsPlaceholder("I_kwDOABC") {
+		t.Error("IsPlaceholder(real node id) = true")
+	}
+}
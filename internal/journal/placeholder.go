@@ -0,0 +1,61 @@
+package journal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 記録中に返す仮の値。実際の GitHub の値と衝突しない範囲を使う。
+// ノード ID は固定長にして、ある仮の値が別の仮の値の前方一致にならないようにする。
+const (
+	placeholderNodeIDPrefix = "DRYRUN_"
+	placeholderNodeIDFormat = placeholderNodeIDPrefix + "%010d"
+	placeholderNumberBase   = 1_000_000_000
+	placeholderIDBase       = 9_000_000_000_000
+)
+
+// IsPlaceholder は s が仮のノード ID か判定する。
+func IsPlaceholder(s string) bool {
+	return strings.HasPrefix(s, placeholderNodeIDPrefix)
+}
+
+// NewNodeID は仮のノード ID を払い出す。
+func (j *Journal) NewNodeID() string {
+	return fmt.Sprintf(placeholderNodeIDFormat, j.nextPlaceholder())
+}
+
+// NewIssueNumber は仮の Issue 番号を払い出す。
+func (j *Journal) NewIssueNumber() int64 {
+	return placeholderNumberBase + j.nextPlaceholder()
+}
+
+// NewDatabaseID は REST API の id に相当する仮の値を払い出す。
+func (j *Journal) NewDatabaseID() int64 {
+	return placeholderIDBase + j.nextPlaceholder()
+}
+
+func (j *Journal) nextPlaceholder() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.placeholderSeq++
+	return j.placeholderSeq
+}
+
+// placeholderSeqOf は仮の値から払い出し時の連番を取り出す。仮の値でなければ 0 を返す。
+func placeholderSeqOf(placeholder string) int64 {
+	if rest, ok := strings.CutPrefix(placeholder, placeholderNodeIDPrefix); ok {
+		n, _ := strconv.ParseInt(rest, 10, 64)
+		return n
+	}
+	n, err := strconv.ParseInt(placeholder, 10, 64)
+	switch {
+	case err != nil:
+		return 0
+	case n > placeholderIDBase:
+		return n - placeholderIDBase
+	case n > placeholderNumberBase:
+		return n - placeholderNumberBase
+	}
+	return 0
+}
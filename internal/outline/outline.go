@@ -0,0 +1,192 @@
+package outline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Node はアウトラインの1項目。子は sub-issue として登録する。
+type Node struct {
+	// Key は blocked-by から参照するためのキー。YAML の id で指定する。
+	Key string
+	// Title は作成する Issue のタイトル。Issue を指定した場合は空でもよい。
+	Title string
+	Body  string
+	// Issue は既存の Issue を指定する参照（OWNER/REPO#NUMBER または #NUMBER）。
+	Issue string
+	// BlockedBy はこの項目をブロックする項目への参照。
+	// Key、タイトル、OWNER/REPO#NUMBER、#NUMBER のいずれかで指定する。
+	BlockedBy []string
+	Children  []*Node
+}
+
+// Parse はファイル拡張子に応じて YAML または Markdown としてアウトラインを読み込む。
+func Parse(filename string, data []byte) ([]*Node, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".md", ".markdown":
+		return ParseMarkdown(data)
+	default:
+		return nil, fmt.Errorf("unsupported outline file %q, expected .yaml, .yml or .md", filename)
+	}
+}
+
+// yamlNode は YAML の1項目。文字列だけの項目はタイトルとして扱う。
+type yamlNode struct {
+	ID        string     `yaml:"id"`
+	Title     string     `yaml:"title"`
+	Body      string     `yaml:"body"`
+	Issue     string     `yaml:"issue"`
+	BlockedBy []string   `yaml:"blocked-by"`
+	Children  []yamlNode `yaml:"children"`
+}
+
+func (n *yamlNode) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		n.Title = value.Value
+		return nil
+	}
+	type plain yamlNode
+	return value.Decode((*plain)(n))
+}
+
+// ParseYAML は YAML のアウトラインを読み込む。トップレベルは項目の配列。
+//
+//	# outline.yaml
+//	- id: design
+//	  title: Design
+//	- title: Build
+//	  blocked-by: [design]
+//	  children:
+//	    - Backend
+//	    - Frontend
+func ParseYAML(data []byte) ([]*Node, error) {
+	var items []yamlNode
+	if err := yaml.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML outline: %w", err)
+	}
+	return convertYAMLNodes(items)
+}
+
+func convertYAMLNodes(items []yamlNode) ([]*Node, error) {
+	nodes := make([]*Node, 0, len(items))
+	for _, item := range items {
+		if item.Title == "" && item.Issue == "" {
+			return nil, fmt.Errorf("outline item requires title or issue")
+		}
+		children, err := convertYAMLNodes(item.Children)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &Node{
+			Key:       item.ID,
+			Title:     item.Title,
+			Body:      item.Body,
+			Issue:     item.Issue,
+			BlockedBy: item.BlockedBy,
+			Children:  children,
+		})
+	}
+	return nodes, nil
+}
+
+var (
+	bulletPattern    = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	checkboxPattern  = regexp.MustCompile(`^\[[ xX]\]\s+`)
+	blockedByPattern = regexp.MustCompile(`(?i)\s*[(\[]?blocked[- ]by:\s*([^)\]]*)[)\]]?\s*$`)
+	issueRefPattern  = regexp.MustCompile(`^(?:[\w.-]+/[\w.-]+)?#\d+$`)
+)
+
+// ParseMarkdown は Markdown の箇条書きのアウトラインを読み込む。
+// インデントで階層を表し、行末の `blocked-by: A, B` で blocked-by を指定する。
+// 項目が `#12` や `owner/repo#12` だけの場合は既存の Issue を指す。
+// 箇条書きの下にインデントされた箇条書きでない行は、その項目の本文になる。
+//
+//	# outline.md
+//	- Epic
+//	  - Design
+//	  - Build (blocked-by: Design)
+//	    - #12
+func ParseMarkdown(data []byte) ([]*Node, error) {
+	type frame struct {
+		indent int
+		node   *Node
+	}
+	var roots []*Node
+	var stack []frame
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		m := bulletPattern.FindStringSubmatch(line)
+		if m == nil {
+			// 直前の項目より深くインデントされた行は本文として扱う
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if len(stack) > 0 && indent > stack[len(stack)-1].indent {
+				top := stack[len(stack)-1].node
+				if top.Body != "" {
+					top.Body += "\n"
+				}
+				top.Body += strings.TrimSpace(line)
+			}
+			continue
+		}
+
+		indent := len(m[1])
+		node, err := parseMarkdownItem(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, frame{indent: indent, node: node})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Markdown outline: %w", err)
+	}
+	return roots, nil
+}
+
+func parseMarkdownItem(text string) (*Node, error) {
+	text = checkboxPattern.ReplaceAllString(text, "")
+	node := &Node{}
+
+	if m := blockedByPattern.FindStringSubmatchIndex(text); m != nil {
+		for _, ref := range strings.Split(text[m[2]:m[3]], ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				node.BlockedBy = append(node.BlockedBy, ref)
+			}
+		}
+		text = text[:m[0]]
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("outline item requires title")
+	}
+	if issueRefPattern.MatchString(text) {
+		node.Issue = text
+	} else {
+		node.Title = text
+	}
+	return node, nil
+}
@@ -0,0 +1,111 @@
+package outline
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`
+- title: Epic
+  body: Epic body
+  children:
+    - id: design
+      title: Design
+    - title: Build
+      blocked-by: [design, "#9"]
+      children:
+        - Backend
+- issue: owner/repo#12
+`)
+
+	nodes, err := ParseYAML(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(nodes))
+	}
+	epic := nodes[0]
+	if epic.Title != "Epic" || epic.Body != "Epic body" || len(epic.Children) != 2 {
+		t.Errorf("unexpected epic: %+v", epic)
+	}
+	if design := epic.Children[0]; design.Key != "design" || design.Title != "Design" {
+		t.Errorf("unexpected design: %+v", design)
+	}
+	build := epic.Children[1]
+	if !slices.Equal(build.BlockedBy, []string{"design", "#9"}) {
+		t.Errorf("unexpected blocked-by: %v", build.BlockedBy)
+	}
+	if len(build.Children) != 1 || build.Children[0].Title != "Backend" {
+		t.Errorf("unexpected build children: %+v", build.Children)
+	}
+	if nodes[1].Issue != "owner/repo#12" {
+		t.Errorf("unexpected issue ref: %+v", nodes[1])
+	}
+}
+
+func TestParseYAML_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not a list", data: "title: Epic"},
+		{name: "missing title", data: "- body: no title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseYAML([]byte(tt.data)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	data := []byte(`# Release plan
+
+- Epic
+  Epic body line
+  - [ ] Design
+  - Build (blocked-by: Design, #9)
+    * Backend
+  - owner/repo#12
+1. Standalone blocked-by: owner/other#3
+`)
+
+	nodes, err := ParseMarkdown(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(nodes))
+	}
+	epic := nodes[0]
+	if epic.Title != "Epic" || epic.Body != "Epic body line" || len(epic.Children) != 3 {
+		t.Fatalf("unexpected epic: %+v", epic)
+	}
+	if design := epic.Children[0]; design.Title != "Design" {
+		t.Errorf("unexpected design: %+v", design)
+	}
+	build := epic.Children[1]
+	if build.Title != "Build" || !slices.Equal(build.BlockedBy, []string{"Design", "#9"}) {
+		t.Errorf("unexpected build: %+v", build)
+	}
+	if len(build.Children) != 1 || build.Children[0].Title != "Backend" {
+		t.Errorf("unexpected build children: %+v", build.Children)
+	}
+	if ref := epic.Children[2]; ref.Issue != "owner/repo#12" || ref.Title != "" {
+		t.Errorf("unexpected issue ref: %+v", ref)
+	}
+	standalone := nodes[1]
+	if standalone.Title != "Standalone" || !slices.Equal(standalone.BlockedBy, []string{"owner/other#3"}) {
+		t.Errorf("unexpected standalone: %+v", standalone)
+	}
+}
+
+func TestParse_UnsupportedExtension(t *testing.T) {
+	if _, err := Parse("outline.txt", []byte("- a")); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
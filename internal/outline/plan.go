@@ -0,0 +1,345 @@
+package outline
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// PlannedIssue は取り込みで扱う Issue。ID が空のものは新規に作成する。
+type PlannedIssue struct {
+	ID           string
+	NodeID       string
+	Owner        string
+	Repo         string
+	Title        string
+	Body         string
+	AddToProject bool
+}
+
+// Create は新規に作成する Issue か判定する。
+func (pi *PlannedIssue) Create() bool {
+	return pi.ID == ""
+}
+
+func (pi *PlannedIssue) label() string {
+	if pi.ID != "" {
+		return pi.ID
+	}
+	return strconv.Quote(pi.Title)
+}
+
+// PlannedLink は追加する依存関係。Source と Target は Plan.Issues の添字。
+// sub_issue は Source が親、blocked_by は Source が Target をブロックする。
+type PlannedLink struct {
+	Type   github.DependencyType
+	Source int
+	Target int
+}
+
+// Plan はアウトラインの取り込み計画。
+type Plan struct {
+	ProjectID string
+	Issues    []*PlannedIssue
+	Links     []PlannedLink
+}
+
+// BuildPlan はアウトラインと取り込み先プロジェクトの現在の状態から取り込み計画を作る。
+// プロジェクト内に同じリポジトリ・同じタイトルの Issue があれば、作成せずにそれを使う。
+// 既に存在する依存関係は計画に含めない。
+func BuildPlan(roots []*Node, projectID, owner, repo string, existing []github.Issue, deps []github.Dependency) (*Plan, error) {
+	b := &planBuilder{
+		plan:     &Plan{ProjectID: projectID},
+		owner:    owner,
+		repo:     repo,
+		existing: make(map[string]github.Issue, len(existing)),
+		byTitle:  make(map[string]github.Issue),
+		byID:     make(map[string]int),
+		byKey:    make(map[string]int),
+		byName:   make(map[string][]int),
+		linked:   make(map[github.Dependency]bool, len(deps)),
+		seen:     make(map[PlannedLink]bool),
+	}
+	for _, issue := range existing {
+		b.existing[issue.ID] = issue
+		if issue.Owner == owner && issue.Repo == repo {
+			if _, dup := b.byTitle[issue.Title]; !dup {
+				b.byTitle[issue.Title] = issue
+			}
+		}
+	}
+	for _, d := range deps {
+		b.linked[d] = true
+	}
+
+	if err := b.addNodes(roots, -1); err != nil {
+		return nil, err
+	}
+	if err := b.addBlockedBy(roots); err != nil {
+		return nil, err
+	}
+	return b.plan, nil
+}
+
+type planBuilder struct {
+	plan        *Plan
+	owner, repo string
+	existing    map[string]github.Issue
+	byTitle     map[string]github.Issue
+	byID        map[string]int
+	byKey       map[string]int
+	byName      map[string][]int
+	nodeIndex   []int
+	linked      map[github.Dependency]bool
+	seen        map[PlannedLink]bool
+}
+
+// addNodes はアウトラインの項目を深さ優先で Issues に登録し、sub-issue の関係を追加する。
+func (b *planBuilder) addNodes(nodes []*Node, parent int) error {
+	for _, n := range nodes {
+		var idx int
+		if n.Issue != "" {
+			id, err := b.resolveIssueRef(n.Issue)
+			if err != nil {
+				return err
+			}
+			idx = b.issueByID(id, true)
+		} else if issue, ok := b.byTitle[n.Title]; ok {
+			idx = b.issueByID(issue.ID, true)
+		} else {
+			b.plan.Issues = append(b.plan.Issues, &PlannedIssue{
+				Owner:        b.owner,
+				Repo:         b.repo,
+				Title:        n.Title,
+				Body:         n.Body,
+				AddToProject: true,
+			})
+			idx = len(b.plan.Issues) - 1
+		}
+
+		if n.Key != "" {
+			if _, dup := b.byKey[n.Key]; dup {
+				return fmt.Errorf("duplicate outline id %q", n.Key)
+			}
+			b.byKey[n.Key] = idx
+		}
+		if n.Title != "" && !slices.Contains(b.byName[n.Title], idx) {
+			b.byName[n.Title] = append(b.byName[n.Title], idx)
+		}
+		b.nodeIndex = append(b.nodeIndex, idx)
+
+		if parent >= 0 {
+			b.addLink(github.DependencySubIssue, parent, idx)
+		}
+		if err := b.addNodes(n.Children, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addBlockedBy は addNodes と同じ順序で項目を辿り、blocked-by の関係を追加する。
+func (b *planBuilder) addBlockedBy(roots []*Node) error {
+	i := 0
+	var walk func(nodes []*Node) error
+	walk = func(nodes []*Node) error {
+		for _, n := range nodes {
+			idx := b.nodeIndex[i]
+			i++
+			for _, ref := range n.BlockedBy {
+				blocker, err := b.resolveBlockedByRef(ref)
+				if err != nil {
+					return fmt.Errorf("%s: %w", b.plan.Issues[idx].label(), err)
+				}
+				b.addLink(github.DependencyBlockedBy, blocker, idx)
+			}
+			if err := walk(n.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(roots)
+}
+
+func (b *planBuilder) resolveBlockedByRef(ref string) (int, error) {
+	if idx, ok := b.byKey[ref]; ok {
+		return idx, nil
+	}
+	if issueRefPattern.MatchString(ref) {
+		id, err := b.resolveIssueRef(ref)
+		if err != nil {
+			return 0, err
+		}
+		if idx, ok := b.byID[id]; ok {
+			return idx, nil
+		}
+		return b.issueByID(id, false), nil
+	}
+	switch matches := b.byName[ref]; len(matches) {
+	case 0:
+		return 0, fmt.Errorf("unknown blocked-by reference %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("ambiguous blocked-by reference %q matches %d items", ref, len(matches))
+	}
+}
+
+// resolveIssueRef は #NUMBER を取り込み先リポジトリの複合 ID に展開する。
+func (b *planBuilder) resolveIssueRef(ref string) (string, error) {
+	if strings.HasPrefix(ref, "#") {
+		ref = b.owner + "/" + b.repo + ref
+	}
+	owner, repo, number, err := github.ParseIssueID(ref)
+	if err != nil {
+		return "", err
+	}
+	return github.BuildIssueID(owner, repo, number), nil
+}
+
+// issueByID は既存の Issue を Issues に登録して添字を返す。登録済みならその添字を返す。
+// inOutline が true でプロジェクト外の Issue はプロジェクトに追加する。
+func (b *planBuilder) issueByID(id string, inOutline bool) int {
+	if idx, ok := b.byID[id]; ok {
+		if inOutline {
+			_, inProject := b.existing[id]
+			b.plan.Issues[idx].AddToProject = !inProject
+		}
+		return idx
+	}
+
+	owner, repo, _, _ := github.ParseIssueID(id)
+	pi := &PlannedIssue{ID: id, Owner: owner, Repo: repo}
+	if issue, ok := b.existing[id]; ok {
+		pi.NodeID = issue.NodeID
+		pi.Title = issue.Title
+	} else {
+		pi.AddToProject = inOutline
+	}
+	b.plan.Issues = append(b.plan.Issues, pi)
+	b.byID[id] = len(b.plan.Issues) - 1
+	return len(b.plan.Issues) - 1
+}
+
+func (b *planBuilder) addLink(t github.DependencyType, source, target int) {
+	link := PlannedLink{Type: t, Source: source, Target: target}
+	if b.seen[link] {
+		return
+	}
+	b.seen[link] = true
+
+	s, tg := b.plan.Issues[source], b.plan.Issues[target]
+	if s.ID != "" && tg.ID != "" && b.linked[github.Dependency{Source: s.ID, Target: tg.ID, Type: t}] {
+		return
+	}
+	b.plan.Links = append(b.plan.Links, link)
+}
+
+// Empty は実行すべき操作がないか判定する。
+func (p *Plan) Empty() bool {
+	if len(p.Links) > 0 {
+		return false
+	}
+	for _, pi := range p.Issues {
+		if pi.Create() || pi.AddToProject {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe は計画を1操作1行で書き出す。
+func (p *Plan) Describe(w io.Writer) error {
+	var b strings.Builder
+	for _, pi := range p.Issues {
+		if pi.Create() {
+			fmt.Fprintf(&b, "create   %s/%s %s\n", pi.Owner, pi.Repo, pi.label())
+		}
+	}
+	for _, pi := range p.Issues {
+		if pi.AddToProject {
+			fmt.Fprintf(&b, "add      %s to project\n", pi.label())
+		}
+	}
+	for _, l := range p.Links {
+		fmt.Fprintf(&b, "link     %s\n", p.describeLink(l))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (p *Plan) describeLink(l PlannedLink) string {
+	source, target := p.Issues[l.Source].label(), p.Issues[l.Target].label()
+	if l.Type == github.DependencySubIssue {
+		return fmt.Sprintf("%s as sub-issue of %s", target, source)
+	}
+	return fmt.Sprintf("%s blocked by %s", target, source)
+}
+
+// Executor は計画の実行に必要な GitHub API の操作。
+type Executor interface {
+	CreateIssue(owner, repo, title, body string) (github.Issue, error)
+	ResolveIssueNodeID(owner, repo string, number int) (string, error)
+	AddProjectItem(projectID, contentID string) (string, error)
+	AddSubIssue(issueID, subIssueID string) error
+	AddBlockedBy(issueID, blockingIssueID string) error
+}
+
+// Apply は計画を実行し、進捗を w に書き出す。
+// Issue の作成、プロジェクトへの追加、依存関係の追加の順に行い、最初のエラーで中断する。
+func (p *Plan) Apply(ex Executor, w io.Writer) error {
+	for _, pi := range p.Issues {
+		if !pi.Create() {
+			continue
+		}
+		created, err := ex.CreateIssue(pi.Owner, pi.Repo, pi.Title, pi.Body)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "created  %s %q\n", created.ID, pi.Title)
+		pi.ID, pi.NodeID = created.ID, created.NodeID
+	}
+
+	for _, pi := range p.Issues {
+		if pi.NodeID != "" {
+			continue
+		}
+		_, _, number, err := github.ParseIssueID(pi.ID)
+		if err != nil {
+			return err
+		}
+		if pi.NodeID, err = ex.ResolveIssueNodeID(pi.Owner, pi.Repo, number); err != nil {
+			return err
+		}
+	}
+
+	for _, pi := range p.Issues {
+		if !pi.AddToProject {
+			continue
+		}
+		if _, err := ex.AddProjectItem(p.ProjectID, pi.NodeID); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "added    %s to project\n", pi.ID)
+	}
+
+	for _, l := range p.Links {
+		source, target := p.Issues[l.Source], p.Issues[l.Target]
+		var err error
+		if l.Type == github.DependencySubIssue {
+			err = ex.AddSubIssue(source.NodeID, target.NodeID)
+		} else {
+			err = ex.AddBlockedBy(target.NodeID, source.NodeID)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "linked   %s\n", p.describeLink(l))
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package outline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func TestBuildPlan(t *testing.T) {
+	roots := []*Node{
+		{Title: "Epic", Children: []*Node{
+			{Key: "design", Title: "Design"},
+			{Title: "Build", BlockedBy: []string{"design", "#9"}},
+		}},
+		{Issue: "#12", BlockedBy: []string{"Build"}},
+	}
+	existing := []github.Issue{
+		{ID: "o/r#1", NodeID: "I_1", Owner: "o", Repo: "r", Number: 1, Title: "Epic"},
+		{ID: "o/r#2", NodeID: "I_2", Owner: "o", Repo: "r", Number: 2, Title: "Design"},
+	}
+	deps := []github.Dependency{
+		{Source: "o/r#1", Target: "o/r#2", Type: github.DependencySubIssue},
+	}
+
+	plan, err := BuildPlan(roots, "PVT_1", "o", "r", existing, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	if err := plan.Describe(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Join([]string{
+		`create   o/r "Build"`,
+		`add      "Build" to project`,
+		`add      o/r#12 to project`,
+		`link     "Build" as sub-issue of o/r#1`,
+		`link     "Build" blocked by o/r#2`,
+		`link     "Build" blocked by o/r#9`,
+		`link     o/r#12 blocked by "Build"`,
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("unexpected plan:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestBuildPlan_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		roots []*Node
+	}{
+		{
+			name:  "unknown reference",
+			roots: []*Node{{Title: "A", BlockedBy: []string{"missing"}}},
+		},
+		{
+			name:  "ambiguous title",
+			roots: []*Node{{Title: "A"}, {Title: "A"}, {Title: "B", BlockedBy: []string{"A"}}},
+		},
+		{
+			name:  "duplicate id",
+			roots: []*Node{{Key: "x", Title: "A"}, {Key: "x", Title: "B"}},
+		},
+		{
+			name:  "invalid issue reference",
+			roots: []*Node{{Issue: "owner#1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildPlan(tt.roots, "PVT_1", "o", "r", nil, nil); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+type fakeExecutor struct {
+	calls   []string
+	created int
+}
+
+func (f *fakeExecutor) CreateIssue(owner, repo, title, body string) (github.Issue, error) {
+	f.created++
+	number := 100 + f.created
+	f.calls = append(f.calls, fmt.Sprintf("create %s", title))
+	return github.Issue{ID: github.BuildIssueID(owner, repo, number), NodeID: fmt.Sprintf("I_%d", number)}, nil
+}
+
+func (f *fakeExecutor) ResolveIssueNodeID(owner, repo string, number int) (string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("resolve %s", github.BuildIssueID(owner, repo, number)))
+	return fmt.Sprintf("I_%d", number), nil
+}
+
+func (f *fakeExecutor) AddProjectItem(projectID, contentID string) (string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("add %s %s", projectID, contentID))
+	return "PVTI_" + contentID, nil
+}
+
+func (f *fakeExecutor) AddSubIssue(issueID, subIssueID string) error {
+	f.calls = append(f.calls, fmt.Sprintf("sub %s %s", issueID, subIssueID))
+	return nil
+}
+
+func (f *fakeExecutor) AddBlockedBy(issueID, blockingIssueID string) error {
+	f.calls = append(f.calls, fmt.Sprintf("blocked %s %s", issueID, blockingIssueID))
+	return nil
+}
+
+func TestPlanApply(t *testing.T) {
+	roots := []*Node{
+		{Title: "Epic", Children: []*Node{
+			{Title: "Design"},
+			{Title: "Build", BlockedBy: []string{"Design", "o/r#9"}},
+		}},
+	}
+	plan, err := BuildPlan(roots, "PVT_1", "o", "r", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ex := &fakeExecutor{}
+	var out strings.Builder
+	if err := plan.Apply(ex, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"create Epic",
+		"create Design",
+		"create Build",
+		"resolve o/r#9",
+		"add PVT_1 I_101",
+		"add PVT_1 I_102",
+		"add PVT_1 I_103",
+		"sub I_101 I_102",
+		"sub I_101 I_103",
+		"blocked I_103 I_102",
+		"blocked I_103 I_9",
+	}
+	if strings.Join(ex.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected calls:\n%s\nwant:\n%s", strings.Join(ex.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(out.String(), "created  o/r#101 \"Epic\"") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if plan.Empty() {
+		t.Error("expected plan not to be empty")
+	}
+}
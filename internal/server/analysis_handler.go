@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/graph"
+)
+
+// projectGateway は server が Go 側で利用する GitHub Projects API を抽象化する。
+type projectGateway interface {
+	SyncProjectItems(projectID string, cached []github.ProjectItem, since time.Time) (*github.ProjectSync, error)
+	ListProjectItems(projectID string) ([]github.Issue, []github.Dependency, error)
+	ListProjectFields(projectID string) ([]github.ProjectField, error)
+}
+
+type analysisHandler struct {
+	gateway projectGateway
+}
+
+// criticalPathResponse は GET /api/analysis/critical-path のレスポンス。
+type criticalPathResponse struct {
+	graph.CriticalPath
+	WeightField string `json:"weightField,omitempty"`
+}
+
+// ServeCriticalPath は GET /api/analysis/critical-path?projectId=...&weightField=... を処理する。
+// weightField を指定した場合は NUMBER フィールドの値で重み付けする。
+func (h *analysisHandler) ServeCriticalPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	projectID := r.URL.Query().Get("projectId")
+	if projectID == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "missing projectId")
+		return
+	}
+	if !github.IsProjectID(projectID) {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid projectId")
+		return
+	}
+	weightField := r.URL.Query().Get("weightField")
+
+	issues, deps, err := h.gateway.ListProjectItems(projectID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, errCodeUpstream, err.Error())
+		return
+	}
+	g := graph.New(issues, deps)
+
+	weight := graph.UnitWeight
+	if weightField != "" {
+		fields, err := h.gateway.ListProjectFields(projectID)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, errCodeUpstream, err.Error())
+			return
+		}
+		field, ok := github.FindProjectField(fields, weightField)
+		if !ok || field.DataType != "NUMBER" {
+			writeError(w, http.StatusBadRequest, errCodeBadRequest, fmt.Sprintf("NUMBER field %q not found", weightField))
+			return
+		}
+		weight = graph.NumberFieldWeight(g, field.ID)
+	}
+
+	path, err := graph.FindCriticalPath(g, weight)
+	if errors.Is(err, graph.ErrCycle) {
+		writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(criticalPathResponse{CriticalPath: path, WeightField: weightField})
+}
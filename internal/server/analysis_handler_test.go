@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+type fakeProjectGateway struct {
+	items     []github.ProjectItem
+	syncedAt  time.Time
+	rateLimit *github.RateLimit
+	issues    []github.Issue
+	deps      []github.Dependency
+	fields    []github.ProjectField
+	err       error
+
+	// synced records the projects passed to SyncProjectItems, cached and since the
+	// other arguments of the last call.
+	synced []string
+	cached []github.ProjectItem
+	since  time.Time
+}
+
+func (f *fakeProjectGateway) SyncProjectItems(projectID string, cached []github.ProjectItem, since time.Time) (*github.ProjectSync, error) {
+	f.synced = append(f.synced, projectID)
+	f.cached, f.since = cached, since
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &github.ProjectSync{Items: f.items, SyncedAt: f.syncedAt, Full: since.IsZero(), RateLimit: f.rateLimit}, nil
+}
+
+func (f *fakeProjectGateway) ListProjectItems(string) ([]github.Issue, []github.Dependency, error) {
+	return f.issues, f.deps, f.err
+}
+
+func (f *fakeProjectGateway) ListProjectFields(string) ([]github.ProjectField, error) {
+	return f.fields, f.err
+}
+
+func newAnalysisGateway() *fakeProjectGateway {
+	issue := func(id string, estimate float64) github.Issue {
+		return github.Issue{ID: id, State: "open", NumberValues: map[string]float64{"F_EST": estimate}}
+	}
+	blocks := func(blocker, blocked string) github.Dependency {
+		return github.Dependency{Source: blocker, Target: blocked, Type: github.DependencyBlockedBy}
+	}
+	return &fakeProjectGateway{
+		issues: []github.Issue{issue("o/r#1", 1), issue("o/r#2", 1), issue("o/r#3", 8)},
+		deps:   []github.Dependency{blocks("o/r#1", "o/r#2")},
+		fields: []github.ProjectField{{ID: "F_EST", Name: "Estimate", DataType: "NUMBER"}},
+	}
+}
+
+func TestServeCriticalPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		gateway    *fakeProjectGateway
+		wantStatus int
+		wantIDs    []string
+		wantTotal  float64
+	}{
+		{
+			name:       "unweighted",
+			url:        "/api/analysis/critical-path?projectId=PVT_1",
+			gateway:    newAnalysisGateway(),
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"o/r#1", "o/r#2"},
+			wantTotal:  2,
+		},
+		{
+			name:       "weighted by estimate",
+			url:        "/api/analysis/critical-path?projectId=PVT_1&weightField=Estimate",
+			gateway:    newAnalysisGateway(),
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"o/r#3"},
+			wantTotal:  8,
+		},
+		{
+			name:       "missing project ID",
+			url:        "/api/analysis/critical-path",
+			gateway:    newAnalysisGateway(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid project ID",
+			url:        "/api/analysis/critical-path?projectId=../etc",
+			gateway:    newAnalysisGateway(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown weight field",
+			url:        "/api/analysis/critical-path?projectId=PVT_1&weightField=Points",
+			gateway:    newAnalysisGateway(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "gateway error",
+			url:        "/api/analysis/critical-path?projectId=PVT_1",
+			gateway:    &fakeProjectGateway{err: errors.New("boom")},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name: "cycle",
+			url:  "/api/analysis/critical-path?projectId=PVT_1",
+			gateway: &fakeProjectGateway{deps: []github.Dependency{
+				{Source: "o/r#1", Target: "o/r#2", Type: github.DependencyBlockedBy},
+				{Source: "o/r#2", Target: "o/r#1", Type: github.DependencyBlockedBy},
+			}},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &analysisHandler{gateway: tt.gateway}
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			h.ServeCriticalPath(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got criticalPathResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if !slices.Equal(got.IDs, tt.wantIDs) || got.Total != tt.wantTotal {
+				t.Errorf("got %+v, want ids %v total %v", got, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestServeCriticalPath_MethodNotAllowed(t *testing.T) {
+	h := &analysisHandler{gateway: newAnalysisGateway()}
+	req := httptest.NewRequest(http.MethodPost, "/api/analysis/critical-path?projectId=PVT_1", nil)
+	w := httptest.NewRecorder()
+	h.ServeCriticalPath(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,322 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+)
+
+const (
+	// maxBatchOperations caps the number of operations of a single batch.
+	maxBatchOperations = 200
+	// defaultBatchConcurrency is how many operations are sent to GitHub at once
+	// unless the batch asks for another limit, which can be up to maxBatchConcurrency.
+	defaultBatchConcurrency = 4
+	maxBatchConcurrency     = 8
+)
+
+// batchOrderedFields are the input fields naming the issue or project item an operation
+// changes. Operations that share one of them run in the order of the batch.
+var batchOrderedFields = []string{"issueId", "blockingIssueId", "subIssueId", "itemId"}
+
+// batchMutations maps the mutations a batch can run to the string input fields they require.
+var batchMutations = map[string][]string{
+	"addBlockedBy":                  {"issueId", "blockingIssueId"},
+	"removeBlockedBy":               {"issueId", "blockingIssueId"},
+	"addSubIssue":                   {"issueId", "subIssueId"},
+	"removeSubIssue":                {"issueId", "subIssueId"},
+	"updateProjectV2ItemFieldValue": {"projectId", "itemId", "fieldId"},
+	"clearProjectV2ItemFieldValue":  {"projectId", "itemId", "fieldId"},
+}
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+	// Atomic rolls back the operations that succeeded when any operation fails.
+	Atomic      bool `json:"atomic"`
+	Concurrency int  `json:"concurrency"`
+}
+
+// batchOperation is a single mutation of a batch, with the input GitHub expects.
+type batchOperation struct {
+	Op    string         `json:"op"`
+	Input map[string]any `json:"input"`
+}
+
+// validate checks the operation and strips input fields the mutation does not take.
+func (op *batchOperation) validate() error {
+	fields, ok := batchMutations[op.Op]
+	if !ok {
+		return fmt.Errorf("unsupported op %q", op.Op)
+	}
+	input, ok := pickStrings(op.Input, fields...)
+	if !ok {
+		return fmt.Errorf("%s requires %v", op.Op, fields)
+	}
+	if op.Op == "updateProjectV2ItemFieldValue" {
+		value, ok := op.Input["value"].(map[string]any)
+		if !ok || len(value) != 1 {
+			return fmt.Errorf("%s requires a value with exactly one field", op.Op)
+		}
+		input["value"] = value
+	}
+	op.Input = input
+	return nil
+}
+
+// Statuses of the operations of a batch.
+const (
+	batchSucceeded = "succeeded"
+	batchFailed    = "failed"
+	// batchSkipped is an operation of an atomic batch that was not sent because another one failed.
+	batchSkipped        = "skipped"
+	batchRolledBack     = "rolled_back"
+	batchRollbackFailed = "rollback_failed"
+)
+
+type batchResult struct {
+	Index         int    `json:"index"`
+	Op            string `json:"op"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+	RollbackError string `json:"rollbackError,omitempty"`
+}
+
+type batchResponse struct {
+	Results    []batchResult `json:"results"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	RolledBack int           `json:"rolledBack"`
+}
+
+// batchHandler runs a list of dependency and project field mutations.
+//
+//	POST /api/batch  {"operations": [{"op": "addBlockedBy", "input": {...}}, ...], "atomic": true}
+//
+// Operations are started in order with at most Concurrency of them in flight, and
+// the response reports the outcome of each one. An operation that touches the same
+// issue or project item as an earlier one waits for it to finish, so moving a sub-issue
+// with removeSubIssue and then addSubIssue works as it reads. When an atomic batch has a failure,
+// the operations that were not started yet are skipped and the ones that succeeded
+// are reverted, newest first. The rollback is best effort: GitHub has no transactions,
+// and a revert can fail too, which the result of that operation reports.
+//
+// In dry-run mode the operations are recorded in the journal and a rollback removes them from it.
+type batchHandler struct {
+	proxy *proxyHandler
+}
+
+// batchChange is a change of a batch that reached GitHub (or the dry-run journal).
+type batchChange struct {
+	do history.Request
+	// undo reverts the change. It is nil when the change cannot be reverted.
+	undo *history.Request
+	// journalSeq is the journal entry of the change in dry-run mode.
+	journalSeq int
+}
+
+func (h *batchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.proxy.readOnly {
+		writeError(w, http.StatusForbidden, errCodeReadOnly, "the console is in read-only mode: batch operations are not allowed")
+		return
+	}
+	body, ok := readBody(w, r, maxInspectedBodyBytes)
+	if !ok {
+		return
+	}
+	var req batchRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	// Keep number field values as they were sent.
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "invalid batch request")
+		return
+	}
+	switch {
+	case len(req.Operations) == 0:
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "operations must not be empty")
+		return
+	case len(req.Operations) > maxBatchOperations:
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, fmt.Sprintf("a batch can have at most %d operations", maxBatchOperations))
+		return
+	case req.Concurrency < 0 || req.Concurrency > maxBatchConcurrency:
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, fmt.Sprintf("concurrency must be between 1 and %d", maxBatchConcurrency))
+		return
+	}
+	for i := range req.Operations {
+		if err := req.Operations[i].validate(); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidBody, fmt.Sprintf("operations[%d]: %v", i, err))
+			return
+		}
+	}
+	var stack *history.Stack
+	if h.proxy.history != nil && h.proxy.dryRun == nil {
+		var err error
+		if stack, err = h.proxy.historyOf(r.Header.Get(ProjectHeader)); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid project ID")
+			return
+		}
+	}
+
+	// A batch that has started runs to the end, so a rollback is not cut off when the browser goes away.
+	writeJSON(w, http.StatusOK, h.run(context.WithoutCancel(r.Context()), req, stack))
+}
+
+// run runs the batch and records the changes that were kept in stack, if not nil.
+func (h *batchHandler) run(ctx context.Context, req batchRequest, stack *history.Stack) batchResponse {
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if h.proxy.dryRun != nil {
+		// apply replays the journal in order, so record the operations in the order of the batch.
+		concurrency = 1
+	}
+
+	results := make([]batchResult, len(req.Operations))
+	changes := make([]*batchChange, len(req.Operations))
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	// last holds, for each issue or item ID, when the latest operation touching it is done.
+	last := make(map[string]chan struct{})
+	for i, op := range req.Operations {
+		results[i] = batchResult{Index: i, Op: op.Op}
+		done := make(chan struct{})
+		var deps []chan struct{}
+		for _, field := range batchOrderedFields {
+			id, ok := op.Input[field].(string)
+			if !ok {
+				continue
+			}
+			if dep, ok := last[id]; ok {
+				deps = append(deps, dep)
+			}
+			last[id] = done
+		}
+
+		sem <- struct{}{}
+		if req.Atomic && failed.Load() {
+			<-sem
+			results[i].Status = batchSkipped
+			close(done)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done)
+			defer func() { <-sem }()
+			// The operations waited for were started earlier and hold a slot, so they finish.
+			for _, dep := range deps {
+				<-dep
+			}
+			if req.Atomic && failed.Load() {
+				results[i].Status = batchSkipped
+				return
+			}
+			change, err := h.execute(ctx, op, req.Atomic)
+			if err != nil {
+				failed.Store(true)
+				results[i].Status, results[i].Error = batchFailed, err.Error()
+				return
+			}
+			results[i].Status, changes[i] = batchSucceeded, change
+		}()
+	}
+	wg.Wait()
+
+	if req.Atomic && failed.Load() {
+		for i := len(changes) - 1; i >= 0; i-- {
+			if changes[i] == nil {
+				continue
+			}
+			if err := h.rollback(ctx, changes[i]); err != nil {
+				results[i].Status, results[i].RollbackError = batchRollbackFailed, err.Error()
+			} else {
+				results[i].Status = batchRolledBack
+			}
+			changes[i] = nil
+		}
+	}
+	pushHistory(stack, req.Operations, changes)
+
+	resp := batchResponse{Results: results}
+	for _, res := range results {
+		switch res.Status {
+		case batchSucceeded:
+			resp.Succeeded++
+		case batchFailed:
+			resp.Failed++
+		case batchRolledBack:
+			resp.RolledBack++
+		}
+	}
+	return resp
+}
+
+// execute sends a single operation. The inverse is looked up beforehand when the
+// batch may have to roll it back or the history records it; an atomic batch does
+// not send an operation it would not be able to revert.
+func (h *batchHandler) execute(ctx context.Context, op batchOperation, atomic bool) (*batchChange, error) {
+	change := &batchChange{do: graphqlMutationRequest(op.Op, op.Input)}
+
+	if d := h.proxy.dryRun; d != nil {
+		entry, err := d.record(change.do, op.Op)
+		if err != nil {
+			return nil, err
+		}
+		change.journalSeq = entry.Seq
+		return change, nil
+	}
+
+	if atomic || h.proxy.history != nil {
+		undo, err := h.proxy.undoRequest(ctx, op.Op, op.Input)
+		switch {
+		case err == nil:
+			change.undo = &undo
+		case atomic:
+			return nil, fmt.Errorf("cannot prepare rollback: %w", err)
+		}
+	}
+	if err := h.proxy.replay(ctx, change.do, audit.SourceBatch); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (h *batchHandler) rollback(ctx context.Context, change *batchChange) error {
+	if d := h.proxy.dryRun; d != nil {
+		return d.journal.Remove(change.journalSeq)
+	}
+	return h.proxy.replay(ctx, *change.undo, audit.SourceRollback)
+}
+
+// pushHistory records the changes that were kept, in the order of the batch, so they
+// can be undone one by one like the changes made through the proxy.
+func pushHistory(stack *history.Stack, ops []batchOperation, changes []*batchChange) {
+	if stack == nil {
+		return
+	}
+	for i, change := range changes {
+		if change == nil || change.undo == nil {
+			continue
+		}
+		step := history.Step{Label: ops[i].Op, Do: change.do, Undo: *change.undo}
+		if _, err := stack.Push(step); err != nil {
+			// The changes already reached GitHub, so the batch still succeeds.
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
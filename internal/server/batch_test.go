@@ -0,0 +1,227 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func serveBatch(t *testing.T, handler *proxyHandler, body string) (int, batchResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	(&batchHandler{proxy: handler}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(body)))
+	var resp batchResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %s: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+func batchStatuses(resp batchResponse) []string {
+	statuses := make([]string, len(resp.Results))
+	for i, res := range resp.Results {
+		statuses[i] = res.Status
+	}
+	return statuses
+}
+
+// failBlocker makes the upstream reject mutations that reference the issue I_bad.
+func failBlocker(r upstreamRequest) (int, string) {
+	if strings.Contains(r.Body, `"I_bad"`) {
+		return http.StatusOK, `{"data":null,"errors":[{"message":"Could not resolve to a node"}]}`
+	}
+	return http.StatusOK, `{"data":{}}`
+}
+
+func TestBatch_ReportsEachOperation(t *testing.T) {
+	handler, stack, received := newHistoryHandler(t, failBlocker)
+
+	status, resp := serveBatch(t, handler, `{"operations":[
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_2"}},
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_bad"}},
+		{"op":"addSubIssue","input":{"issueId":"I_1","subIssueId":"I_3","extra":"dropped"}}
+	]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	want := []string{batchSucceeded, batchFailed, batchSucceeded}
+	if got := batchStatuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if resp.Results[1].Error != "Could not resolve to a node" {
+		t.Errorf("error = %q", resp.Results[1].Error)
+	}
+	if resp.Succeeded != 2 || resp.Failed != 1 || resp.RolledBack != 0 {
+		t.Errorf("summary = %+v", resp)
+	}
+	if len(*received) != 3 {
+		t.Fatalf("upstream received %d requests, want 3", len(*received))
+	}
+	for _, r := range *received {
+		if strings.Contains(r.Body, "extra") {
+			t.Errorf("unexpected input field forwarded: %s", r.Body)
+		}
+	}
+
+	// Kept changes can be undone one by one, in the order of the batch.
+	undo, _ := stack.Steps()
+	if len(undo) != 2 || undo[0].Label != "addSubIssue" || undo[1].Label != "addBlockedBy" {
+		t.Errorf("history = %+v", undo)
+	}
+}
+
+func TestBatch_AtomicRollsBack(t *testing.T) {
+	handler, stack, received := newHistoryHandler(t, failBlocker)
+
+	status, resp := serveBatch(t, handler, `{"atomic":true,"concurrency":1,"operations":[
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_2"}},
+		{"op":"removeSubIssue","input":{"issueId":"I_1","subIssueId":"I_3"}},
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_bad"}},
+		{"op":"addSubIssue","input":{"issueId":"I_1","subIssueId":"I_4"}}
+	]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	want := []string{batchRolledBack, batchRolledBack, batchFailed, batchSkipped}
+	if got := batchStatuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if resp.RolledBack != 2 || resp.Failed != 1 || resp.Succeeded != 0 {
+		t.Errorf("summary = %+v", resp)
+	}
+
+	// 3 operations were sent, then the two that succeeded were reverted newest first.
+	if len(*received) != 5 {
+		t.Fatalf("upstream received %d requests, want 5", len(*received))
+	}
+	wantRollback := []string{"addSubIssue", "removeBlockedBy"}
+	for i, r := range (*received)[3:] {
+		query, vars := graphqlVariablesOf(t, r.Body)
+		if !strings.Contains(query, wantRollback[i]+"(input") {
+			t.Errorf("rollback %d query = %s, want %s", i, query, wantRollback[i])
+		}
+		if input := vars["input"].(map[string]any); input["issueId"] != "I_1" {
+			t.Errorf("rollback %d input = %v", i, input)
+		}
+	}
+	if undo, _ := stack.Steps(); len(undo) != 0 {
+		t.Errorf("history = %+v, want no steps after rollback", undo)
+	}
+}
+
+func TestBatch_AtomicReportsFailedRollback(t *testing.T) {
+	handler, _, _ := newHistoryHandler(t, func(r upstreamRequest) (int, string) {
+		if strings.Contains(r.Body, "removeBlockedBy") {
+			return http.StatusBadGateway, `{"message":"Server Error"}`
+		}
+		return failBlocker(r)
+	})
+
+	_, resp := serveBatch(t, handler, `{"atomic":true,"concurrency":1,"operations":[
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_2"}},
+		{"op":"addBlockedBy","input":{"issueId":"I_1","blockingIssueId":"I_bad"}}
+	]}`)
+	if got := resp.Results[0]; got.Status != batchRollbackFailed || got.RollbackError != "Server Error" {
+		t.Errorf("result = %+v", got)
+	}
+}
+
+func TestBatch_OrdersOperationsOnTheSameIssue(t *testing.T) {
+	// Like GitHub, the upstream rejects adding a sub-issue that still has a parent.
+	var removed, independentWaited atomic.Bool
+	handler, _, _ := newHistoryHandler(t, func(r upstreamRequest) (int, string) {
+		switch {
+		case strings.Contains(r.Body, "removeSubIssue"):
+			time.Sleep(50 * time.Millisecond)
+			removed.Store(true)
+		case strings.Contains(r.Body, "addSubIssue") && !removed.Load():
+			return http.StatusOK, `{"data":null,"errors":[{"message":"Issue may only have one parent"}]}`
+		case strings.Contains(r.Body, "addBlockedBy") && removed.Load():
+			independentWaited.Store(true)
+		}
+		return http.StatusOK, `{"data":{}}`
+	})
+
+	status, resp := serveBatch(t, handler, `{"operations":[
+		{"op":"removeSubIssue","input":{"issueId":"I_old","subIssueId":"I_x"}},
+		{"op":"addSubIssue","input":{"issueId":"I_new","subIssueId":"I_x"}},
+		{"op":"addBlockedBy","input":{"issueId":"I_5","blockingIssueId":"I_6"}}
+	]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	want := []string{batchSucceeded, batchSucceeded, batchSucceeded}
+	if got := batchStatuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("statuses = %v, want %v (results: %+v)", got, want, resp.Results)
+	}
+	if independentWaited.Load() {
+		t.Errorf("an operation on other issues waited for the sub-issue move")
+	}
+}
+
+func TestBatch_DryRun(t *testing.T) {
+	handler, j, called := newDryRunHandler(t)
+
+	_, resp := serveBatch(t, handler, `{"operations":[
+		{"op":"addSubIssue","input":{"issueId":"I_1","subIssueId":"I_2"}},
+		{"op":"updateProjectV2ItemFieldValue","input":{"projectId":"P_1","itemId":"PVTI_1","fieldId":"F_1","value":{"number":3}}}
+	]}`)
+	if resp.Succeeded != 2 {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+	if *called {
+		t.Error("upstream should not be called in dry-run mode")
+	}
+	entries, _ := j.List()
+	if len(entries) != 2 || entries[0].Operation != "addSubIssue" || entries[1].Operation != "updateProjectV2ItemFieldValue" {
+		t.Fatalf("journal = %+v", entries)
+	}
+	_, vars := graphqlVariablesOf(t, string(entries[1].Body))
+	if value := vars["input"].(map[string]any)["value"]; value.(map[string]any)["number"] != float64(3) {
+		t.Errorf("value = %v", value)
+	}
+}
+
+func TestBatch_RejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not JSON", body: `operations`},
+		{name: "no operations", body: `{"operations":[]}`},
+		{name: "unsupported op", body: `{"operations":[{"op":"deleteIssue","input":{"issueId":"I_1"}}]}`},
+		{name: "missing input field", body: `{"operations":[{"op":"addSubIssue","input":{"issueId":"I_1"}}]}`},
+		{name: "field update without value", body: `{"operations":[{"op":"updateProjectV2ItemFieldValue","input":{"projectId":"P","itemId":"I","fieldId":"F"}}]}`},
+		{name: "concurrency too high", body: `{"concurrency":100,"operations":[{"op":"addSubIssue","input":{"issueId":"I_1","subIssueId":"I_2"}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, received := newHistoryHandler(t, failBlocker)
+			if status, _ := serveBatch(t, handler, tt.body); status != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", status)
+			}
+			if len(*received) != 0 {
+				t.Errorf("upstream received %d requests", len(*received))
+			}
+		})
+	}
+}
+
+func TestBatch_ReadOnly(t *testing.T) {
+	handler, _, received := newHistoryHandler(t, failBlocker)
+	handler.readOnly = true
+
+	status, _ := serveBatch(t, handler, `{"operations":[{"op":"addSubIssue","input":{"issueId":"I_1","subIssueId":"I_2"}}]}`)
+	if status != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", status)
+	}
+	if len(*received) != 0 {
+		t.Errorf("upstream received %d requests", len(*received))
+	}
+}
@@ -2,13 +2,23 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
 )
 
+// maxCacheBodyBytes はキャッシュ API が受け付けるリクエストボディの上限。
+// 数千件規模のプロジェクトの items が収まる大きさにしている。
+const maxCacheBodyBytes = 32 << 20
+
+// cacheHandler はプロジェクトごとのキャッシュを読み書きする。
+// GET はキャッシュのリビジョンを ETag で返し、書き込みは If-Match でリビジョンを指定すると、
+// その間に他のタブや sync コマンドが書き込んでいた場合に 412 で拒否する。
 type cacheHandler struct {
 	store *cache.Store
 }
@@ -17,64 +27,160 @@ func (h *cacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Strip "/api/cache/" prefix to get "<projectId>" or "<projectId>/sub"
 	path := strings.TrimPrefix(r.URL.Path, "/api/cache/")
 	if path == "" {
-		http.Error(w, "missing project ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "missing project ID")
 		return
 	}
 
 	// POST /api/cache/flush — 全キャッシュをディスクに書き出す
 	if path == "flush" && r.Method == http.MethodPost {
-		h.store.FlushAll()
+		if err := h.store.FlushAll(); err != nil {
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "failed to flush cache")
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
 	projectID, sub, _ := strings.Cut(path, "/")
 	if projectID == "" {
-		http.Error(w, "missing project ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "missing project ID")
+		return
+	}
+	// projectID はキャッシュファイル名になるため、ProjectV2 のノード ID 以外は受け付けない
+	if !github.IsProjectID(projectID) {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid project ID")
 		return
 	}
 
 	switch {
 	case sub == "" && r.Method == http.MethodGet:
-		h.handleGet(w, projectID)
+		h.handleGet(w, r, projectID)
 	case sub == "items" && r.Method == http.MethodPut:
 		h.handlePutItems(w, r, projectID)
 	case sub == "items" && r.Method == http.MethodDelete:
-		h.handleDeleteItems(w, projectID)
+		h.handleDeleteItems(w, r, projectID)
 	case sub == "node-positions" && r.Method == http.MethodPut:
 		h.handlePutNodePositions(w, r, projectID)
 	default:
-		http.Error(w, "not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 	}
 }
 
-func (h *cacheHandler) handleGet(w http.ResponseWriter, projectID string) {
+func (h *cacheHandler) handleGet(w http.ResponseWriter, r *http.Request, projectID string) {
 	c := h.store.GetCache(projectID)
+	w.Header().Set("ETag", revisionETag(c.Revision))
+	if match, ok := parseIfMatch(r.Header.Get("If-None-Match")); ok && match == c.Revision {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(c)
 }
 
 func (h *cacheHandler) handlePutItems(w http.ResponseWriter, r *http.Request, projectID string) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "failed to read body", http.StatusBadRequest)
+	revision, ok := ifMatchRevision(w, r)
+	if !ok {
 		return
 	}
-	h.store.SetItems(projectID, json.RawMessage(body))
-	w.WriteHeader(http.StatusNoContent)
+	body, ok := readBody(w, r, maxCacheBodyBytes)
+	if !ok {
+		return
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil || items == nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "items must be a JSON array")
+		return
+	}
+	current, err := h.store.SetItemsIfRevision(projectID, json.RawMessage(body), revision)
+	writeRevisionResult(w, current, err)
 }
 
-func (h *cacheHandler) handleDeleteItems(w http.ResponseWriter, projectID string) {
-	h.store.DeleteItems(projectID)
-	w.WriteHeader(http.StatusNoContent)
+func (h *cacheHandler) handleDeleteItems(w http.ResponseWriter, r *http.Request, projectID string) {
+	revision, ok := ifMatchRevision(w, r)
+	if !ok {
+		return
+	}
+	current, err := h.store.DeleteItemsIfRevision(projectID, revision)
+	writeRevisionResult(w, current, err)
 }
 
 func (h *cacheHandler) handlePutNodePositions(w http.ResponseWriter, r *http.Request, projectID string) {
+	revision, ok := ifMatchRevision(w, r)
+	if !ok {
+		return
+	}
+	body, ok := readBody(w, r, maxCacheBodyBytes)
+	if !ok {
+		return
+	}
 	var positions map[string]cache.NodePosition
-	if err := json.NewDecoder(r.Body).Decode(&positions); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	if err := json.Unmarshal(body, &positions); err != nil || positions == nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "node positions must be a JSON object")
+		return
+	}
+	current, err := h.store.MergeNodePositionsIfRevision(projectID, positions, revision)
+	writeRevisionResult(w, current, err)
+}
+
+// ifMatchRevision は If-Match ヘッダーから書き込み先に期待するリビジョンを返す。
+// ヘッダーがないか "*" の場合は cache.AnyRevision を返す。
+// リビジョンとして読めない場合は、どのリビジョンにも一致しないものとして 412 を書き込み false を返す。
+func ifMatchRevision(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return cache.AnyRevision, true
+	}
+	revision, ok := parseIfMatch(v)
+	if !ok {
+		writeError(w, http.StatusPreconditionFailed, errCodePrecondition, "If-Match must be a cache revision ETag")
+		return 0, false
+	}
+	return revision, true
+}
+
+// parseIfMatch は revisionETag の形式の ETag をリビジョンに変換する。
+func parseIfMatch(v string) (int64, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+	unquoted, ok := strings.CutPrefix(v, `"`)
+	if !ok {
+		return 0, false
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, false
+	}
+	revision, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, false
+	}
+	return revision, true
+}
+
+func revisionETag(revision int64) string {
+	return `"` + strconv.FormatInt(revision, 10) + `"`
+}
+
+// writeRevisionResult は書き込みの結果を書き込む。成功時も競合時も現在のリビジョンを ETag で返す。
+func writeRevisionResult(w http.ResponseWriter, revision int64, err error) {
+	w.Header().Set("ETag", revisionETag(revision))
+	if errors.Is(err, cache.ErrRevisionMismatch) {
+		writeError(w, http.StatusPreconditionFailed, errCodePrecondition, "cache was modified by another writer")
 		return
 	}
-	h.store.MergeNodePositions(projectID, positions)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// readBody は上限付きでリクエストボディを読み込む。失敗した場合はエラーレスポンスを書き込み false を返す。
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, errCodeBodyTooLarge, "request body too large")
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "failed to read body")
+		return nil, false
+	}
+	return body, true
+}
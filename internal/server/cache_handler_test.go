@@ -18,7 +18,7 @@ func setupHandler(t *testing.T) (*cacheHandler, *cache.Store) {
 
 func TestGetCache_Empty(t *testing.T) {
 	h, _ := setupHandler(t)
-	req := httptest.NewRequest(http.MethodGet, "/api/cache/proj-1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/cache/PVT_proj1", nil)
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 
@@ -40,14 +40,14 @@ func TestGetCache_Empty(t *testing.T) {
 func TestPutItems(t *testing.T) {
 	h, store := setupHandler(t)
 	body := `[{"id":1},{"id":2}]`
-	req := httptest.NewRequest(http.MethodPut, "/api/cache/proj-1/items", strings.NewReader(body))
+	req := httptest.NewRequest(http.MethodPut, "/api/cache/PVT_proj1/items", strings.NewReader(body))
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusNoContent {
 		t.Fatalf("expected 204, got %d", w.Code)
 	}
-	c := store.GetCache("proj-1")
+	c := store.GetCache("PVT_proj1")
 	if string(c.Items) != body {
 		t.Fatalf("expected %s, got %s", body, c.Items)
 	}
@@ -55,16 +55,16 @@ func TestPutItems(t *testing.T) {
 
 func TestDeleteItems(t *testing.T) {
 	h, store := setupHandler(t)
-	store.SetItems("proj-1", json.RawMessage(`[1,2]`))
+	store.SetItems("PVT_proj1", json.RawMessage(`[1,2]`))
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/cache/proj-1/items", nil)
+	req := httptest.NewRequest(http.MethodDelete, "/api/cache/PVT_proj1/items", nil)
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusNoContent {
 		t.Fatalf("expected 204, got %d", w.Code)
 	}
-	c := store.GetCache("proj-1")
+	c := store.GetCache("PVT_proj1")
 	if c.Items != nil {
 		t.Fatalf("expected nil items, got %s", c.Items)
 	}
@@ -73,19 +73,19 @@ func TestDeleteItems(t *testing.T) {
 func TestPutNodePositions(t *testing.T) {
 	h, store := setupHandler(t)
 	// set initial positions
-	store.MergeNodePositions("proj-1", map[string]cache.NodePosition{
+	store.MergeNodePositions("PVT_proj1", map[string]cache.NodePosition{
 		"node-1": {X: 10, Y: 20},
 	})
 
 	body := `{"node-1":{"x":99,"y":99},"node-2":{"x":50,"y":60}}`
-	req := httptest.NewRequest(http.MethodPut, "/api/cache/proj-1/node-positions", strings.NewReader(body))
+	req := httptest.NewRequest(http.MethodPut, "/api/cache/PVT_proj1/node-positions", strings.NewReader(body))
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusNoContent {
 		t.Fatalf("expected 204, got %d", w.Code)
 	}
-	c := store.GetCache("proj-1")
+	c := store.GetCache("PVT_proj1")
 	if pos := c.NodePositions["node-1"]; pos.X != 99 || pos.Y != 99 {
 		t.Fatalf("expected (99,99), got %v", pos)
 	}
@@ -98,17 +98,17 @@ func TestGetAfterPut(t *testing.T) {
 	h, _ := setupHandler(t)
 
 	// PUT items
-	putReq := httptest.NewRequest(http.MethodPut, "/api/cache/proj-1/items", strings.NewReader(`[{"x":1}]`))
+	putReq := httptest.NewRequest(http.MethodPut, "/api/cache/PVT_proj1/items", strings.NewReader(`[{"x":1}]`))
 	putW := httptest.NewRecorder()
 	h.ServeHTTP(putW, putReq)
 
 	// PUT node-positions
-	posReq := httptest.NewRequest(http.MethodPut, "/api/cache/proj-1/node-positions", strings.NewReader(`{"n1":{"x":5,"y":6}}`))
+	posReq := httptest.NewRequest(http.MethodPut, "/api/cache/PVT_proj1/node-positions", strings.NewReader(`{"n1":{"x":5,"y":6}}`))
 	posW := httptest.NewRecorder()
 	h.ServeHTTP(posW, posReq)
 
 	// GET
-	getReq := httptest.NewRequest(http.MethodGet, "/api/cache/proj-1", nil)
+	getReq := httptest.NewRequest(http.MethodGet, "/api/cache/PVT_proj1", nil)
 	getW := httptest.NewRecorder()
 	h.ServeHTTP(getW, getReq)
 
@@ -124,7 +124,7 @@ func TestGetAfterPut(t *testing.T) {
 
 func TestNotFound(t *testing.T) {
 	h, _ := setupHandler(t)
-	req := httptest.NewRequest(http.MethodGet, "/api/cache/proj-1/unknown", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/cache/PVT_proj1/unknown", nil)
 	w := httptest.NewRecorder()
 	h.ServeHTTP(w, req)
 
@@ -143,3 +143,105 @@ func TestMissingProjectID(t *testing.T) {
 		t.Fatalf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestCacheHandler_RejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "path traversal", method: http.MethodPut, path: "/api/cache/..%2F..%2Fetc/items", body: `[]`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidProjectID},
+		{name: "dot segments", method: http.MethodGet, path: "/api/cache/../secrets", wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidProjectID},
+		{name: "not a ProjectV2 ID", method: http.MethodGet, path: "/api/cache/I_kwDOissue", wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidProjectID},
+		{name: "missing project ID", method: http.MethodGet, path: "/api/cache/", wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidProjectID},
+		{name: "items object", method: http.MethodPut, path: "/api/cache/PVT_proj1/items", body: `{"id":1}`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidBody},
+		{name: "items null", method: http.MethodPut, path: "/api/cache/PVT_proj1/items", body: `null`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidBody},
+		{name: "items invalid JSON", method: http.MethodPut, path: "/api/cache/PVT_proj1/items", body: `[{`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidBody},
+		{name: "items too large", method: http.MethodPut, path: "/api/cache/PVT_proj1/items", body: "[" + strings.Repeat(" ", maxCacheBodyBytes) + "]", wantStatus: http.StatusRequestEntityTooLarge, wantCode: errCodeBodyTooLarge},
+		{name: "node positions array", method: http.MethodPut, path: "/api/cache/PVT_proj1/node-positions", body: `[]`, wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidBody},
+		{name: "unknown sub-resource", method: http.MethodGet, path: "/api/cache/PVT_proj1/unknown", wantStatus: http.StatusNotFound, wantCode: errCodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := setupHandler(t)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected JSON content type, got %q", ct)
+			}
+			var env errorEnvelope
+			if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+				t.Fatalf("expected JSON error envelope, got %q", w.Body.String())
+			}
+			if env.Error.Code != tt.wantCode || env.Error.Message == "" {
+				t.Errorf("unexpected error envelope: %+v", env)
+			}
+			if c := store.GetCache("PVT_proj1"); c.Items != nil {
+				t.Errorf("expected items to be untouched, got %s", c.Items)
+			}
+		})
+	}
+}
+
+func TestCacheHandler_Revisions(t *testing.T) {
+	h, store := setupHandler(t)
+	store.SetItems("PVT_proj1", json.RawMessage(`[1]`))
+
+	serve := func(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/cache/PVT_proj1", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected 200 with ETag \"1\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := serve(http.MethodGet, "/api/cache/PVT_proj1", "", map[string]string{"If-None-Match": `"1"`}); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+
+	w = serve(http.MethodPut, "/api/cache/PVT_proj1/node-positions", `{"n1":{"x":1,"y":2}}`, map[string]string{"If-Match": `"1"`})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected 204 with ETag \"2\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	// 別のタブが古いリビジョンのまま書き込むと 412 になり、現在のリビジョンが返る
+	w = serve(http.MethodPut, "/api/cache/PVT_proj1/items", `[2]`, map[string]string{"If-Match": `"1"`})
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected 412 with ETag \"2\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if !strings.Contains(w.Body.String(), `"precondition_failed"`) {
+		t.Fatalf("expected precondition_failed, got %s", w.Body)
+	}
+	if w := serve(http.MethodDelete, "/api/cache/PVT_proj1/items", "", map[string]string{"If-Match": `"1"`}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", w.Code)
+	}
+	if w := serve(http.MethodPut, "/api/cache/PVT_proj1/items", `[2]`, map[string]string{"If-Match": `abc`}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for malformed If-Match, got %d", w.Code)
+	}
+	if c := store.GetCache("PVT_proj1"); string(c.Items) != `[1]` {
+		t.Fatalf("expected stale writes to be rejected, got %s", c.Items)
+	}
+
+	// If-Match なしと "*" は無条件に書き込む
+	if w := serve(http.MethodPut, "/api/cache/PVT_proj1/items", `[3]`, map[string]string{"If-Match": "*"}); w.Code != http.StatusNoContent || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("expected 204 with ETag \"3\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := serve(http.MethodDelete, "/api/cache/PVT_proj1/items", "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+}
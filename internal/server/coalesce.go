@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// coalescer is a transport that shares one upstream call between identical read
+// requests that are in flight at the same time, such as the project field list or
+// the viewer asked for by several components at once. Requests are identical when
+// they have the same method, URL, Accept headers and body; only REST GET and HEAD
+// and GraphQL documents without mutations are shared.
+type coalescer struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	flights map[string]*flight
+
+	// requests counts the requests that could be shared and shared those that were.
+	requests atomic.Int64
+	shared   atomic.Int64
+}
+
+// flight is an upstream call and its buffered response, shared by the requests waiting for it.
+type flight struct {
+	done   chan struct{}
+	resp   *http.Response
+	body   []byte
+	err    error
+	shared bool
+}
+
+func newCoalescer(next http.RoundTripper) *coalescer {
+	return &coalescer{next: next, flights: make(map[string]*flight)}
+}
+
+func (c *coalescer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := coalesceKey(req)
+	if !ok {
+		return c.next.RoundTrip(req)
+	}
+	c.requests.Add(1)
+
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		f.shared = true
+		c.mu.Unlock()
+		c.shared.Add(1)
+		select {
+		case <-f.done:
+			return f.response(req)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	// The call is shared, so it is not canceled when the request that started it goes away.
+	resp, err := c.next.RoundTrip(req.WithContext(context.WithoutCancel(req.Context())))
+
+	c.mu.Lock()
+	delete(c.flights, key)
+	shared := f.shared
+	c.mu.Unlock()
+	// No request can join once the flight is removed, so when none did the
+	// response is streamed as usual.
+	if !shared || err != nil {
+		f.err = err
+		close(f.done)
+		return resp, err
+	}
+
+	f.body, f.err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	f.resp = resp
+	close(f.done)
+	return f.response(req)
+}
+
+// response returns a copy of the shared response for req.
+func (f *flight) response(req *http.Request) (*http.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(f.body))
+	resp.ContentLength = int64(len(f.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+// coalesceKey returns the key identical requests share, or false if req must not be
+// shared. It buffers the body of GraphQL requests and puts it back.
+func coalesceKey(req *http.Request) (string, bool) {
+	if req.Header.Get("Range") != "" {
+		return "", false
+	}
+	key := req.Method + " " + req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Accept-Encoding")
+	switch {
+	case req.Method == http.MethodGet || req.Method == http.MethodHead:
+		return key, true
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/graphql") && req.Body != nil:
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxInspectedBodyBytes+1))
+		rest := req.Body
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), rest), rest}
+		if err != nil || len(body) > maxInspectedBodyBytes || !isGraphQLQuery(body) {
+			return "", false
+		}
+		return key + "\n" + string(body), true
+	}
+	return "", false
+}
+
+// isGraphQLQuery reports whether a GraphQL request body only runs queries.
+func isGraphQLQuery(body []byte) bool {
+	var req struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return false
+	}
+	types, err := graphqlOperationTypes(req.Query)
+	if err != nil {
+		return false
+	}
+	for _, t := range types {
+		if t != "query" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_SharesIdenticalRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		b, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"echo":` + string(b) + `}`))
+	}))
+	t.Cleanup(mock.Close)
+	handler := newMockHandler(t, mock, "")
+
+	const n = 5
+	body := `{"query":"query { viewer { login } }"}`
+	var wg sync.WaitGroup
+	responses := make([]string, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+			responses[i] = w.Body.String()
+		}()
+	}
+
+	// Let the other requests join the first one before GitHub answers.
+	deadline := time.Now().Add(5 * time.Second)
+	for handler.coalesce.shared.Load() < n-1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+	for i, resp := range responses {
+		if resp != `{"echo":`+body+`}` {
+			t.Errorf("response %d = %s", i, resp)
+		}
+	}
+	if got := handler.coalesce.requests.Load(); got != n {
+		t.Errorf("requests = %d, want %d", got, n)
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   bool
+	}{
+		{name: "REST GET", method: http.MethodGet, path: "/user", want: true},
+		{name: "REST POST", method: http.MethodPost, path: "/repos/o/r/issues", body: `{"title":"x"}`},
+		{name: "GraphQL query", method: http.MethodPost, path: "/graphql", body: `{"query":"{ viewer { login } }"}`, want: true},
+		{name: "GraphQL mutation", method: http.MethodPost, path: "/graphql", body: `{"query":"mutation { addSubIssue(input: {}) { clientMutationId } }"}`},
+		{name: "invalid GraphQL", method: http.MethodPost, path: "/graphql", body: `{"query":"{"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://github"+tt.path, strings.NewReader(tt.body))
+			if _, got := coalesceKey(req); got != tt.want {
+				t.Errorf("coalesceKey = %v, want %v", got, tt.want)
+			}
+			// The body is still there to be sent.
+			if b, _ := io.ReadAll(req.Body); string(b) != tt.body {
+				t.Errorf("body = %q, want %q", b, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// consoleConfig は GET /api/config のレスポンス。フロントエンドはこれを見て表示を切り替える。
+type consoleConfig struct {
+	ReadOnly bool `json:"readOnly"`
+	// DryRun は書き込みを GitHub に送らずジャーナルに記録するモードかどうか。
+	DryRun bool `json:"dryRun"`
+}
+
+type configHandler struct {
+	readOnly bool
+	dryRun   bool
+}
+
+func (h *configHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(consoleConfig{ReadOnly: h.readOnly, DryRun: h.dryRun})
+}
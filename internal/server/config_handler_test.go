@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigHandler(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		dryRun := !readOnly
+		h := &configHandler{readOnly: readOnly, dryRun: dryRun}
+		req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var got consoleConfig
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if got.ReadOnly != readOnly {
+			t.Errorf("readOnly = %v, want %v", got.ReadOnly, readOnly)
+		}
+		if got.DryRun != dryRun {
+			t.Errorf("dryRun = %v, want %v", got.DryRun, dryRun)
+		}
+	}
+}
+
+func TestConfigHandler_MethodNotAllowed(t *testing.T) {
+	h := &configHandler{}
+	req := httptest.NewRequest(http.MethodPost, "/api/config", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+	"github.com/kmtym1998/gh-issue-treefier/internal/journal"
+)
+
+// dryRun records write requests to the GitHub API in a journal instead of
+// forwarding them, and answers them with synthesized payloads so the UI keeps working.
+//
+// Synthesized IDs and issue numbers are journal placeholders. They are recorded
+// with the entry so `apply` can replace them with the real values once the
+// request that created them has been sent.
+type dryRun struct {
+	journal *journal.Journal
+
+	mu sync.Mutex
+	// issues holds the issues created during the session, keyed by their REST path
+	// (repos/{owner}/{repo}/issues/{number}), so the UI can fetch them back.
+	issues map[string]map[string]any
+}
+
+func newDryRun(j *journal.Journal) *dryRun {
+	return &dryRun{journal: j, issues: make(map[string]map[string]any)}
+}
+
+// serveSyntheticIssue answers GET requests for issues created during the session.
+// It reports false when path is not such an issue.
+func (d *dryRun) serveSyntheticIssue(w http.ResponseWriter, path string) bool {
+	d.mu.Lock()
+	issue, ok := d.issues[path]
+	d.mu.Unlock()
+	if !ok {
+		return false
+	}
+	writeJSON(w, http.StatusOK, issue)
+	return true
+}
+
+// serveREST records a REST write request and answers it.
+//
+// Creating an issue returns an issue with placeholder id, node_id and number.
+// DELETE returns 204. Other requests echo the request body, merged into the
+// synthesized issue when they update one.
+func (d *dryRun) serveREST(w http.ResponseWriter, r *http.Request, path string) {
+	body, ok := readBody(w, r, maxInspectedBodyBytes)
+	if !ok {
+		return
+	}
+	fields := map[string]any{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidBody, "request body must be a JSON object")
+			return
+		}
+	}
+
+	entry := journal.Entry{
+		API:       journal.APIREST,
+		Method:    r.Method,
+		Path:      path,
+		Body:      body,
+		Operation: r.Method + " " + path,
+	}
+	status := http.StatusOK
+	var resp any = fields
+
+	segments := strings.Split(path, "/")
+	isIssues := len(segments) >= 4 && segments[0] == "repos" && segments[3] == "issues"
+	switch {
+	case r.Method == http.MethodDelete:
+		status, resp = http.StatusNoContent, nil
+	case r.Method == http.MethodPost && isIssues && len(segments) == 4:
+		number, id, nodeID := d.journal.NewIssueNumber(), d.journal.NewDatabaseID(), d.journal.NewNodeID()
+		issue := maps.Clone(fields)
+		issue["id"] = id
+		issue["node_id"] = nodeID
+		issue["number"] = number
+		issue["state"] = "open"
+		issue["html_url"] = fmt.Sprintf("https://github.com/%s/%s/issues/%d", segments[1], segments[2], number)
+		entry.Placeholders = map[string]string{
+			strconv.FormatInt(number, 10): "number",
+			strconv.FormatInt(id, 10):     "id",
+			nodeID:                        "node_id",
+		}
+
+		d.mu.Lock()
+		d.issues[path+"/"+strconv.FormatInt(number, 10)] = issue
+		d.mu.Unlock()
+		status, resp = http.StatusCreated, issue
+	case isIssues && len(segments) == 5:
+		d.mu.Lock()
+		if issue, ok := d.issues[path]; ok {
+			maps.Copy(issue, fields)
+			resp = maps.Clone(issue)
+		} else if number, err := strconv.Atoi(segments[4]); err == nil {
+			fields["number"] = number
+		}
+		d.mu.Unlock()
+	case r.Method == http.MethodPost:
+		status = http.StatusCreated
+	}
+
+	if _, err := d.journal.Append(entry); err != nil {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "failed to record request")
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, resp)
+}
+
+// serveGraphQL records a GraphQL mutation and answers it with a payload shaped
+// like the selection set: every "id" field gets a placeholder and every other
+// leaf is null.
+func (d *dryRun) serveGraphQL(w http.ResponseWriter, body []byte, op graphqlOperation) {
+	placeholders := map[string]string{}
+	data := map[string]any{}
+	for _, f := range op.Selections {
+		data[f.ResponseKey()] = d.synthesize(f, f.ResponseKey(), placeholders)
+	}
+
+	entry := journal.Entry{
+		API:       journal.APIGraphQL,
+		Body:      body,
+		Operation: op.fieldNames(),
+	}
+	if len(placeholders) > 0 {
+		entry.Placeholders = placeholders
+	}
+	if _, err := d.journal.Append(entry); err != nil {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "failed to record request")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+// record records a GraphQL mutation the server builds itself, such as an operation of a batch.
+func (d *dryRun) record(req history.Request, operation string) (journal.Entry, error) {
+	return d.journal.Append(journal.Entry{API: journal.APIGraphQL, Body: req.Body, Operation: operation})
+}
+
+func (d *dryRun) synthesize(f graphqlField, path string, placeholders map[string]string) any {
+	if len(f.Selections) == 0 {
+		if f.Name != "id" {
+			return nil
+		}
+		id := d.journal.NewNodeID()
+		placeholders[id] = path
+		return id
+	}
+	obj := make(map[string]any, len(f.Selections))
+	for _, child := range f.Selections {
+		key := child.ResponseKey()
+		obj[key] = d.synthesize(child, path+"."+key, placeholders)
+	}
+	return obj
+}
+
+// dryRunJournalHandler serves the journal of the dry-run session.
+// GET lists the recorded requests and DELETE discards them.
+type dryRunJournalHandler struct {
+	journal *journal.Journal
+}
+
+func (h *dryRunJournalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := h.journal.List()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "failed to read journal")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	case http.MethodDelete:
+		if err := h.journal.Clear(); err != nil {
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "failed to clear journal")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/journal"
+)
+
+// newDryRunHandler creates a dry-run proxyHandler. The returned flag reports
+// whether the upstream was called.
+func newDryRunHandler(t *testing.T) (*proxyHandler, *journal.Journal, *bool) {
+	t.Helper()
+	var called bool
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}}}`))
+	}))
+	t.Cleanup(mock.Close)
+
+	j, err := journal.Open(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("journal.Open: %v", err)
+	}
+	handler := newMockHandler(t, mock, "")
+	handler.dryRun = newDryRun(j)
+	return handler, j, &called
+}
+
+func TestDryRun_CreateIssue(t *testing.T) {
+	handler, j, called := newDryRunHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/github/rest/repos/owner/repo/issues", strings.NewReader(`{"title":"New"}`))
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (body: %s)", w.Code, w.Body.String())
+	}
+	if *called {
+		t.Fatal("write request was forwarded upstream")
+	}
+	var created struct {
+		ID      int64  `json:"id"`
+		NodeID  string `json:"node_id"`
+		Number  int64  `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if created.ID == 0 || created.Number == 0 || !journal.IsPlaceholder(created.NodeID) || created.Title != "New" {
+		t.Errorf("unexpected synthesized issue: %+v", created)
+	}
+
+	entries, _ := j.List()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 journal entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.API != journal.APIREST || e.Method != http.MethodPost || e.Path != "repos/owner/repo/issues" || string(e.Body) != `{"title":"New"}` {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Placeholders[created.NodeID] != "node_id" || len(e.Placeholders) != 3 {
+		t.Errorf("placeholders = %v", e.Placeholders)
+	}
+
+	// The created issue can be fetched back and reflects later updates.
+	path := "/api/github/rest/repos/owner/repo/issues/" + strconv.FormatInt(created.Number, 10)
+	w = httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodPatch, path, strings.NewReader(`{"state":"closed"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if *called {
+		t.Fatal("GET of a synthesized issue was forwarded upstream")
+	}
+	if !strings.Contains(w.Body.String(), `"state":"closed"`) || !strings.Contains(w.Body.String(), created.NodeID) {
+		t.Errorf("GET body = %s", w.Body.String())
+	}
+}
+
+func TestDryRun_REST(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "sub-issue", method: http.MethodPost, path: "repos/o/r/issues/1/sub_issues", body: `{"sub_issue_id":42}`, wantStatus: http.StatusCreated, wantBody: `"sub_issue_id":42`},
+		{name: "update issue", method: http.MethodPatch, path: "repos/o/r/issues/3", body: `{"title":"x"}`, wantStatus: http.StatusOK, wantBody: `"number":3`},
+		{name: "delete", method: http.MethodDelete, path: "repos/o/r/issues/1/sub_issue", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, j, called := newDryRunHandler(t)
+			req := httptest.NewRequest(tt.method, "/api/github/rest/"+tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeRESTProxy(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if *called {
+				t.Fatal("write request was forwarded upstream")
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+			entries, _ := j.List()
+			if len(entries) != 1 || entries[0].Path != tt.path || entries[0].Method != tt.method {
+				t.Errorf("unexpected entries: %+v", entries)
+			}
+		})
+	}
+}
+
+func TestDryRun_RESTReadIsForwarded(t *testing.T) {
+	handler, j, called := newDryRunHandler(t)
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/repos/o/r/issues/1", nil))
+
+	if !*called {
+		t.Fatal("GET was not forwarded upstream")
+	}
+	if entries, _ := j.List(); len(entries) != 0 {
+		t.Errorf("GET was recorded: %+v", entries)
+	}
+}
+
+func TestDryRun_GraphQLMutation(t *testing.T) {
+	handler, j, called := newDryRunHandler(t)
+
+	body := `{"query":"mutation AddProjectItem($p: ID!, $c: ID!) { added: addProjectV2ItemById(input: {projectId: $p, contentId: $c}) { item { id } clientMutationId } }","variables":{"p":"PVT_1","c":"I_1"}}`
+	w := httptest.NewRecorder()
+	handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (body: %s)", w.Code, w.Body.String())
+	}
+	if *called {
+		t.Fatal("mutation was forwarded upstream")
+	}
+	var resp struct {
+		Data struct {
+			Added struct {
+				Item struct {
+					ID string `json:"id"`
+				} `json:"item"`
+				ClientMutationID *string `json:"clientMutationId"`
+			} `json:"added"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	itemID := resp.Data.Added.Item.ID
+	if !journal.IsPlaceholder(itemID) || resp.Data.Added.ClientMutationID != nil {
+		t.Errorf("unexpected synthesized payload: %s", w.Body.String())
+	}
+
+	entries, _ := j.List()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 journal entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.API != journal.APIGraphQL || e.Operation != "addProjectV2ItemById" || string(e.Body) != body {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.Placeholders[itemID] != "added.item.id" {
+		t.Errorf("placeholders = %v", e.Placeholders)
+	}
+}
+
+func TestDryRun_GraphQLQueryIsForwarded(t *testing.T) {
+	handler, j, called := newDryRunHandler(t)
+
+	body := `{"query":"query V { viewer { login } } mutation M { x }","operationName":"V"}`
+	w := httptest.NewRecorder()
+	handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || !*called {
+		t.Fatalf("query was not forwarded: status %d", w.Code)
+	}
+	if entries, _ := j.List(); len(entries) != 0 {
+		t.Errorf("query was recorded: %+v", entries)
+	}
+}
+
+func TestDryRunJournalHandler(t *testing.T) {
+	j, _ := journal.Open(filepath.Join(t.TempDir(), "journal.jsonl"))
+	j.Append(journal.Entry{API: journal.APIREST, Method: http.MethodPost, Path: "repos/o/r/issues", Operation: "POST repos/o/r/issues"})
+	h := &dryRunJournalHandler{journal: j}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/dry-run/journal", nil))
+	var got struct {
+		Entries []journal.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].Path != "repos/o/r/issues" {
+		t.Errorf("entries = %+v", got.Entries)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/dry-run/journal", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", w.Code)
+	}
+	if entries, _ := j.List(); len(entries) != 0 {
+		t.Errorf("journal not cleared: %+v", entries)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/dry-run/journal", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
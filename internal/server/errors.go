@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes returned in the error envelope of the /api/ routes.
+const (
+	errCodeBadRequest       = "bad_request"
+	errCodeInvalidProjectID = "invalid_project_id"
+	errCodeInvalidBody      = "invalid_body"
+	errCodeBodyTooLarge     = "body_too_large"
+	errCodeNotFound         = "not_found"
+	errCodeMethodNotAllowed = "method_not_allowed"
+	errCodeForbidden        = "forbidden"
+	errCodeUnauthorized     = "unauthorized"
+	errCodeReadOnly         = "read_only"
+	errCodeConflict         = "conflict"
+	errCodePrecondition     = "precondition_failed"
+	errCodeUpstream         = "upstream_error"
+	errCodeRateLimited      = "rate_limited"
+	errCodeInternal         = "internal_error"
+)
+
+// errorEnvelope is the JSON body of every error response from the /api/ routes:
+//
+//	{"error": {"code": "invalid_project_id", "message": "..."}}
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError writes an error response in the errorEnvelope format.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorEnvelope{Error: errorBody{Code: code, Message: message}})
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxETagEntries caps the number of responses the ETag cache keeps; the oldest is dropped first.
+	maxETagEntries = 256
+	// maxETagBodyBytes caps the size of a response the ETag cache keeps.
+	maxETagBodyBytes = 4 << 20
+	// etagEntryTTL is how long a response is kept. Every use is still revalidated with GitHub.
+	etagEntryTTL = 10 * time.Minute
+)
+
+// etagCache is a transport that keeps REST GET responses with an ETag and revalidates
+// them with If-None-Match. GitHub answers 304 without counting it against the rate
+// limit, and the kept response is returned in its place. Requests that are already
+// conditional, such as the ones the browser revalidates its own cache with, are passed on.
+type etagCache struct {
+	next http.RoundTripper
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*etagEntry
+
+	// hits counts the responses served from the cache after a 304, misses the ones
+	// GitHub had to send in full.
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type etagEntry struct {
+	etag     string
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+}
+
+func newETagCache(next http.RoundTripper) *etagCache {
+	return &etagCache{next: next, now: time.Now, entries: make(map[string]*etagEntry)}
+}
+
+func (c *etagCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || strings.HasSuffix(req.URL.Path, "/graphql") ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" || req.Header.Get("Range") != "" {
+		return c.next.RoundTrip(req)
+	}
+	key := req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Accept-Encoding")
+	entry := c.lookup(key)
+
+	out := req
+	if entry != nil {
+		out = req.Clone(req.Context())
+		out.Header.Set("If-None-Match", entry.etag)
+	}
+	resp, err := c.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		c.hits.Add(1)
+		resp.Body.Close()
+		header := entry.header.Clone()
+		// The 304 carries the current rate limit and caching headers.
+		for k, v := range resp.Header {
+			switch k {
+			case "Content-Length", "Content-Encoding", "Content-Type":
+			default:
+				header[k] = v
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	c.misses.Add(1)
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || resp.ContentLength > maxETagBodyBytes {
+		return resp, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxETagBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxETagBodyBytes {
+		// Too large to keep: pass on what was read and the rest of the body.
+		rest := resp.Body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), rest), rest}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.store(key, &etagEntry{etag: etag, header: resp.Header.Clone(), body: body, storedAt: c.now()})
+	return resp, nil
+}
+
+// lookup returns the entry for key unless it has expired.
+func (c *etagCache) lookup(key string) *etagEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if c.now().Sub(e.storedAt) > etagEntryTTL {
+		delete(c.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (c *etagCache) store(key string, e *etagEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxETagEntries {
+		var oldest string
+		for k, v := range c.entries {
+			if oldest == "" || v.storedAt.Before(c.entries[oldest].storedAt) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = e
+}
+
+func (c *etagCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// ServeMetrics serves how many upstream calls the coalescing and the ETag cache saved.
+//
+//	GET /api/github/metrics  {"coalescing": {"requests": 10, "shared": 4}, "etag": {"hits": 3, "misses": 5, "entries": 5}}
+func (h *proxyHandler) ServeMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"coalescing": map[string]int64{
+			"requests": h.coalesce.requests.Load(),
+			"shared":   h.coalesce.shared.Load(),
+		},
+		"etag": map[string]int64{
+			"hits":    h.etag.hits.Load(),
+			"misses":  h.etag.misses.Load(),
+			"entries": int64(h.etag.len()),
+		},
+	})
+}
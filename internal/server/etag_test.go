@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagCache_RevalidatesRESTGets(t *testing.T) {
+	var conditional []string
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inm := r.Header.Get("If-None-Match")
+		conditional = append(conditional, inm)
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		if inm == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	t.Cleanup(mock.Close)
+	handler := newMockHandler(t, mock, "")
+
+	for i := range 2 {
+		w := httptest.NewRecorder()
+		handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/user", nil))
+		if w.Code != http.StatusOK || w.Body.String() != `{"login":"octocat"}` {
+			t.Fatalf("request %d: response = %d %s", i, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("ETag"); got != `"v1"` {
+			t.Errorf("request %d: ETag = %q", i, got)
+		}
+	}
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Errorf("If-None-Match sent = %q", conditional)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeMetrics(w, httptest.NewRequest(http.MethodGet, "/api/github/metrics", nil))
+	var metrics struct {
+		ETag struct {
+			Hits, Misses, Entries int
+		} `json:"etag"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &metrics)
+	if metrics.ETag.Hits != 1 || metrics.ETag.Misses != 1 || metrics.ETag.Entries != 1 {
+		t.Errorf("metrics = %s", w.Body.String())
+	}
+}
+
+func TestETagCache_PassesConditionalRequests(t *testing.T) {
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(mock.Close)
+	handler := newMockHandler(t, mock, "")
+
+	// The browser revalidates its own copy, so the 304 is passed on.
+	req := httptest.NewRequest(http.MethodGet, "/api/github/rest/user", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", w.Code)
+	}
+	if n := handler.etag.len(); n != 0 {
+		t.Errorf("entries = %d, want 0", n)
+	}
+}
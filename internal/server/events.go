@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// eventsKeepAlive is how often an idle event stream sends a comment, so that the
+// connection is not dropped as idle.
+const eventsKeepAlive = 30 * time.Second
+
+// eventsHandler streams the changes of the cache store to the browser as Server-Sent
+// Events, so a tab sees the items written by the background refresh, the sync command
+// or another tab, and the nodes moved in another tab, without reloading.
+type eventsHandler struct {
+	store *cache.Store
+
+	// done is closed when the server shuts down, so the streams end instead of
+	// holding up the shutdown.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newEventsHandler(store *cache.Store) *eventsHandler {
+	return &eventsHandler{store: store, done: make(chan struct{})}
+}
+
+// close ends every stream.
+func (h *eventsHandler) close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// ServeHTTP serves the change stream.
+//
+//	GET /api/events?projectId=PVT_xxx
+//
+// Each change of the project, or of every project without projectId, is sent as a
+// "change" event whose data is a cache.Change:
+//
+//	event: change
+//	data: {"projectId":"PVT_xxx","revision":3,"items":true}
+//
+// When the stream falls behind and changes were dropped, a "reset" event is sent and
+// the stream ends. The browser reconnects and should load the project again.
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	projectID := r.URL.Query().Get("projectId")
+	if projectID != "" && !github.IsProjectID(projectID) {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid project ID")
+		return
+	}
+
+	changes, unsubscribe := h.store.Subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case c, ok := <-changes:
+			if !ok {
+				writeEvent(w, "reset", struct{}{})
+				rc.Flush()
+				return
+			}
+			if projectID != "" && c.ProjectID != projectID {
+				continue
+			}
+			err = writeEvent(w, "change", c)
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with JSON data.
+func writeEvent(w http.ResponseWriter, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+)
+
+// openEventStream connects to the event stream at url and returns its events as
+// "event data" strings.
+func openEventStream(t *testing.T, url string) <-chan string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("response = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan string, 16)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		var event string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				events <- event + " " + strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan string) string {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+	return ""
+}
+
+func TestEvents_StreamsProjectChanges(t *testing.T) {
+	store := cache.NewStore(t.TempDir())
+	ts := httptest.NewServer(newEventsHandler(store))
+	t.Cleanup(ts.Close)
+	events := openEventStream(t, ts.URL+"/api/events?projectId=PVT_1")
+
+	store.SetItems("PVT_other", json.RawMessage(`[1]`))
+	store.SetItems("PVT_1", json.RawMessage(`[1]`))
+	if e := nextEvent(t, events); e != `change {"projectId":"PVT_1","revision":1,"items":true}` {
+		t.Errorf("event = %s", e)
+	}
+
+	store.MergeNodePositions("PVT_1", map[string]cache.NodePosition{"o/r#1": {X: 1, Y: 2}})
+	if e := nextEvent(t, events); e != `change {"projectId":"PVT_1","revision":2,"nodePositions":{"o/r#1":{"x":1,"y":2}}}` {
+		t.Errorf("event = %s", e)
+	}
+}
+
+func TestEvents_Errors(t *testing.T) {
+	h := newEventsHandler(cache.NewStore(t.TempDir()))
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		wantStatus int
+	}{
+		{name: "wrong method", method: http.MethodPost, url: "/api/events", wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid project ID", method: http.MethodGet, url: "/api/events?projectId=../x", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStart_ShutdownEndsEventStreams(t *testing.T) {
+	srv := New(Config{CacheStore: cache.NewStore(t.TempDir())})
+	ln := listenLocal(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx, ln) }()
+
+	events := openEventStream(t, "http://"+ln.Addr().String()+"/api/events?token="+srv.Token())
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	for range events {
+	}
+}
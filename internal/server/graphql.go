@@ -0,0 +1,449 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// graphqlOperation is an operation definition of a GraphQL document.
+type graphqlOperation struct {
+	Type       string // "query", "mutation" or "subscription"
+	Name       string
+	Selections []graphqlField
+}
+
+// graphqlField is a field of a selection set. Inline fragments are flattened into
+// their parent selection set and named fragment spreads are dropped.
+type graphqlField struct {
+	Alias string
+	Name  string
+	// Arguments holds the argument values as strings, json.Number, bool, nil,
+	// []any, map[string]any and graphqlVariable references.
+	Arguments  map[string]any
+	Selections []graphqlField
+}
+
+// graphqlVariable is a reference to an operation variable in an argument value.
+type graphqlVariable string
+
+// resolveGraphQLValue replaces the variable references in v with their values.
+// Undefined variables resolve to nil.
+func resolveGraphQLValue(v any, variables map[string]any) any {
+	switch v := v.(type) {
+	case graphqlVariable:
+		return variables[string(v)]
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for k, child := range v {
+			resolved[k] = resolveGraphQLValue(child, variables)
+		}
+		return resolved
+	case []any:
+		resolved := make([]any, len(v))
+		for i, child := range v {
+			resolved[i] = resolveGraphQLValue(child, variables)
+		}
+		return resolved
+	default:
+		return v
+	}
+}
+
+// ResponseKey returns the key the field has in the response data.
+func (f graphqlField) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// fieldNames returns the names of the root fields, e.g. the mutations a mutation operation runs.
+func (op graphqlOperation) fieldNames() string {
+	names := make([]string, len(op.Selections))
+	for i, f := range op.Selections {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// graphqlOperationTypes returns the operation type ("query", "mutation" or "subscription")
+// of every operation defined in a GraphQL document. Fragment definitions are skipped.
+func graphqlOperationTypes(doc string) ([]string, error) {
+	ops, err := parseGraphQLOperations(doc)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]string, len(ops))
+	for i, op := range ops {
+		types[i] = op.Type
+	}
+	return types, nil
+}
+
+// parseGraphQLOperations parses the operation definitions of a GraphQL document.
+//
+// It only parses as much as is needed to find operations, the shape of their
+// selection sets and field arguments: variable definitions and directives are
+// skipped and nothing is validated against the schema; GitHub still does that.
+func parseGraphQLOperations(doc string) ([]graphqlOperation, error) {
+	tokens, err := lexGraphQL(doc)
+	if err != nil {
+		return nil, err
+	}
+	p := &graphqlParser{tokens: tokens}
+
+	var ops []graphqlOperation
+	for !p.done() {
+		tok := p.next()
+		switch {
+		case tok.punct == "{":
+			// Shorthand "{ ... }" is an anonymous query.
+			p.pos--
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, graphqlOperation{Type: "query", Selections: sel})
+		case tok.name == "query" || tok.name == "mutation" || tok.name == "subscription":
+			op := graphqlOperation{Type: tok.name}
+			if p.peek().name != "" {
+				op.Name = p.next().name
+			}
+			if p.peek().punct == "(" {
+				if err := p.skipParens(); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.Selections = sel
+			ops = append(ops, op)
+		case tok.name == "fragment":
+			// fragment Name on Type @directives { ... }
+			name, on, typ := p.next(), p.next(), p.next()
+			if name.name == "" || on.name != "on" || typ.name == "" {
+				return nil, errors.New("invalid fragment definition")
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if _, err := p.selectionSet(); err != nil {
+				return nil, err
+			}
+		case tok.name != "":
+			return nil, errors.New("unexpected definition " + tok.name)
+		default:
+			return nil, fmt.Errorf("unexpected %q", tok.text())
+		}
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("no operation found")
+	}
+	return ops, nil
+}
+
+// graphqlToken is a lexical token. Exactly one of punct and name is set for
+// punctuators and names; other tokens (strings, numbers) only set value.
+type graphqlToken struct {
+	punct string
+	name  string
+	value string
+}
+
+func (t graphqlToken) text() string {
+	return t.punct + t.name + t.value
+}
+
+func lexGraphQL(doc string) ([]graphqlToken, error) {
+	var tokens []graphqlToken
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			end, err := skipGraphQLString(doc, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, graphqlToken{value: doc[i:end]})
+			i = end
+		case strings.HasPrefix(doc[i:], "..."):
+			tokens = append(tokens, graphqlToken{punct: "..."})
+			i += 3
+		case strings.IndexByte("{}()[]:=@$!&|", c) >= 0:
+			tokens = append(tokens, graphqlToken{punct: string(c)})
+			i++
+		case isGraphQLNameStart(c):
+			start := i
+			for i < len(doc) && isGraphQLNameContinue(doc[i]) {
+				i++
+			}
+			tokens = append(tokens, graphqlToken{name: doc[start:i]})
+		case c == '-' || '0' <= c && c <= '9':
+			start := i
+			i++
+			for i < len(doc) && (isGraphQLNameContinue(doc[i]) || doc[i] == '.' || doc[i] == '+' || doc[i] == '-') {
+				i++
+			}
+			tokens = append(tokens, graphqlToken{value: doc[start:i]})
+		case strings.HasPrefix(doc[i:], "\ufeff"):
+			i += len("\ufeff")
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+type graphqlParser struct {
+	tokens []graphqlToken
+	pos    int
+}
+
+func (p *graphqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *graphqlParser) peek() graphqlToken {
+	if p.done() {
+		return graphqlToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *graphqlParser) next() graphqlToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// selectionSet parses "{ ... }" starting at the current token.
+func (p *graphqlParser) selectionSet() ([]graphqlField, error) {
+	if p.next().punct != "{" {
+		return nil, errors.New("expected selection set")
+	}
+	fields := []graphqlField{}
+	for {
+		if p.done() {
+			return nil, errors.New("unbalanced braces")
+		}
+		tok := p.next()
+		switch {
+		case tok.punct == "}":
+			return fields, nil
+		case tok.punct == "...":
+			if p.peek().name != "" && p.peek().name != "on" {
+				// Named fragment spread.
+				p.next()
+				if err := p.skipDirectives(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if p.peek().name == "on" {
+				p.next()
+				if p.next().name == "" {
+					return nil, errors.New("expected type condition")
+				}
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, sel...)
+		case tok.name != "":
+			field := graphqlField{Name: tok.name}
+			if p.peek().punct == ":" {
+				p.next()
+				name := p.next().name
+				if name == "" {
+					return nil, errors.New("expected field name after alias")
+				}
+				field.Alias, field.Name = field.Name, name
+			}
+			if p.peek().punct == "(" {
+				args, err := p.arguments()
+				if err != nil {
+					return nil, err
+				}
+				field.Arguments = args
+			}
+			if err := p.skipDirectives(); err != nil {
+				return nil, err
+			}
+			if p.peek().punct == "{" {
+				sel, err := p.selectionSet()
+				if err != nil {
+					return nil, err
+				}
+				field.Selections = sel
+			}
+			fields = append(fields, field)
+		default:
+			return nil, fmt.Errorf("unexpected %q in selection set", tok.text())
+		}
+	}
+}
+
+// arguments parses "(name: value ...)" starting at the current token.
+func (p *graphqlParser) arguments() (map[string]any, error) {
+	p.next()
+	args := map[string]any{}
+	for {
+		tok := p.next()
+		switch {
+		case tok.punct == ")":
+			return args, nil
+		case tok.name != "" && p.next().punct == ":":
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			args[tok.name] = v
+		default:
+			return nil, errors.New("invalid arguments")
+		}
+	}
+}
+
+// value parses an argument value starting at the current token.
+func (p *graphqlParser) value() (any, error) {
+	tok := p.next()
+	switch {
+	case tok.punct == "$":
+		name := p.next().name
+		if name == "" {
+			return nil, errors.New("expected variable name")
+		}
+		return graphqlVariable(name), nil
+	case tok.punct == "[":
+		list := []any{}
+		for p.peek().punct != "]" {
+			if p.done() {
+				return nil, errors.New("unbalanced brackets")
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.next()
+		return list, nil
+	case tok.punct == "{":
+		obj := map[string]any{}
+		for p.peek().punct != "}" {
+			name := p.next().name
+			if name == "" || p.next().punct != ":" {
+				return nil, errors.New("invalid object value")
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+		}
+		p.next()
+		return obj, nil
+	case tok.name == "true", tok.name == "false":
+		return tok.name == "true", nil
+	case tok.name == "null":
+		return nil, nil
+	case tok.name != "":
+		// Enum value.
+		return tok.name, nil
+	case strings.HasPrefix(tok.value, `"""`):
+		return strings.TrimSuffix(strings.TrimPrefix(tok.value, `"""`), `"""`), nil
+	case strings.HasPrefix(tok.value, `"`):
+		var s string
+		if err := json.Unmarshal([]byte(tok.value), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", tok.value)
+		}
+		return s, nil
+	case tok.value != "":
+		return json.Number(tok.value), nil
+	}
+	return nil, fmt.Errorf("unexpected %q in value", tok.text())
+}
+
+// skipParens skips a parenthesized variable definition or directive argument list.
+func (p *graphqlParser) skipParens() error {
+	depth := 0
+	for !p.done() {
+		switch p.next().punct {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return errors.New("unbalanced parentheses")
+}
+
+func (p *graphqlParser) skipDirectives() error {
+	for p.peek().punct == "@" {
+		p.next()
+		if p.next().name == "" {
+			return errors.New("expected directive name")
+		}
+		if p.peek().punct == "(" {
+			if err := p.skipParens(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipGraphQLString returns the index just after the string or block string starting at i.
+func skipGraphQLString(doc string, i int) (int, error) {
+	if strings.HasPrefix(doc[i:], `"""`) {
+		for j := i + 3; j < len(doc); j++ {
+			if doc[j] == '\\' && strings.HasPrefix(doc[j:], `\"""`) {
+				j += 3
+				continue
+			}
+			if strings.HasPrefix(doc[j:], `"""`) {
+				return j + 3, nil
+			}
+		}
+		return 0, errors.New("unterminated block string")
+	}
+	for j := i + 1; j < len(doc); j++ {
+		switch doc[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		case '\n', '\r':
+			return 0, errors.New("unterminated string")
+		}
+	}
+	return 0, errors.New("unterminated string")
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isGraphQLNameContinue(c byte) bool {
+	return isGraphQLNameStart(c) || '0' <= c && c <= '9'
+}
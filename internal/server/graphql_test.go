@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGraphQLOperationTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "shorthand query", doc: `{ viewer { login } }`, want: []string{"query"}},
+		{name: "named query", doc: `query Viewer($n: Int = 1) { viewer { login } }`, want: []string{"query"}},
+		{name: "mutation", doc: `mutation($id: ID!) { deleteIssue(input: {issueId: $id}) { clientMutationId } }`, want: []string{"mutation"}},
+		{name: "subscription", doc: `subscription { x }`, want: []string{"subscription"}},
+		{
+			name: "fragment and query",
+			doc:  "fragment F on Issue { title }\nquery { node(id: \"x\") { ...F } }",
+			want: []string{"query"},
+		},
+		{
+			name: "mutation after query",
+			doc:  `query A { viewer { login } } mutation B { addStar(input: {starrableId: "x"}) { clientMutationId } }`,
+			want: []string{"query", "mutation"},
+		},
+		{name: "mutation in comment", doc: "# mutation { x }\n{ viewer { login } }", want: []string{"query"}},
+		{name: "braces in string", doc: `{ search(query: "} mutation {") { issueCount } }`, want: []string{"query"}},
+		{name: "block string", doc: `{ a(b: """ } mutation { """) }`, want: []string{"query"}},
+		{name: "lowercase field named mutation", doc: `{ mutation }`, want: []string{"query"}},
+		{name: "empty", doc: ``, wantErr: true},
+		{name: "unbalanced", doc: `{ viewer { login }`, wantErr: true},
+		{name: "unterminated string", doc: `{ a(b: "x) }`, wantErr: true},
+		{name: "unknown definition", doc: `schema { query: Query }`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := graphqlOperationTypes(tt.doc)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGraphQLOperations_Selections(t *testing.T) {
+	doc := `
+		fragment Ref on Issue { number }
+		mutation Link($id: ID!) @dev {
+			first: addBlockedBy(input: {issueId: $id, blockingIssueId: "x"}) {
+				issue { id ...Ref ... on Issue { title } }
+			}
+			removeSubIssue(input: {issueId: $id, subIssueId: "(y)"}) @include(if: true) { clientMutationId }
+		}`
+	ops, err := parseGraphQLOperations(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Type != "mutation" || ops[0].Name != "Link" {
+		t.Fatalf("unexpected operations: %+v", ops)
+	}
+
+	want := []graphqlField{
+		{Alias: "first", Name: "addBlockedBy", Arguments: map[string]any{
+			"input": map[string]any{"issueId": graphqlVariable("id"), "blockingIssueId": "x"},
+		}, Selections: []graphqlField{
+			{Name: "issue", Selections: []graphqlField{{Name: "id"}, {Name: "title"}}},
+		}},
+		{Name: "removeSubIssue", Arguments: map[string]any{
+			"input": map[string]any{"issueId": graphqlVariable("id"), "subIssueId": "(y)"},
+		}, Selections: []graphqlField{{Name: "clientMutationId"}}},
+	}
+	if !reflect.DeepEqual(ops[0].Selections, want) {
+		t.Errorf("selections = %+v, want %+v", ops[0].Selections, want)
+	}
+	if got := ops[0].Selections[0].ResponseKey(); got != "first" {
+		t.Errorf("ResponseKey = %q, want first", got)
+	}
+}
+
+func TestResolveGraphQLValue(t *testing.T) {
+	doc := `mutation {
+		updateProjectV2ItemFieldValue(input: {
+			projectId: "PVT_1", itemId: $item, fieldId: "F_1",
+			value: {number: -1.5e2, text: """a "b" c"""}, flags: [true, null, OPEN, $missing]
+		}) { clientMutationId }
+	}`
+	ops, err := parseGraphQLOperations(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := resolveGraphQLValue(ops[0].Selections[0].Arguments["input"], map[string]any{"item": "PVTI_1"})
+	want := map[string]any{
+		"projectId": "PVT_1",
+		"itemId":    "PVTI_1",
+		"fieldId":   "F_1",
+		"value":     map[string]any{"number": json.Number("-1.5e2"), "text": `a "b" c`},
+		"flags":     []any{true, nil, "OPEN", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
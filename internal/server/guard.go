@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SessionTokenHeader is the request header that carries the session token.
+// EventSource cannot set headers, so the token is also accepted as the "token" query parameter.
+const SessionTokenHeader = "X-Treefier-Token"
+
+// NewSessionToken returns a random token for a single console launch.
+func NewSessionToken() string {
+	return rand.Text()
+}
+
+// apiGuard rejects API requests that did not come from the console page served by this process.
+// The Host check defeats DNS rebinding, the Origin check rejects cross-site requests and
+// the session token proves the caller was given the URL printed at launch.
+type apiGuard struct {
+	host  string
+	token string
+	next  http.Handler
+}
+
+func (g *apiGuard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.allowedHost(r.Host) {
+		writeError(w, http.StatusForbidden, errCodeForbidden, "forbidden host")
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+		writeError(w, http.StatusForbidden, errCodeForbidden, "forbidden origin")
+		return
+	}
+	token := r.Header.Get(SessionTokenHeader)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "invalid session token")
+		return
+	}
+	g.next.ServeHTTP(w, r)
+}
+
+// allowedHost accepts localhost, IP literals and the configured bind host.
+// A rebinding attack always arrives with the attacker's domain name in Host.
+func (g *apiGuard) allowedHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") || net.ParseIP(host) != nil {
+		return true
+	}
+	return g.host != "" && strings.EqualFold(host, g.host)
+}
+
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
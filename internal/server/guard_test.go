@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIGuard(t *testing.T) {
+	const token = "secret"
+	tests := []struct {
+		name     string
+		bindHost string
+		host     string
+		origin   string
+		header   string
+		query    string
+		want     int
+	}{
+		{name: "localhost with header token", host: "localhost:7000", header: token, want: http.StatusOK},
+		{name: "loopback IP", host: "127.0.0.1:7000", header: token, want: http.StatusOK},
+		{name: "IPv6 loopback", host: "[::1]:7000", header: token, want: http.StatusOK},
+		{name: "token in query", host: "localhost:7000", query: "?token=" + token, want: http.StatusOK},
+		{name: "same origin", host: "localhost:7000", origin: "http://localhost:7000", header: token, want: http.StatusOK},
+		{name: "configured host name", bindHost: "devbox.local", host: "devbox.local:7000", header: token, want: http.StatusOK},
+		{name: "rebinding host", host: "evil.example:7000", header: token, want: http.StatusForbidden},
+		{name: "cross-site origin", host: "localhost:7000", origin: "https://evil.example", header: token, want: http.StatusForbidden},
+		{name: "other port origin", host: "localhost:7000", origin: "http://localhost:3000", header: token, want: http.StatusForbidden},
+		{name: "null origin", host: "localhost:7000", origin: "null", header: token, want: http.StatusForbidden},
+		{name: "missing token", host: "localhost:7000", want: http.StatusUnauthorized},
+		{name: "wrong token", host: "localhost:7000", header: "guess", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &apiGuard{
+				host:  tt.bindHost,
+				token: token,
+				next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}),
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/github/graphql"+tt.query, nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.header != "" {
+				req.Header.Set(SessionTokenHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestNew_GeneratesToken(t *testing.T) {
+	a, b := New(Config{}), New(Config{})
+	if a.Token() == "" || a.Token() == b.Token() {
+		t.Fatalf("expected distinct random tokens, got %q and %q", a.Token(), b.Token())
+	}
+	if got := New(Config{Token: "fixed"}).Token(); got != "fixed" {
+		t.Fatalf("expected configured token, got %q", got)
+	}
+}
+
+func TestHandler_GuardsAPIOnly(t *testing.T) {
+	srv := New(Config{Token: "secret"})
+	h, err := srv.handler()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cache/PVT_proj1", nil)
+	req.Host = "localhost:7000"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected API request without token to be rejected, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "localhost:7000"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+		t.Fatalf("expected static files to be served without token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,398 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+)
+
+// inverseMutations maps the dependency mutations the history can undo to their inverse
+// and the input fields both of them take.
+var inverseMutations = map[string]struct {
+	inverse string
+	fields  []string
+}{
+	"addBlockedBy":    {inverse: "removeBlockedBy", fields: []string{"issueId", "blockingIssueId"}},
+	"removeBlockedBy": {inverse: "addBlockedBy", fields: []string{"issueId", "blockingIssueId"}},
+	"addSubIssue":     {inverse: "removeSubIssue", fields: []string{"issueId", "subIssueId"}},
+	"removeSubIssue":  {inverse: "addSubIssue", fields: []string{"issueId", "subIssueId"}},
+}
+
+// graphqlUndoStep returns the history step for a GraphQL mutation that is about to be
+// forwarded, or nil if the mutation cannot be undone. For project field updates it
+// looks up the current value first, so it has to be called before forwarding.
+func (h *proxyHandler) graphqlUndoStep(ctx context.Context, body []byte, op graphqlOperation, variables map[string]any) *history.Step {
+	if len(op.Selections) != 1 {
+		return nil
+	}
+	f := op.Selections[0]
+	input, ok := resolveGraphQLValue(f.Arguments["input"], variables).(map[string]any)
+	if !ok {
+		return nil
+	}
+	undo, err := h.undoRequest(ctx, f.Name, input)
+	if err != nil {
+		return nil
+	}
+	return &history.Step{Label: f.Name, Do: history.Request{API: "graphql", Body: body}, Undo: undo}
+}
+
+// errNotUndoable is returned by undoRequest for mutations the history does not support.
+var errNotUndoable = errors.New("the change cannot be undone")
+
+// undoRequest returns the request that reverts the mutation with the given input.
+// For project field updates it looks up the current value, so it has to be called
+// before the mutation is sent.
+func (h *proxyHandler) undoRequest(ctx context.Context, mutation string, input map[string]any) (history.Request, error) {
+	if m, ok := inverseMutations[mutation]; ok {
+		in, ok := pickStrings(input, m.fields...)
+		if !ok {
+			return history.Request{}, errNotUndoable
+		}
+		return graphqlMutationRequest(m.inverse, in), nil
+	}
+
+	switch mutation {
+	case "updateProjectV2ItemFieldValue", "clearProjectV2ItemFieldValue":
+		in, ok := pickStrings(input, "projectId", "itemId", "fieldId")
+		if !ok {
+			return history.Request{}, errNotUndoable
+		}
+		prev, found, err := h.projectFieldValue(ctx, in["itemId"].(string), in["fieldId"].(string))
+		if err != nil {
+			return history.Request{}, err
+		}
+		if !found {
+			return graphqlMutationRequest("clearProjectV2ItemFieldValue", in), nil
+		}
+		in["value"] = prev
+		return graphqlMutationRequest("updateProjectV2ItemFieldValue", in), nil
+	}
+	return history.Request{}, errNotUndoable
+}
+
+// restUndoStep returns the history step for a REST request, or nil if it cannot be undone.
+// Only adding and removing sub-issues is supported.
+func restUndoStep(method, path string, body []byte) *history.Step {
+	segments := strings.Split(path, "/")
+	if len(segments) != 6 || segments[0] != "repos" || segments[3] != "issues" {
+		return nil
+	}
+	var req struct {
+		SubIssueID json.Number `json:"sub_issue_id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.SubIssueID == "" {
+		return nil
+	}
+	undoBody, _ := json.Marshal(map[string]any{"sub_issue_id": req.SubIssueID})
+	issuePath := strings.Join(segments[:5], "/")
+	do := history.Request{API: "rest", Method: method, Path: path, Body: body}
+
+	switch {
+	case method == http.MethodPost && segments[5] == "sub_issues":
+		return &history.Step{Label: "addSubIssue", Do: do, Undo: history.Request{
+			API: "rest", Method: http.MethodDelete, Path: issuePath + "/sub_issue", Body: undoBody,
+		}}
+	case method == http.MethodDelete && segments[5] == "sub_issue":
+		return &history.Step{Label: "removeSubIssue", Do: do, Undo: history.Request{
+			API: "rest", Method: http.MethodPost, Path: issuePath + "/sub_issues", Body: undoBody,
+		}}
+	}
+	return nil
+}
+
+// pickStrings returns the given fields of input, which all have to be non-empty strings.
+func pickStrings(input map[string]any, fields ...string) (map[string]any, bool) {
+	out := make(map[string]any, len(fields))
+	for _, name := range fields {
+		v, ok := input[name].(string)
+		if !ok || v == "" {
+			return nil, false
+		}
+		out[name] = v
+	}
+	return out, true
+}
+
+// graphqlMutationRequest builds a request that runs a single mutation with the given input.
+func graphqlMutationRequest(mutation string, input map[string]any) history.Request {
+	inputType := strings.ToUpper(mutation[:1]) + mutation[1:] + "Input"
+	query := fmt.Sprintf("mutation($input: %s!) { %s(input: $input) { clientMutationId } }", inputType, mutation)
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": map[string]any{"input": input}})
+	return history.Request{API: "graphql", Body: body}
+}
+
+const projectFieldValuesQuery = `query($itemId: ID!) {
+	node(id: $itemId) {
+		... on ProjectV2Item {
+			fieldValues(first: 100) {
+				nodes {
+					... on ProjectV2ItemFieldSingleSelectValue { field { ... on ProjectV2FieldCommon { id } } optionId }
+					... on ProjectV2ItemFieldIterationValue { field { ... on ProjectV2FieldCommon { id } } iterationId }
+					... on ProjectV2ItemFieldNumberValue { field { ... on ProjectV2FieldCommon { id } } number }
+					... on ProjectV2ItemFieldTextValue { field { ... on ProjectV2FieldCommon { id } } text }
+					... on ProjectV2ItemFieldDateValue { field { ... on ProjectV2FieldCommon { id } } date }
+				}
+			}
+		}
+	}
+}`
+
+// projectFieldValue returns the current value of a project item field in the
+// ProjectV2FieldValue input shape. found is false when the field has no value.
+func (h *proxyHandler) projectFieldValue(ctx context.Context, itemID, fieldID string) (value map[string]any, found bool, err error) {
+	body, _ := json.Marshal(map[string]any{"query": projectFieldValuesQuery, "variables": map[string]any{"itemId": itemID}})
+	status, header, data := h.roundTrip(ctx, history.Request{API: "graphql", Body: body})
+	if msg := githubErrorMessage(status, header, data); msg != "" || status >= 300 {
+		return nil, false, fmt.Errorf("failed to fetch field values of %s: %s", itemID, cmp.Or(msg, http.StatusText(status)))
+	}
+
+	var resp struct {
+		Data struct {
+			Node *struct {
+				FieldValues struct {
+					Nodes []struct {
+						Field *struct {
+							ID string `json:"id"`
+						} `json:"field"`
+						OptionID    *string      `json:"optionId"`
+						IterationID *string      `json:"iterationId"`
+						Number      *json.Number `json:"number"`
+						Text        *string      `json:"text"`
+						Date        *string      `json:"date"`
+					} `json:"nodes"`
+				} `json:"fieldValues"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&resp); err != nil {
+		return nil, false, fmt.Errorf("failed to parse field values of %s: %w", itemID, err)
+	}
+	if resp.Data.Node == nil {
+		return nil, false, fmt.Errorf("project item %s not found", itemID)
+	}
+	for _, fv := range resp.Data.Node.FieldValues.Nodes {
+		if fv.Field == nil || fv.Field.ID != fieldID {
+			continue
+		}
+		switch {
+		case fv.OptionID != nil:
+			return map[string]any{"singleSelectOptionId": *fv.OptionID}, true, nil
+		case fv.IterationID != nil:
+			return map[string]any{"iterationId": *fv.IterationID}, true, nil
+		case fv.Number != nil:
+			return map[string]any{"number": *fv.Number}, true, nil
+		case fv.Text != nil:
+			return map[string]any{"text": *fv.Text}, true, nil
+		case fv.Date != nil:
+			return map[string]any{"date": *fv.Date}, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// replay sends a history request to GitHub and records it in the audit log.
+func (h *proxyHandler) replay(ctx context.Context, req history.Request, source string) error {
+	var entry audit.Entry
+	if h.audit != nil {
+		entry = auditEntry(req, source)
+		// Look the targets up before the change, like forwardWrite.
+		entry.Targets = h.targets.Resolve(ctx, entry.Targets)
+	}
+
+	status, header, data := h.roundTrip(ctx, req)
+	msg := githubErrorMessage(status, header, data)
+
+	if h.audit != nil {
+		entry.Status, entry.Error = status, msg
+		if err := h.audit.Append(entry); err != nil {
+			// The change already reached GitHub, so the history still moves on.
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	if msg != "" || status >= 300 {
+		return errors.New(cmp.Or(msg, http.StatusText(status)))
+	}
+	return nil
+}
+
+// auditEntry returns the audit entry for a history request, without the response.
+func auditEntry(req history.Request, source string) audit.Entry {
+	entry := audit.Entry{Source: source, API: req.API}
+	if req.API == "rest" {
+		entry.Method, entry.Path = req.Method, req.Path
+		entry.Operation = req.Method + " " + req.Path
+		entry.Variables = jsonOrNil(req.Body)
+		entry.Targets = audit.RESTTargets(req.Path, req.Body)
+		return entry
+	}
+	var gql struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+	json.Unmarshal(req.Body, &gql)
+	if ops, err := parseGraphQLOperations(gql.Query); err == nil && len(ops) == 1 {
+		entry.Operation = ops[0].fieldNames()
+	}
+	entry.Variables = jsonOrNil(gql.Variables)
+	entry.Targets = audit.GraphQLTargets(gql.Variables)
+	return entry
+}
+
+// roundTrip sends a request to GitHub through the reverse proxy, so it uses the same
+// host and credentials as the requests of the UI, and returns the buffered response.
+func (h *proxyHandler) roundTrip(ctx context.Context, req history.Request) (int, http.Header, []byte) {
+	method, path := http.MethodPost, h.pathPrefix+"/graphql"
+	if req.API == "rest" {
+		method, path = req.Method, h.pathPrefix+"/"+req.Path
+	}
+	r, err := http.NewRequestWithContext(ctx, method, "http://github"+path, bytes.NewReader(req.Body))
+	if err != nil {
+		return http.StatusBadRequest, http.Header{}, nil
+	}
+	if len(req.Body) > 0 {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	w := &bufferedResponse{header: http.Header{}}
+	h.proxy.ServeHTTP(w, r)
+	return w.statusCode(), w.header, w.body.Bytes()
+}
+
+// query sends a GraphQL query to GitHub through the reverse proxy and decodes the data
+// of the response into resp. It is an audit.QueryFunc. Errors for some of the nodes are
+// ignored as long as GitHub returns data.
+func (h *proxyHandler) query(ctx context.Context, query string, variables map[string]any, resp any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	status, header, data := h.roundTrip(ctx, history.Request{API: "graphql", Body: body})
+	if status >= 300 {
+		return errors.New(cmp.Or(githubErrorMessage(status, header, data), http.StatusText(status)))
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return errors.New(cmp.Or(githubErrorMessage(status, header, data), "no data in GraphQL response"))
+	}
+	return json.Unmarshal(envelope.Data, resp)
+}
+
+// bufferedResponse is an http.ResponseWriter that keeps the whole response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponse) Header() http.Header { return w.header }
+
+func (w *bufferedResponse) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedResponse) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponse) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// ProjectHeader names the project the browser is working on, so the changes it sends
+// through the proxy are recorded in the history of that project.
+const ProjectHeader = "X-Treefier-Project"
+
+// historyOf returns the history of a project, or the history of the changes sent
+// without a project when projectID is empty.
+func (h *proxyHandler) historyOf(projectID string) (*history.Stack, error) {
+	if projectID != "" && !github.IsProjectID(projectID) {
+		return nil, fmt.Errorf("invalid project ID %q", projectID)
+	}
+	return h.history.Get(projectID)
+}
+
+// historyHandler serves the undo / redo history of the changes made through the proxy.
+//
+//	GET  /api/history?projectId=PVT_xxx       lists the steps that can be undone and redone, newest first
+//	POST /api/history/undo?projectId=PVT_xxx  sends the inverse of the last change
+//	POST /api/history/redo?projectId=PVT_xxx  sends the last undone change again
+//
+// Each project has its own history, so undoing in one project never reverts a change
+// made in another. Without projectId, the history of the changes sent without
+// ProjectHeader is used. The history is saved in a file per project; two consoles
+// working on the same project do not see each other's steps, and the one that
+// saves last keeps its own.
+type historyHandler struct {
+	proxy *proxyHandler
+}
+
+func (h *historyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/history"), "/")
+	stack, err := h.proxy.historyOf(r.URL.Query().Get("projectId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid project ID")
+		return
+	}
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		undo, redo := stack.Steps()
+		writeJSON(w, http.StatusOK, map[string]any{"undo": undo, "redo": redo})
+	case "undo", "redo":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+			return
+		}
+		source := audit.SourceUndo
+		if action == "redo" {
+			source = audit.SourceRedo
+		}
+		send := func(req history.Request) error {
+			return h.proxy.replay(r.Context(), req, source)
+		}
+		var step history.Step
+		if action == "undo" {
+			step, err = stack.Undo(send)
+		} else {
+			step, err = stack.Redo(send)
+		}
+		switch {
+		case errors.Is(err, history.ErrEmpty):
+			writeError(w, http.StatusConflict, errCodeConflict, "nothing to "+action)
+		case err != nil:
+			writeError(w, http.StatusBadGateway, errCodeUpstream, "failed to "+action+" "+step.Label+": "+err.Error())
+		default:
+			writeJSON(w, http.StatusOK, map[string]any{"step": step})
+		}
+	default:
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+	}
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+)
+
+type upstreamRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// newHistoryHandler creates a proxyHandler with a history whose upstream answers
+// with respond and records every request it receives.
+// The returned stack is the history of the changes sent without a project.
+func newHistoryHandler(t *testing.T, respond func(r upstreamRequest) (int, string)) (*proxyHandler, *history.Stack, *[]upstreamRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []upstreamRequest
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req := upstreamRequest{Method: r.Method, Path: r.URL.Path, Body: string(b)}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+		status, body := respond(req)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(mock.Close)
+
+	stacks := history.OpenDir(t.TempDir(), 0)
+	stack, err := stacks.Get("")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	handler := newMockHandler(t, mock, "")
+	handler.history = stacks
+	return handler, stack, &received
+}
+
+func graphqlVariablesOf(t *testing.T, body string) (string, map[string]any) {
+	t.Helper()
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("invalid GraphQL body %s: %v", body, err)
+	}
+	return req.Query, req.Variables
+}
+
+func TestHistory_RecordsDependencyMutations(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		method      string
+		body        string
+		wantLabel   string
+		wantUndo    string
+		wantUndoVar map[string]any
+		wantREST    *history.Request
+	}{
+		{
+			name: "addBlockedBy", path: "/api/github/graphql", method: http.MethodPost,
+			body:      `{"query":"mutation($issueId: ID!, $blockingIssueId: ID!) { addBlockedBy(input: { issueId: $issueId, blockingIssueId: $blockingIssueId }) { issue { id } } }","variables":{"issueId":"I_1","blockingIssueId":"I_2"}}`,
+			wantLabel: "addBlockedBy", wantUndo: "removeBlockedBy",
+			wantUndoVar: map[string]any{"input": map[string]any{"issueId": "I_1", "blockingIssueId": "I_2"}},
+		},
+		{
+			name: "removeSubIssue with inline input", path: "/api/github/graphql", method: http.MethodPost,
+			body:      `{"query":"mutation { removeSubIssue(input: {issueId: \"I_1\", subIssueId: \"I_3\"}) { issue { id } } }"}`,
+			wantLabel: "removeSubIssue", wantUndo: "addSubIssue",
+			wantUndoVar: map[string]any{"input": map[string]any{"issueId": "I_1", "subIssueId": "I_3"}},
+		},
+		{
+			name: "REST add sub-issue", path: "/api/github/rest/repos/o/r/issues/1/sub_issues", method: http.MethodPost,
+			body:      `{"sub_issue_id":4242}`,
+			wantLabel: "addSubIssue",
+			wantREST:  &history.Request{API: "rest", Method: http.MethodDelete, Path: "repos/o/r/issues/1/sub_issue", Body: json.RawMessage(`{"sub_issue_id":4242}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, stack, _ := newHistoryHandler(t, func(upstreamRequest) (int, string) { return http.StatusOK, `{"data":{}}` })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if strings.HasSuffix(tt.path, "/graphql") {
+				handler.ServeGraphQLProxy(w, req)
+			} else {
+				handler.ServeRESTProxy(w, req)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+
+			undo, _ := stack.Steps()
+			if len(undo) != 1 {
+				t.Fatalf("expected 1 step, got %d", len(undo))
+			}
+			step := undo[0]
+			if step.Label != tt.wantLabel || string(step.Do.Body) != tt.body {
+				t.Errorf("unexpected step: %+v", step)
+			}
+			if tt.wantREST != nil {
+				if step.Undo.API != "rest" || step.Undo.Method != tt.wantREST.Method || step.Undo.Path != tt.wantREST.Path || string(step.Undo.Body) != string(tt.wantREST.Body) {
+					t.Errorf("undo = %+v, want %+v", step.Undo, *tt.wantREST)
+				}
+				return
+			}
+			query, vars := graphqlVariablesOf(t, string(step.Undo.Body))
+			if !strings.Contains(query, tt.wantUndo+"(input: $input)") {
+				t.Errorf("undo query = %q, want %s", query, tt.wantUndo)
+			}
+			if got, _ := json.Marshal(vars); string(got) != string(mustJSON(t, tt.wantUndoVar)) {
+				t.Errorf("undo variables = %s, want %s", got, mustJSON(t, tt.wantUndoVar))
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestHistory_FieldUpdateRestoresPreviousValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		lookup    string
+		wantQuery string
+		wantInput string
+	}{
+		{
+			name:      "previous value",
+			lookup:    `{"data":{"node":{"fieldValues":{"nodes":[{},{"field":{"id":"F_other"},"optionId":"x"},{"field":{"id":"F_1"},"number":3.5}]}}}}`,
+			wantQuery: "updateProjectV2ItemFieldValue",
+			wantInput: `{"fieldId":"F_1","itemId":"PVTI_1","projectId":"PVT_1","value":{"number":3.5}}`,
+		},
+		{
+			name:      "no previous value",
+			lookup:    `{"data":{"node":{"fieldValues":{"nodes":[]}}}}`,
+			wantQuery: "clearProjectV2ItemFieldValue",
+			wantInput: `{"fieldId":"F_1","itemId":"PVTI_1","projectId":"PVT_1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, stack, received := newHistoryHandler(t, func(r upstreamRequest) (int, string) {
+				if strings.Contains(r.Body, "fieldValues(first: 100)") {
+					return http.StatusOK, tt.lookup
+				}
+				return http.StatusOK, `{"data":{"updateProjectV2ItemFieldValue":{"projectV2Item":{"id":"PVTI_1"}}}}`
+			})
+
+			body := `{"query":"mutation UpdateFieldValue($projectId: ID!, $itemId: ID!, $fieldId: ID!, $value: ProjectV2FieldValue!) { updateProjectV2ItemFieldValue(input: { projectId: $projectId itemId: $itemId fieldId: $fieldId value: $value }) { projectV2Item { id } } }","variables":{"projectId":"PVT_1","itemId":"PVTI_1","fieldId":"F_1","value":{"number":5}}}`
+			w := httptest.NewRecorder()
+			handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+			if len(*received) != 2 || (*received)[1].Body != body {
+				t.Fatalf("upstream received %+v, want the lookup and then the mutation", *received)
+			}
+
+			undo, _ := stack.Steps()
+			if len(undo) != 1 {
+				t.Fatalf("expected 1 step, got %d", len(undo))
+			}
+			query, vars := graphqlVariablesOf(t, string(undo[0].Undo.Body))
+			if !strings.Contains(query, tt.wantQuery) {
+				t.Errorf("undo query = %q, want %s", query, tt.wantQuery)
+			}
+			if got := mustJSON(t, vars["input"]); string(got) != tt.wantInput {
+				t.Errorf("undo input = %s, want %s", got, tt.wantInput)
+			}
+		})
+	}
+}
+
+func TestHistory_SkipsFailedAndUnsupportedMutations(t *testing.T) {
+	handler, stack, _ := newHistoryHandler(t, func(r upstreamRequest) (int, string) {
+		if strings.Contains(r.Body, "addBlockedBy") {
+			return http.StatusOK, `{"errors":[{"message":"already blocked"}]}`
+		}
+		return http.StatusOK, `{"data":{}}`
+	})
+
+	for _, body := range []string{
+		`{"query":"mutation { addBlockedBy(input: {issueId: \"I_1\", blockingIssueId: \"I_2\"}) { clientMutationId } }"}`,
+		`{"query":"mutation { deleteIssue(input: {issueId: \"I_1\"}) { clientMutationId } }"}`,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+	}
+
+	if undo, _ := stack.Steps(); len(undo) != 0 {
+		t.Errorf("expected no steps, got %+v", undo)
+	}
+}
+
+func TestHistoryHandler_UndoRedo(t *testing.T) {
+	fail := false
+	handler, stack, received := newHistoryHandler(t, func(upstreamRequest) (int, string) {
+		if fail {
+			return http.StatusUnprocessableEntity, `{"message":"Validation Failed"}`
+		}
+		return http.StatusOK, `{"data":{}}`
+	})
+	log := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	handler.audit = log
+	h := &historyHandler{proxy: handler}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := serve(http.MethodPost, "/api/history/undo"); w.Code != http.StatusConflict {
+		t.Fatalf("undo on empty history: status = %d, want 409", w.Code)
+	}
+
+	forward := `{"query":"mutation { removeBlockedBy(input: {issueId: \"I_1\", blockingIssueId: \"I_2\"}) { clientMutationId } }"}`
+	handler.ServeGraphQLProxy(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(forward)))
+
+	w := serve(http.MethodPost, "/api/history/undo")
+	if w.Code != http.StatusOK {
+		t.Fatalf("undo: status = %d (body: %s)", w.Code, w.Body.String())
+	}
+	last := (*received)[len(*received)-1]
+	if last.Path != "/graphql" || !strings.Contains(last.Body, "addBlockedBy(input: $input)") {
+		t.Errorf("undo sent %+v, want addBlockedBy", last)
+	}
+
+	w = serve(http.MethodGet, "/api/history")
+	var steps struct {
+		Undo []history.Step `json:"undo"`
+		Redo []history.Step `json:"redo"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &steps); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if len(steps.Undo) != 0 || len(steps.Redo) != 1 || steps.Redo[0].Label != "removeBlockedBy" {
+		t.Errorf("history = %+v", steps)
+	}
+
+	fail = true
+	w = serve(http.MethodPost, "/api/history/redo")
+	if w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), "Validation Failed") {
+		t.Fatalf("failed redo: status = %d (body: %s), want 502", w.Code, w.Body.String())
+	}
+	if _, redo := stack.Steps(); len(redo) != 1 {
+		t.Errorf("failed redo moved the step")
+	}
+
+	fail = false
+	if w := serve(http.MethodPost, "/api/history/redo"); w.Code != http.StatusOK {
+		t.Fatalf("redo: status = %d", w.Code)
+	}
+	if last := (*received)[len(*received)-1]; last.Body != forward {
+		t.Errorf("redo sent %s, want the original request", last.Body)
+	}
+
+	entries, _ := log.List()
+	var sources []string
+	for _, e := range entries {
+		sources = append(sources, e.Source+":"+e.Operation)
+	}
+	want := "console:removeBlockedBy undo:addBlockedBy redo:removeBlockedBy redo:removeBlockedBy"
+	if got := strings.Join(sources, " "); got != want {
+		t.Errorf("audit = %q, want %q", got, want)
+	}
+
+	if w := serve(http.MethodGet, "/api/history/undo"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET undo: status = %d, want 405", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/history/other"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown action: status = %d, want 404", w.Code)
+	}
+}
+
+func TestHistoryHandler_KeepsHistoryPerProject(t *testing.T) {
+	handler, stack, received := newHistoryHandler(t, func(upstreamRequest) (int, string) { return http.StatusOK, `{"data":{}}` })
+	h := &historyHandler{proxy: handler}
+
+	forward := `{"query":"mutation { removeBlockedBy(input: {issueId: \"I_1\", blockingIssueId: \"I_2\"}) { clientMutationId } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(forward))
+	req.Header.Set(ProjectHeader, "PVT_a")
+	handler.ServeGraphQLProxy(httptest.NewRecorder(), req)
+	sent := len(*received)
+
+	if undo, _ := stack.Steps(); len(undo) != 0 {
+		t.Errorf("change of PVT_a was recorded without a project: %+v", undo)
+	}
+	for _, path := range []string{"/api/history/undo?projectId=PVT_b", "/api/history/undo"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: status = %d, want 409", path, w.Code)
+		}
+	}
+	if len(*received) != sent {
+		t.Fatalf("undo in another project sent %+v", (*received)[sent:])
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/history/undo?projectId=PVT_a", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("undo in PVT_a: status = %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/history?projectId=../x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid project: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+const (
+	// pollerIdleTimeout is how long a project is refreshed after the browser last loaded it.
+	pollerIdleTimeout = 30 * time.Minute
+	// pollerBudgetReserve is the share of the GraphQL rate limit the poller leaves to the
+	// browser: it skips its rounds while less than 1/pollerBudgetReserve is left.
+	pollerBudgetReserve = 5
+)
+
+// poller keeps the cached items of the projects open in the browser fresh while the
+// console runs, so the UI loads from a warm cache and changes made on GitHub show up
+// without a manual refetch. Every interval it syncs each project the browser loaded
+// within pollerIdleTimeout, writing the results into the store like
+// GET /api/projects/{id}/graph does. It holds off while GitHub reports a secondary
+// rate limit or the GraphQL budget runs low, until the budget resets.
+type poller struct {
+	gateway   projectGateway
+	store     *cache.Store
+	rateLimit *rateLimiter
+	interval  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	projects map[string]time.Time
+	// budget is the GraphQL budget reported by the last sync, at budgetAt.
+	budget   *github.RateLimit
+	budgetAt time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func newPoller(gateway projectGateway, store *cache.Store, rateLimit *rateLimiter, interval time.Duration) *poller {
+	return &poller{
+		gateway:   gateway,
+		store:     store,
+		rateLimit: rateLimit,
+		interval:  interval,
+		now:       time.Now,
+		projects:  make(map[string]time.Time),
+	}
+}
+
+// watch marks a project as open in the browser. It does nothing on a nil poller.
+func (p *poller) watch(projectID string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.projects[projectID] = p.now()
+}
+
+// Start starts the polling goroutine.
+func (p *poller) Start() {
+	p.stopCh = make(chan struct{})
+	p.doneCh = make(chan struct{})
+	go func() {
+		defer close(p.doneCh)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.poll()
+			case <-p.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the polling goroutine, waiting for the round in progress.
+func (p *poller) Stop() {
+	close(p.stopCh)
+	<-p.doneCh
+}
+
+// poll runs one round. Errors are left for the next round or the browser's own fetch to report.
+func (p *poller) poll() {
+	for _, projectID := range p.active() {
+		if p.wait() > 0 {
+			return
+		}
+		synced, err := syncProject(p.gateway, p.store, projectID)
+		if err != nil || synced.RateLimit == nil {
+			continue
+		}
+		p.mu.Lock()
+		p.budget, p.budgetAt = synced.RateLimit, p.now()
+		p.mu.Unlock()
+	}
+}
+
+// active returns the projects loaded within pollerIdleTimeout and forgets the others.
+func (p *poller) active() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	threshold := p.now().Add(-pollerIdleTimeout)
+	var ids []string
+	for id, seen := range p.projects {
+		if seen.Before(threshold) {
+			delete(p.projects, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// wait returns how long the poller has to hold off: until a secondary rate limit ends,
+// or until the GraphQL budget resets while less than the reserve is left. The budget
+// is shared with the browser, so the one the proxy saw last is used if it is newer.
+func (p *poller) wait() time.Duration {
+	p.mu.Lock()
+	var limit, remaining int
+	var reset, updatedAt time.Time
+	if p.budget != nil {
+		limit, remaining, reset, updatedAt = p.budget.Limit, p.budget.Remaining, p.budget.ResetAt, p.budgetAt
+	}
+	p.mu.Unlock()
+
+	var until time.Time
+	if p.rateLimit != nil {
+		for _, h := range p.rateLimit.snapshot() {
+			if h.BlockedUntil != nil && h.BlockedUntil.After(until) {
+				until = *h.BlockedUntil
+			}
+			if r, ok := h.Resources["graphql"]; ok && r.Limit > 0 && r.UpdatedAt.After(updatedAt) {
+				limit, remaining, reset, updatedAt = r.Limit, r.Remaining, r.Reset, r.UpdatedAt
+			}
+		}
+	}
+	if limit > 0 && remaining < limit/pollerBudgetReserve && reset.After(until) {
+		until = reset
+	}
+	return max(until.Sub(p.now()), 0)
+}
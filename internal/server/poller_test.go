@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func TestPoller_RefreshesOpenProjects(t *testing.T) {
+	store := cache.NewStore(t.TempDir())
+	syncedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	gw := &fakeProjectGateway{items: []github.ProjectItem{{ID: "PVTI_1"}}, syncedAt: syncedAt}
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	p := newPoller(gw, store, nil, time.Minute)
+	p.now = func() time.Time { return now }
+
+	p.watch("PVT_old")
+	now = now.Add(pollerIdleTimeout + time.Second)
+	p.watch("PVT_2")
+	p.watch("PVT_1")
+	p.poll()
+
+	if want := []string{"PVT_1", "PVT_2"}; !slices.Equal(gw.synced, want) {
+		t.Errorf("synced = %v, want %v", gw.synced, want)
+	}
+	if items, got := store.SyncedItems("PVT_1"); items == nil || !got.Equal(syncedAt) {
+		t.Errorf("cache = %s at %v", items, got)
+	}
+}
+
+func TestPoller_HoldsOffOnLowBudget(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	gw := &fakeProjectGateway{rateLimit: &github.RateLimit{Limit: 5000, Remaining: 900, ResetAt: now.Add(10 * time.Minute)}}
+	p := newPoller(gw, cache.NewStore(t.TempDir()), nil, time.Minute)
+	p.now = func() time.Time { return now }
+	p.watch("PVT_1")
+	p.watch("PVT_2")
+
+	// The first sync reports that less than a fifth of the budget is left.
+	p.poll()
+	if len(gw.synced) != 1 {
+		t.Fatalf("synced = %v, want one project", gw.synced)
+	}
+	if got := p.wait(); got != 10*time.Minute {
+		t.Errorf("wait = %v, want 10m", got)
+	}
+
+	now = now.Add(10 * time.Minute)
+	p.poll()
+	if len(gw.synced) != 3 {
+		t.Errorf("synced = %v, want both projects after the reset", gw.synced)
+	}
+}
+
+func TestPoller_RespectsProxyRateLimit(t *testing.T) {
+	handler, _, _ := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/user", nil))
+
+	gw := &fakeProjectGateway{}
+	p := newPoller(gw, cache.NewStore(t.TempDir()), handler.rateLimit, time.Minute)
+	p.watch("PVT_1")
+	p.poll()
+	if len(gw.synced) != 0 {
+		t.Errorf("synced = %v, want none while GitHub is rate limiting", gw.synced)
+	}
+}
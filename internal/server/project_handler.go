@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+// projectGraphResponse は GET /api/projects/{id}/graph のレスポンス。
+type projectGraphResponse struct {
+	Issues       []github.Issue      `json:"issues"`
+	Dependencies []github.Dependency `json:"dependencies"`
+}
+
+// projectHandler は Go 側で組み立てたプロジェクトのデータを返す。
+type projectHandler struct {
+	gateway projectGateway
+	store   *cache.Store
+	// poller が nil ならバックグラウンドの更新は行わない
+	poller *poller
+}
+
+// ServeHTTP は GET /api/projects/{id}/graph を処理する。
+// キャッシュの item を前回の同期以降に更新された item だけ取り直して最新にし、Issue と依存関係を返す。
+// 同期ウォーターマークがなければ全 item を入れ子の connection まで辿って取得する。
+// 同期した item はブラウザと同じ形でウォーターマークとともにキャッシュに書き込む。
+func (h *projectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	projectID, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/projects/"), "/")
+	if !github.IsProjectID(projectID) {
+		writeError(w, http.StatusBadRequest, errCodeInvalidProjectID, "invalid project ID")
+		return
+	}
+	if sub != "graph" {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// 開いているプロジェクトはバックグラウンドでも最新に保つ
+	h.poller.watch(projectID)
+
+	synced, err := syncProject(h.gateway, h.store, projectID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, errCodeUpstream, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, projectGraphResponse{
+		Issues:       github.ParseProjectItems(synced.Items),
+		Dependencies: github.ParseProjectDependencies(synced.Items),
+	})
+}
+
+// syncProject はキャッシュの item を同期ウォーターマーク以降の変更で最新にし、キャッシュに書き戻す。
+// 同期している間に他の書き込みがあった場合は、そちらを残して書き戻さない。
+func syncProject(gateway projectGateway, store *cache.Store, projectID string) (*github.ProjectSync, error) {
+	c := store.GetCache(projectID)
+	raw, since := c.Items, c.SyncedAt
+	var cached []github.ProjectItem
+	if since.IsZero() || json.Unmarshal(raw, &cached) != nil {
+		// 読めないキャッシュは捨てて全件取得する
+		cached, since = nil, time.Time{}
+	}
+	synced, err := gateway.SyncProjectItems(projectID, cached, since)
+	if err != nil {
+		return nil, err
+	}
+	if raw, err := json.Marshal(synced.Items); err == nil {
+		_, _ = store.SetSyncedItemsIfRevision(projectID, raw, synced.SyncedAt, c.Revision)
+	}
+	return synced, nil
+}
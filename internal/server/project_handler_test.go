@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+)
+
+func TestServeProjectGraph(t *testing.T) {
+	var items []github.ProjectItem
+	json.Unmarshal([]byte(`[
+		{
+			"id": "PVTI_1",
+			"content": {
+				"id": "I_1", "number": 1, "title": "Epic", "state": "OPEN",
+				"repository": {"owner": {"login": "o"}, "name": "r"},
+				"subIssues": {"nodes": [{"number": 2, "repository": {"owner": {"login": "o"}, "name": "r"}}]}
+			},
+			"fieldValues": {"nodes": [{"field": {"id": "F_1"}, "optionId": "OPT_1"}]}
+		},
+		{"id": "PVTI_DRAFT", "content": {}, "fieldValues": {"nodes": []}}
+	]`), &items)
+	store := cache.NewStore(t.TempDir())
+	h := &projectHandler{gateway: &fakeProjectGateway{items: items}, store: store}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/projects/PVT_1/graph", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp projectGraphResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(resp.Issues) != 1 || resp.Issues[0].ID != "o/r#1" || resp.Issues[0].FieldValues["F_1"] != "OPT_1" {
+		t.Errorf("issues = %+v", resp.Issues)
+	}
+	want := github.Dependency{Source: "o/r#1", Target: "o/r#2", Type: github.DependencySubIssue}
+	if len(resp.Dependencies) != 1 || resp.Dependencies[0] != want {
+		t.Errorf("dependencies = %+v", resp.Dependencies)
+	}
+
+	// The fetched items are cached in the shape the web UI stores.
+	var cached []github.ProjectItem
+	if err := json.Unmarshal(store.GetCache("PVT_1").Items, &cached); err != nil || len(cached) != 2 {
+		t.Errorf("cached items = %s", store.GetCache("PVT_1").Items)
+	}
+}
+
+func TestServeProjectGraph_SyncsFromWatermark(t *testing.T) {
+	store := cache.NewStore(t.TempDir())
+	syncedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	gw := &fakeProjectGateway{items: []github.ProjectItem{{ID: "PVTI_1"}}, syncedAt: syncedAt}
+	h := &projectHandler{gateway: gw, store: store}
+
+	for i, wantSince := range []time.Time{{}, syncedAt} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/projects/PVT_1/graph", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d: %s", i, w.Code, w.Body.String())
+		}
+		if !gw.since.Equal(wantSince) {
+			t.Errorf("request %d: since = %v, want %v", i, gw.since, wantSince)
+		}
+	}
+	// The second sync starts from the items the first one cached.
+	if len(gw.cached) != 1 || gw.cached[0].ID != "PVTI_1" {
+		t.Errorf("cached = %+v", gw.cached)
+	}
+	if _, got := store.SyncedItems("PVT_1"); !got.Equal(syncedAt) {
+		t.Errorf("watermark = %v, want %v", got, syncedAt)
+	}
+}
+
+func TestServeProjectGraph_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		gateway    *fakeProjectGateway
+		wantStatus int
+	}{
+		{name: "invalid project ID", method: http.MethodGet, url: "/api/projects/../graph", gateway: &fakeProjectGateway{}, wantStatus: http.StatusBadRequest},
+		{name: "unknown sub-resource", method: http.MethodGet, url: "/api/projects/PVT_1/items", gateway: &fakeProjectGateway{}, wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPost, url: "/api/projects/PVT_1/graph", gateway: &fakeProjectGateway{}, wantStatus: http.StatusMethodNotAllowed},
+		{name: "upstream error", method: http.MethodGet, url: "/api/projects/PVT_1/graph", gateway: &fakeProjectGateway{err: errors.New("boom")}, wantStatus: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &projectHandler{gateway: tt.gateway, store: cache.NewStore(t.TempDir())}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -1,16 +1,42 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
+	"os"
 	"strings"
 
 	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
 )
 
+// maxInspectedBodyBytes caps request bodies that are inspected in read-only, dry-run and audit mode.
+const maxInspectedBodyBytes = 1 << 20
+
 type proxyHandler struct {
 	proxy      *httputil.ReverseProxy
 	pathPrefix string // "" for github.com, "/api/v3" for GHE
+	// readOnly rejects GraphQL mutations and REST calls other than GET and HEAD.
+	readOnly bool
+	// dryRun, when set, records write requests instead of forwarding them.
+	dryRun *dryRun
+	// audit, when set, records every write request that is forwarded to GitHub.
+	audit *audit.Log
+	// targets resolves the node IDs of the audited changes to composite IDs.
+	targets *audit.Resolver
+	// history, when set, records how to undo the dependency and field changes forwarded
+	// to GitHub, in the history of the project named by ProjectHeader.
+	history *history.Stacks
+	// coalesce, etag and rateLimit are the transports of proxy, outermost first.
+	// See coalescer, etagCache and rateLimiter.
+	coalesce  *coalescer
+	etag      *etagCache
+	rateLimit *rateLimiter
 }
 
 func newProxyHandler() (*proxyHandler, error) {
@@ -24,7 +50,11 @@ func newProxyHandler() (*proxyHandler, error) {
 // newProxyHandlerWith creates a proxyHandler with explicit configuration.
 // Exported for testing.
 func newProxyHandlerWith(scheme, apiHost, pathPrefix, token string) *proxyHandler {
+	rateLimit := newRateLimiter(http.DefaultTransport)
+	etag := newETagCache(rateLimit)
+	coalesce := newCoalescer(etag)
 	proxy := &httputil.ReverseProxy{
+		Transport: coalesce,
 		Director: func(req *http.Request) {
 			req.URL.Scheme = scheme
 			req.URL.Host = apiHost
@@ -35,7 +65,9 @@ func newProxyHandlerWith(scheme, apiHost, pathPrefix, token string) *proxyHandle
 		},
 	}
 
-	return &proxyHandler{proxy: proxy, pathPrefix: pathPrefix}
+	h := &proxyHandler{proxy: proxy, pathPrefix: pathPrefix, coalesce: coalesce, etag: etag, rateLimit: rateLimit}
+	h.targets = audit.NewResolver(h.query)
+	return h
 }
 
 // resolveGitHubAPI returns the API host and path prefix for the given GitHub host.
@@ -51,7 +83,38 @@ func resolveGitHubAPI(host string) (apiHost string, pathPrefix string) {
 func (h *proxyHandler) ServeRESTProxy(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/github/rest/")
 	if path == "" {
-		http.Error(w, "path required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeBadRequest, "path required")
+		return
+	}
+	if h.readOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusForbidden, errCodeReadOnly, "the console is in read-only mode: "+r.Method+" requests are not allowed")
+		return
+	}
+	if h.dryRun != nil {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.dryRun.serveREST(w, r, path)
+			return
+		}
+		if h.dryRun.serveSyntheticIssue(w, path) {
+			return
+		}
+	}
+	if (h.audit != nil || h.history != nil) && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		body, ok := readBody(w, r, maxInspectedBodyBytes)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.URL.Path = h.pathPrefix + "/" + path
+		h.forwardWrite(w, r, audit.Entry{
+			API:       "rest",
+			Method:    r.Method,
+			Path:      path,
+			Operation: r.Method + " " + path,
+			Variables: jsonOrNil(body),
+			Targets:   audit.RESTTargets(path, body),
+		}, restUndoStep(r.Method, path, body))
 		return
 	}
 	r.URL.Path = h.pathPrefix + "/" + path
@@ -60,13 +123,151 @@ func (h *proxyHandler) ServeRESTProxy(w http.ResponseWriter, r *http.Request) {
 
 func (h *proxyHandler) ServeGraphQLProxy(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
-		http.Error(w, "request body required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeBadRequest, "request body required")
+		return
+	}
+	if !h.readOnly && h.dryRun == nil && h.audit == nil && h.history == nil {
+		r.URL.Path = h.pathPrefix + "/graphql"
+		h.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	body, ops, ok := inspectGraphQL(w, r)
+	if !ok {
 		return
 	}
 	r.URL.Path = h.pathPrefix + "/graphql"
-	h.proxy.ServeHTTP(w, r)
+	if h.readOnly {
+		for _, op := range ops {
+			if op.Type != "query" {
+				writeError(w, http.StatusForbidden, errCodeReadOnly, "the console is in read-only mode: "+op.Type+" operations are not allowed")
+				return
+			}
+		}
+		h.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	op, ok := executedOperation(w, body, ops)
+	if !ok {
+		return
+	}
+	switch {
+	case op.Type != "mutation":
+		h.proxy.ServeHTTP(w, r)
+	case h.dryRun != nil:
+		h.dryRun.serveGraphQL(w, body, op)
+	default:
+		var req struct {
+			Variables json.RawMessage `json:"variables"`
+		}
+		json.Unmarshal(body, &req)
+		var step *history.Step
+		if h.history != nil {
+			var variables map[string]any
+			dec := json.NewDecoder(bytes.NewReader(req.Variables))
+			dec.UseNumber()
+			dec.Decode(&variables)
+			step = h.graphqlUndoStep(r.Context(), body, op, variables)
+		}
+		h.forwardWrite(w, r, audit.Entry{
+			API:       "graphql",
+			Operation: op.fieldNames(),
+			Variables: jsonOrNil(req.Variables),
+			Targets:   audit.GraphQLTargets(req.Variables),
+		}, step)
+	}
+}
+
+// forwardWrite forwards a write request. It appends entry to the audit log with the
+// status and, if any, the error message of the GitHub response, and pushes step,
+// if not nil, to the history when GitHub accepted the change.
+func (h *proxyHandler) forwardWrite(w http.ResponseWriter, r *http.Request, entry audit.Entry, step *history.Step) {
+	if h.audit != nil {
+		// Look the targets up before the change, while a deleted issue can still be found.
+		entry.Targets = h.targets.Resolve(r.Context(), entry.Targets)
+	}
+	rec := &responseRecorder{ResponseWriter: w}
+	h.proxy.ServeHTTP(rec, r)
+
+	// The change already reached GitHub, so failures below only leave a warning.
+	errMsg := rec.errorMessage()
+	if h.audit != nil {
+		entry.Source = audit.SourceConsole
+		entry.Status = rec.statusCode()
+		entry.Error = errMsg
+		if err := h.audit.Append(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if h.history != nil && step != nil && errMsg == "" && rec.statusCode() < 300 {
+		stack, err := h.historyOf(r.Header.Get(ProjectHeader))
+		if err == nil {
+			_, err = stack.Push(*step)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
+
+// jsonOrNil returns data unless it is empty or JSON null.
+func jsonOrNil(data []byte) json.RawMessage {
+	if t := bytes.TrimSpace(data); len(t) == 0 || string(t) == "null" {
+		return nil
+	}
+	return data
+}
+
+// inspectGraphQL reads and parses the GraphQL request body.
+// It replaces the body so the request can still be proxied.
+// When the request is invalid, the error response has already been written.
+func inspectGraphQL(w http.ResponseWriter, r *http.Request) ([]byte, []graphqlOperation, bool) {
+	body, ok := readBody(w, r, maxInspectedBodyBytes)
+	if !ok {
+		return nil, nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	var req struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "invalid GraphQL request")
+		return nil, nil, false
+	}
+	ops, err := parseGraphQLOperations(req.Query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidBody, "invalid GraphQL query: "+err.Error())
+		return nil, nil, false
+	}
+	return body, ops, true
+}
+
+// executedOperation returns the operation GitHub would execute for the request:
+// the one named by operationName, or the only one in the document.
+func executedOperation(w http.ResponseWriter, body []byte, ops []graphqlOperation) (graphqlOperation, bool) {
+	var req struct {
+		OperationName string `json:"operationName"`
+	}
+	json.Unmarshal(body, &req)
+	if req.OperationName == "" {
+		if len(ops) != 1 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidBody, "operationName is required when the query has several operations")
+			return graphqlOperation{}, false
+		}
+		return ops[0], true
+	}
+	for _, op := range ops {
+		if op.Name == req.OperationName {
+			return op, true
+		}
+	}
+	writeError(w, http.StatusBadRequest, errCodeInvalidBody, "unknown operation "+req.OperationName)
+	return graphqlOperation{}, false
 }
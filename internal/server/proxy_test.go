@@ -5,8 +5,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
 )
 
 func TestResolveGitHubAPI(t *testing.T) {
@@ -326,3 +330,207 @@ func TestServeGraphQLProxy_ErrorResponse(t *testing.T) {
 		t.Errorf("body = %q, want it to contain 'Bad credentials'", w.Body.String())
 	}
 }
+
+func TestProxy_ReadOnly(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "REST GET", method: http.MethodGet, path: "/api/github/rest/repos/owner/repo", wantStatus: http.StatusOK, wantCalled: true},
+		{name: "REST POST", method: http.MethodPost, path: "/api/github/rest/repos/owner/repo/issues", body: `{}`, wantStatus: http.StatusForbidden},
+		{name: "REST DELETE", method: http.MethodDelete, path: "/api/github/rest/repos/owner/repo/issues/1/sub_issue", wantStatus: http.StatusForbidden},
+		{name: "GraphQL query", method: http.MethodPost, path: "/api/github/graphql", body: `{"query":"{ viewer { login } }"}`, wantStatus: http.StatusOK, wantCalled: true},
+		{name: "GraphQL mutation", method: http.MethodPost, path: "/api/github/graphql", body: `{"query":"mutation { removeBlockedBy(input: {}) { clientMutationId } }"}`, wantStatus: http.StatusForbidden},
+		{name: "GraphQL invalid JSON", method: http.MethodPost, path: "/api/github/graphql", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			var receivedBody string
+			mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				b, _ := io.ReadAll(r.Body)
+				receivedBody = string(b)
+				w.Write([]byte(`{}`))
+			}))
+			defer mock.Close()
+
+			handler := newMockHandler(t, mock, "")
+			handler.readOnly = true
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			if strings.HasSuffix(tt.path, "/graphql") {
+				handler.ServeGraphQLProxy(w, req)
+			} else {
+				handler.ServeRESTProxy(w, req)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if called != tt.wantCalled {
+				t.Fatalf("upstream called = %v, want %v", called, tt.wantCalled)
+			}
+			if called && receivedBody != tt.body {
+				t.Errorf("upstream body = %q, want %q", receivedBody, tt.body)
+			}
+			if tt.wantStatus == http.StatusForbidden && !strings.Contains(w.Body.String(), errCodeReadOnly) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), errCodeReadOnly)
+			}
+		})
+	}
+}
+
+func TestProxy_Audit(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		upstream      string
+		upstreamCode  int
+		wantLogged    bool
+		wantOperation string
+		wantTargets   []string
+		wantError     string
+	}{
+		{name: "REST GET", method: http.MethodGet, path: "/api/github/rest/repos/o/r/issues/1", upstream: `{}`, upstreamCode: 200},
+		{
+			name: "REST PATCH", method: http.MethodPatch, path: "/api/github/rest/repos/o/r/issues/12", body: `{"state":"closed"}`,
+			upstream: `{}`, upstreamCode: 200,
+			wantLogged: true, wantOperation: "PATCH repos/o/r/issues/12", wantTargets: []string{"o/r#12"},
+		},
+		{
+			name: "REST error", method: http.MethodPost, path: "/api/github/rest/repos/o/r/issues/1/sub_issues", body: `{"sub_issue_id":42}`,
+			upstream: `{"message":"Validation Failed"}`, upstreamCode: 422,
+			wantLogged: true, wantOperation: "POST repos/o/r/issues/1/sub_issues", wantTargets: []string{"o/r#1", "42"}, wantError: "Validation Failed",
+		},
+		{name: "GraphQL query", method: http.MethodPost, path: "/api/github/graphql", body: `{"query":"{ viewer { login } }"}`, upstream: `{}`, upstreamCode: 200},
+		{
+			name: "GraphQL mutation", method: http.MethodPost, path: "/api/github/graphql",
+			body:     `{"query":"mutation($issueId: ID!, $subIssueId: ID!) { removeSubIssue(input: {issueId: $issueId, subIssueId: $subIssueId}) { issue { id } } }","variables":{"issueId":"I_1","subIssueId":"I_2"}}`,
+			upstream: `{"data":{"removeSubIssue":{"issue":{"id":"I_1"}}}}`, upstreamCode: 200,
+			wantLogged: true, wantOperation: "removeSubIssue", wantTargets: []string{"I_1", "I_2"},
+		},
+		{
+			name: "GraphQL mutation error", method: http.MethodPost, path: "/api/github/graphql",
+			body:     `{"query":"mutation { deleteIssue(input: {issueId: \"I_1\"}) { clientMutationId } }"}`,
+			upstream: `{"errors":[{"message":"Could not resolve to a node"}]}`, upstreamCode: 200,
+			wantLogged: true, wantOperation: "deleteIssue", wantError: "Could not resolve to a node",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedBody string
+			mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				receivedBody = string(b)
+				w.WriteHeader(tt.upstreamCode)
+				w.Write([]byte(tt.upstream))
+			}))
+			defer mock.Close()
+
+			handler := newMockHandler(t, mock, "")
+			log := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+			handler.audit = log
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			if strings.HasSuffix(tt.path, "/graphql") {
+				handler.ServeGraphQLProxy(w, req)
+			} else {
+				handler.ServeRESTProxy(w, req)
+			}
+
+			if w.Code != tt.upstreamCode || w.Body.String() != tt.upstream {
+				t.Fatalf("response = %d %s, want %d %s", w.Code, w.Body.String(), tt.upstreamCode, tt.upstream)
+			}
+			if receivedBody != tt.body {
+				t.Errorf("upstream body = %q, want %q", receivedBody, tt.body)
+			}
+
+			entries, err := log.List()
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if !tt.wantLogged {
+				if len(entries) != 0 {
+					t.Fatalf("expected no audit entries, got %+v", entries)
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(entries))
+			}
+			e := entries[0]
+			if e.Source != audit.SourceConsole || e.Operation != tt.wantOperation || e.Status != tt.upstreamCode || e.Error != tt.wantError {
+				t.Errorf("unexpected entry: %+v", e)
+			}
+			if !slices.Equal(e.Targets, tt.wantTargets) {
+				t.Errorf("targets = %v, want %v", e.Targets, tt.wantTargets)
+			}
+		})
+	}
+}
+
+func TestProxy_AuditResolvesNodeIDs(t *testing.T) {
+	var lookups int
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(b), "nodes(ids:") {
+			lookups++
+			w.Write([]byte(`{"data":{"nodes":[{"id":"I_1","number":1,"repository":{"nameWithOwner":"o/r"}},{"id":"I_2","number":2,"repository":{"nameWithOwner":"o/r"}}]}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"removeSubIssue":{"issue":{"id":"I_1"}}}}`))
+	}))
+	defer mock.Close()
+
+	handler := newMockHandler(t, mock, "")
+	log := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	handler.audit = log
+
+	body := `{"query":"mutation($issueId: ID!, $subIssueId: ID!) { removeSubIssue(input: {issueId: $issueId, subIssueId: $subIssueId}) { issue { id } } }","variables":{"issueId":"I_1","subIssueId":"I_2"}}`
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+
+	entries, err := log.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"I_1", "o/r#1", "I_2", "o/r#2"}
+	if len(entries) != 2 || !slices.Equal(entries[0].Targets, want) || !slices.Equal(entries[1].Targets, want) {
+		t.Fatalf("entries = %+v, want targets %v", entries, want)
+	}
+	if lookups != 1 {
+		t.Errorf("expected resolved IDs to be remembered, looked up %d times", lookups)
+	}
+}
+
+func TestProxy_AuditSkipsDryRun(t *testing.T) {
+	handler, _, called := newDryRunHandler(t)
+	log := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	handler.audit = log
+
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodPatch, "/api/github/rest/repos/o/r/issues/1", strings.NewReader(`{}`)))
+
+	if *called {
+		t.Fatal("write request was forwarded upstream")
+	}
+	if entries, _ := log.List(); len(entries) != 0 {
+		t.Errorf("dry-run change was audited: %+v", entries)
+	}
+}
@@ -0,0 +1,384 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitWait is the longest a request is held back for a rate limit. When
+	// GitHub asks to wait longer, the proxy answers 429 right away instead.
+	maxRateLimitWait = time.Minute
+	// maxRateLimitRetries is how many times a request rejected by a secondary rate limit is sent again.
+	maxRateLimitRetries = 2
+	// defaultSecondaryRateLimitWait is used for a 429 without Retry-After, as GitHub
+	// documents to wait at least a minute in that case.
+	defaultSecondaryRateLimitWait = time.Minute
+	// maxRateLimitBodyBytes caps the GraphQL responses that are inspected for rateLimit data.
+	maxRateLimitBodyBytes = 8 << 20
+)
+
+// rateLimitResource is the budget of a GitHub rate limit resource ("core", "graphql", "search", ...).
+type rateLimitResource struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	Reset     time.Time `json:"reset"`
+	// LastCost is the cost of the last GraphQL query that asked for rateLimit.
+	LastCost  int       `json:"lastCost,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// rateLimitHost is what is known about the rate limits of a GitHub API host.
+type rateLimitHost struct {
+	Resources map[string]rateLimitResource `json:"resources"`
+	// BlockedUntil is when the last secondary rate limit GitHub reported ends.
+	BlockedUntil *time.Time `json:"blockedUntil,omitempty"`
+}
+
+// rateLimiter is the transport of the GitHub proxy. It keeps the rate limit budget
+// of each host from the X-RateLimit-* headers and the GraphQL rateLimit data of the
+// responses. When GitHub rejects a request with a secondary rate limit (403 or 429
+// with Retry-After), it holds back the requests to that host until the limit ends
+// and sends the rejected request again; requests are also held back while a primary
+// budget is exhausted. When the wait would be longer than maxRateLimitWait, it
+// answers 429 in the errorEnvelope format with Retry-After.
+type rateLimiter struct {
+	next  http.RoundTripper
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	hosts map[string]*rateLimitHost
+}
+
+func newRateLimiter(next http.RoundTripper) *rateLimiter {
+	return &rateLimiter{
+		next:  next,
+		now:   time.Now,
+		sleep: sleepContext,
+		hosts: make(map[string]*rateLimitHost),
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *rateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host, resource := req.URL.Host, rateLimitResourceOf(req.URL.Path)
+
+	// Keep the body so the request can be sent again. Bodies too large to keep are sent once.
+	var body []byte
+	retryable := true
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(req.Body, maxInspectedBodyBytes+1))
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+		if len(body) > maxInspectedBodyBytes {
+			retryable = false
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		} else {
+			req.Body.Close()
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if wait := l.pending(host, resource); wait > maxRateLimitWait {
+			return rateLimitedResponse(req, wait), nil
+		} else if wait > 0 {
+			if err := l.sleep(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+
+		out := req
+		if retryable && body != nil {
+			out = req.Clone(req.Context())
+			out.Body = io.NopCloser(bytes.NewReader(body))
+			out.ContentLength = int64(len(body))
+		}
+		resp, err := l.next.RoundTrip(out)
+		if err != nil {
+			return nil, err
+		}
+		l.observe(host, resource, resp)
+
+		wait, limited := secondaryRateLimit(resp, l.now())
+		if !limited {
+			return resp, nil
+		}
+		l.block(host, wait)
+		if !retryable || attempt >= maxRateLimitRetries || wait > maxRateLimitWait {
+			resp.Body.Close()
+			return rateLimitedResponse(req, wait), nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// rateLimitResourceOf guesses the rate limit resource a request counts against,
+// for requests sent before a response told it.
+func rateLimitResourceOf(path string) string {
+	switch {
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	case strings.Contains(path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
+
+// pending returns how long a request to the host has to wait: until the secondary
+// rate limit ends, or until the budget resets when it is exhausted.
+func (l *rateLimiter) pending(host, resource string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		return 0
+	}
+	now := l.now()
+	var until time.Time
+	if h.BlockedUntil != nil {
+		until = *h.BlockedUntil
+	}
+	if r, ok := h.Resources[resource]; ok && r.Limit > 0 && r.Remaining == 0 && r.Reset.After(until) {
+		until = r.Reset
+	}
+	return max(until.Sub(now), 0)
+}
+
+func (l *rateLimiter) block(host string, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := l.host(host)
+	until := l.now().Add(wait)
+	if h.BlockedUntil == nil || until.After(*h.BlockedUntil) {
+		h.BlockedUntil = &until
+	}
+}
+
+// host returns the state of a host, creating it. l.mu must be held.
+func (l *rateLimiter) host(host string) *rateLimitHost {
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &rateLimitHost{Resources: make(map[string]rateLimitResource)}
+		l.hosts[host] = h
+	}
+	return h
+}
+
+// observe updates the budget from the X-RateLimit-* headers of resp and, for GraphQL
+// responses, arranges for the rateLimit data to be read once the body has been read.
+func (l *rateLimiter) observe(host, resource string, resp *http.Response) {
+	if name := resp.Header.Get("X-RateLimit-Resource"); name != "" {
+		resource = name
+	}
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		r := rateLimitResource{Limit: limit}
+		r.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+		r.Used, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Used"))
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			r.Reset = time.Unix(reset, 0)
+		}
+		l.update(host, resource, func(cur *rateLimitResource) {
+			cost := cur.LastCost
+			*cur = r
+			cur.LastCost = cost
+		})
+	}
+
+	if resource == "graphql" && resp.StatusCode == http.StatusOK && resp.Body != nil {
+		resp.Body = &rateLimitBody{ReadCloser: resp.Body, gzip: resp.Header.Get("Content-Encoding") == "gzip", done: func(data []byte, gz bool) {
+			l.observeGraphQL(host, data, gz)
+		}}
+	}
+}
+
+// observeGraphQL reads the rateLimit data of a GraphQL response, if the query asked for it.
+func (l *rateLimiter) observeGraphQL(host string, data []byte, gz bool) {
+	if gz {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return
+		}
+	}
+	// Most queries do not ask for it, so skip decoding the whole response.
+	if !bytes.Contains(data, []byte(`"rateLimit"`)) {
+		return
+	}
+	var resp struct {
+		Data struct {
+			RateLimit *struct {
+				Limit     int       `json:"limit"`
+				Remaining int       `json:"remaining"`
+				Used      int       `json:"used"`
+				Cost      int       `json:"cost"`
+				ResetAt   time.Time `json:"resetAt"`
+			} `json:"rateLimit"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Data.RateLimit == nil {
+		return
+	}
+	rl := resp.Data.RateLimit
+	l.update(host, "graphql", func(cur *rateLimitResource) {
+		// Only the fields the query selected are set.
+		if rl.Limit > 0 {
+			cur.Limit = rl.Limit
+			cur.Remaining = rl.Remaining
+		}
+		if rl.Used > 0 {
+			cur.Used = rl.Used
+		}
+		if !rl.ResetAt.IsZero() {
+			cur.Reset = rl.ResetAt
+		}
+		cur.LastCost = rl.Cost
+	})
+}
+
+func (l *rateLimiter) update(host, resource string, fn func(*rateLimitResource)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := l.host(host)
+	r := h.Resources[resource]
+	fn(&r)
+	r.UpdatedAt = l.now()
+	h.Resources[resource] = r
+}
+
+// snapshot returns a copy of the state of every host.
+func (l *rateLimiter) snapshot() map[string]rateLimitHost {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	hosts := make(map[string]rateLimitHost, len(l.hosts))
+	for name, h := range l.hosts {
+		c := rateLimitHost{Resources: maps.Clone(h.Resources)}
+		if h.BlockedUntil != nil && h.BlockedUntil.After(now) {
+			until := *h.BlockedUntil
+			c.BlockedUntil = &until
+		}
+		hosts[name] = c
+	}
+	return hosts
+}
+
+// secondaryRateLimit reports whether resp is a rate limit rejection and how long to wait.
+// A 403 without Retry-After or an exhausted budget is a permission error and is passed on.
+func secondaryRateLimit(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(max(secs, 0)) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return defaultSecondaryRateLimitWait, true
+	}
+	return 0, false
+}
+
+// rateLimitedResponse is the response the proxy returns instead of GitHub's when it
+// gives up waiting for a rate limit.
+func rateLimitedResponse(req *http.Request, wait time.Duration) *http.Response {
+	secs := int(math.Ceil(wait.Seconds()))
+	body, _ := json.Marshal(errorEnvelope{Error: errorBody{
+		Code:    errCodeRateLimited,
+		Message: fmt.Sprintf("GitHub rate limit exceeded, retry after %d seconds", secs),
+	}})
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Retry-After", strconv.Itoa(secs))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests)),
+		StatusCode:    http.StatusTooManyRequests,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// rateLimitBody passes a response body through and hands what was read to done
+// when the body is read to the end, unless it was larger than maxRateLimitBodyBytes.
+type rateLimitBody struct {
+	io.ReadCloser
+	gzip bool
+	done func(data []byte, gzip bool)
+
+	buf      bytes.Buffer
+	overflow bool
+	called   bool
+}
+
+func (b *rateLimitBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.overflow {
+		if b.buf.Len()+n > maxRateLimitBodyBytes {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !b.overflow && !b.called {
+		b.called = true
+		b.done(b.buf.Bytes(), b.gzip)
+	}
+	return n, err
+}
+
+// ServeRateLimit serves the rate limit budget of the GitHub hosts the proxy talks to.
+//
+//	GET /api/github/rate-limit  {"hosts": {"api.github.com": {"resources": {"core": {...}, "graphql": {...}}, "blockedUntil": "..."}}}
+func (h *proxyHandler) ServeRateLimit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"hosts": h.rateLimit.snapshot()})
+}
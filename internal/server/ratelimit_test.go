@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRateLimitHandler creates a proxyHandler whose rate limiter records the waits
+// instead of sleeping. The upstream answers with respond; calls counts its requests.
+func newRateLimitHandler(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, call int)) (*proxyHandler, *[]time.Duration, *int) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls int
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+		respond(w, r, call)
+	}))
+	t.Cleanup(mock.Close)
+
+	handler := newMockHandler(t, mock, "")
+	var slept []time.Duration
+	handler.rateLimit.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return handler, &slept, &calls
+}
+
+func rateLimitSnapshot(t *testing.T, handler *proxyHandler) rateLimitHost {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeRateLimit(w, httptest.NewRequest(http.MethodGet, "/api/github/rate-limit", nil))
+	var resp struct {
+		Hosts map[string]rateLimitHost `json:"hosts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body.String(), err)
+	}
+	if len(resp.Hosts) != 1 {
+		t.Fatalf("hosts = %v, want 1 host", resp.Hosts)
+	}
+	for _, h := range resp.Hosts {
+		return h
+	}
+	return rateLimitHost{}
+}
+
+func TestRateLimit_TracksHeaders(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Unix()
+	handler, _, _ := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.Header().Set("X-RateLimit-Used", "679")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.Write([]byte(`{}`))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/user", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	core := rateLimitSnapshot(t, handler).Resources["core"]
+	if core.Limit != 5000 || core.Remaining != 4321 || core.Used != 679 || core.Reset.Unix() != reset {
+		t.Errorf("core = %+v", core)
+	}
+}
+
+func TestRateLimit_TracksGraphQLRateLimitData(t *testing.T) {
+	handler, _, _ := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		w.Write([]byte(`{"data":{"viewer":{"login":"octocat"},"rateLimit":{"limit":5000,"remaining":4990,"used":10,"cost":3,"resetAt":"2030-01-01T00:00:00Z"}}}`))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(`{"query":"{ viewer { login } rateLimit { limit remaining used cost resetAt } }"}`)))
+	if !strings.Contains(w.Body.String(), "octocat") {
+		t.Fatalf("response not passed through: %s", w.Body.String())
+	}
+
+	gql := rateLimitSnapshot(t, handler).Resources["graphql"]
+	if gql.Remaining != 4990 || gql.LastCost != 3 || gql.Reset.Year() != 2030 {
+		t.Errorf("graphql = %+v", gql)
+	}
+}
+
+func TestRateLimit_RetriesAfterSecondaryLimit(t *testing.T) {
+	var bodies []string
+	handler, slept, calls := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if call == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	})
+
+	body := `{"query":"{ viewer { login } }"}`
+	w := httptest.NewRecorder()
+	handler.ServeGraphQLProxy(w, httptest.NewRequest(http.MethodPost, "/api/github/graphql", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"data":{}}` {
+		t.Fatalf("response = %d %s", w.Code, w.Body.String())
+	}
+	if *calls != 2 || bodies[1] != body {
+		t.Errorf("upstream calls = %d, bodies = %q", *calls, bodies)
+	}
+	if len(*slept) != 1 || (*slept)[0] <= time.Second || (*slept)[0] > 2*time.Second {
+		t.Errorf("slept = %v, want about 2s", *slept)
+	}
+}
+
+func TestRateLimit_GivesUpOnLongWaits(t *testing.T) {
+	handler, _, calls := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/user", nil))
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want 429", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "3600" && got != "3599" {
+			t.Errorf("Retry-After = %q", got)
+		}
+		var env errorEnvelope
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil || env.Error.Code != errCodeRateLimited {
+			t.Errorf("body = %s", w.Body.String())
+		}
+	}
+	// The second request is answered without asking GitHub again.
+	if *calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", *calls)
+	}
+	if h := rateLimitSnapshot(t, handler); h.BlockedUntil == nil {
+		t.Error("expected blockedUntil to be reported")
+	}
+}
+
+func TestRateLimit_PassesPermissionErrors(t *testing.T) {
+	handler, slept, calls := newRateLimitHandler(t, func(w http.ResponseWriter, r *http.Request, call int) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeRESTProxy(w, httptest.NewRequest(http.MethodGet, "/api/github/rest/repos/o/r", nil))
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "Resource not accessible") {
+		t.Errorf("response = %d %s", w.Code, w.Body.String())
+	}
+	if *calls != 1 || len(*slept) != 0 {
+		t.Errorf("calls = %d, slept = %v", *calls, *slept)
+	}
+}
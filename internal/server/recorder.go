@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// maxRecordedBodyBytes caps how much of a response body responseRecorder keeps.
+const maxRecordedBodyBytes = 64 << 10
+
+// responseRecorder passes a response through while remembering its status
+// and the beginning of its body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if room := maxRecordedBodyBytes - r.body.Len(); room > 0 {
+		r.body.Write(b[:min(len(b), room)])
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// errorMessage returns the GitHub error message of the recorded response.
+func (r *responseRecorder) errorMessage() string {
+	return githubErrorMessage(r.statusCode(), r.Header(), r.body.Bytes())
+}
+
+// githubErrorMessage returns the message of a GitHub error response: the "message"
+// of a REST error or the first entry of GraphQL "errors". The errorEnvelope the proxy
+// answers with when it gives up on a rate limit is read too. It returns "" for
+// successful responses and for bodies it cannot read.
+func githubErrorMessage(status int, header http.Header, data []byte) string {
+	var body io.Reader = bytes.NewReader(data)
+	if header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return ""
+		}
+		body = zr
+	}
+	// A truncated body fails to decode; the status is still recorded.
+	var resp struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+		Error *errorBody `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		if status >= 400 {
+			return http.StatusText(status)
+		}
+		return ""
+	}
+	switch {
+	case len(resp.Errors) > 0:
+		return resp.Errors[0].Message
+	case status >= 400:
+		if resp.Message != "" {
+			return resp.Message
+		}
+		if resp.Error != nil && resp.Error.Message != "" {
+			return resp.Error.Message
+		}
+		return http.StatusText(status)
+	}
+	return ""
+}
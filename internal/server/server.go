@@ -1,41 +1,197 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
+	"github.com/kmtym1998/gh-issue-treefier/internal/audit"
 	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+	"github.com/kmtym1998/gh-issue-treefier/internal/github"
+	"github.com/kmtym1998/gh-issue-treefier/internal/history"
+	"github.com/kmtym1998/gh-issue-treefier/internal/journal"
 )
 
+// defaultShutdownTimeout is how long Start waits for in-flight requests after ctx is canceled.
+const defaultShutdownTimeout = 10 * time.Second
+
+// Config configures a Server.
+type Config struct {
+	// Host is the address the listener is bound to. It is also accepted in the Host header.
+	Host       string
+	Port       int
+	CacheStore *cache.Store
+	Gateway    *github.ProjectGateway
+	// Token is the session token required on /api/ routes. A random token is generated if empty.
+	Token string
+	// ReadOnly makes the GitHub proxy reject every request that could modify data.
+	ReadOnly bool
+	// DryRunJournal, when set, makes the GitHub proxy record write requests in the
+	// journal and answer them with synthesized payloads instead of forwarding them.
+	DryRunJournal *journal.Journal
+	// AuditLog, when set, records every change the GitHub proxy sends to GitHub.
+	AuditLog *audit.Log
+	// History, when set, records the dependency and field changes made through the proxy,
+	// per project, and serves /api/history to undo and redo them. It is ignored in
+	// read-only and dry-run mode.
+	History *history.Stacks
+	// RefreshInterval, when positive, makes the server refresh the cached items of the
+	// projects open in the browser in the background at that interval.
+	RefreshInterval time.Duration
+}
+
 type Server struct {
-	port       int
-	cacheStore *cache.Store
+	host            string
+	port            int
+	cacheStore      *cache.Store
+	gateway         *github.ProjectGateway
+	token           string
+	readOnly        bool
+	dryRunJournal   *journal.Journal
+	auditLog        *audit.Log
+	history         *history.Stacks
+	refreshInterval time.Duration
+	poller          *poller
+	events          *eventsHandler
+	shutdownTimeout time.Duration
 }
 
-func New(port int, cacheStore *cache.Store) *Server {
+func New(cfg Config) *Server {
+	token := cfg.Token
+	if token == "" {
+		token = NewSessionToken()
+	}
 	return &Server{
-		port:       port,
-		cacheStore: cacheStore,
+		host:            cfg.Host,
+		port:            cfg.Port,
+		cacheStore:      cfg.CacheStore,
+		gateway:         cfg.Gateway,
+		token:           token,
+		readOnly:        cfg.ReadOnly,
+		dryRunJournal:   cfg.DryRunJournal,
+		auditLog:        cfg.AuditLog,
+		history:         cfg.History,
+		refreshInterval: cfg.RefreshInterval,
+		shutdownTimeout: defaultShutdownTimeout,
 	}
 }
 
-func (s *Server) Start(ln net.Listener) error {
-	mux := http.NewServeMux()
+// Token returns the session token the browser has to send with API requests.
+func (s *Server) Token() string {
+	return s.token
+}
+
+// Start serves HTTP on ln until ctx is canceled.
+// On cancellation it stops accepting connections, waits for in-flight requests
+// up to the shutdown timeout and then flushes the cache, even if draining timed out.
+// With a RefreshInterval, the background refresh runs alongside and is stopped before the flush.
+func (s *Server) Start(ctx context.Context, ln net.Listener) error {
+	handler, err := s.handler()
+	if err != nil {
+		return err
+	}
+	if s.poller != nil {
+		s.poller.Start()
+	}
+	return s.serve(ctx, ln, handler)
+}
+
+func (s *Server) handler() (http.Handler, error) {
+	api := http.NewServeMux()
 
 	// GitHub API proxy
 	proxy, err := newProxyHandler()
 	if err != nil {
-		return fmt.Errorf("failed to create proxy handler: %w", err)
+		return nil, fmt.Errorf("failed to create proxy handler: %w", err)
 	}
-	mux.HandleFunc("/api/github/rest/", proxy.ServeRESTProxy)
-	mux.HandleFunc("/api/github/graphql", proxy.ServeGraphQLProxy)
+	proxy.readOnly = s.readOnly
+	proxy.audit = s.auditLog
+	// 取り消しは GitHub に書き込むため、書き込まないモードでは無効にする
+	if s.history != nil && !s.readOnly && s.dryRunJournal == nil {
+		proxy.history = s.history
+		historyAPI := &historyHandler{proxy: proxy}
+		api.Handle("/api/history", historyAPI)
+		api.Handle("/api/history/", historyAPI)
+	}
+	if s.dryRunJournal != nil {
+		proxy.dryRun = newDryRun(s.dryRunJournal)
+		api.Handle("/api/dry-run/journal", &dryRunJournalHandler{journal: s.dryRunJournal})
+	}
+	api.HandleFunc("/api/github/rest/", proxy.ServeRESTProxy)
+	api.HandleFunc("/api/github/graphql", proxy.ServeGraphQLProxy)
+	api.HandleFunc("/api/github/rate-limit", proxy.ServeRateLimit)
+	api.HandleFunc("/api/github/metrics", proxy.ServeMetrics)
+	api.Handle("/api/batch", &batchHandler{proxy: proxy})
+
+	// Console config API
+	api.Handle("/api/config", &configHandler{readOnly: s.readOnly, dryRun: s.dryRunJournal != nil})
 
 	// Cache API
-	mux.Handle("/api/cache/", &cacheHandler{store: s.cacheStore})
+	api.Handle("/api/cache/", &cacheHandler{store: s.cacheStore})
+	s.events = newEventsHandler(s.cacheStore)
+	api.Handle("/api/events", s.events)
+
+	// Graph analysis API
+	analysis := &analysisHandler{gateway: s.gateway}
+	api.HandleFunc("/api/analysis/critical-path", analysis.ServeCriticalPath)
+
+	// Project graph API
+	if s.refreshInterval > 0 {
+		s.poller = newPoller(s.gateway, s.cacheStore, proxy.rateLimit, s.refreshInterval)
+	}
+	api.Handle("/api/projects/", &projectHandler{gateway: s.gateway, store: s.cacheStore, poller: s.poller})
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", &apiGuard{host: s.host, token: s.token, next: api})
 
 	// Static file serving with SPA fallback
 	mux.Handle("/", newSPAHandler())
 
-	return http.Serve(ln, mux)
+	return mux, nil
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Event streams never finish on their own, so end them when shutting down.
+	if s.events != nil {
+		httpServer.RegisterOnShutdown(s.events.close)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		defer cancel()
+		if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+			// Drop the connections that did not finish in time so Serve returns.
+			httpServer.Close()
+			err = fmt.Errorf("failed to drain in-flight requests: %w", shutdownErr)
+		}
+		<-serveErr
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+
+	// Let the background refresh finish its writes before the final flush.
+	if s.poller != nil {
+		s.poller.Stop()
+	}
+	if flushErr := s.cacheStore.FlushAll(); flushErr != nil {
+		err = errors.Join(err, flushErr)
+	}
+	return err
 }
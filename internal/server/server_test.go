@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kmtym1998/gh-issue-treefier/internal/cache"
+)
+
+func listenLocal(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	return ln
+}
+
+func readCacheFile(t *testing.T, dir, projectID string) cache.ProjectCache {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, projectID+".json"))
+	if err != nil {
+		t.Fatalf("expected cache file to be written: %v", err)
+	}
+	var c cache.ProjectCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("failed to unmarshal cache file: %v", err)
+	}
+	return c
+}
+
+func TestStart_ShutdownFlushesCache(t *testing.T) {
+	dir := t.TempDir()
+	srv := New(Config{CacheStore: cache.NewStore(dir)})
+	ln := listenLocal(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx, ln) }()
+
+	body := `{"o/r#1":{"x":10,"y":20}}`
+	req, _ := http.NewRequest(http.MethodPut, "http://"+ln.Addr().String()+"/api/cache/PVT_proj1/node-positions", strings.NewReader(body))
+	req.Header.Set(SessionTokenHeader, srv.Token())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	c := readCacheFile(t, dir, "PVT_proj1")
+	if pos := c.NodePositions["o/r#1"]; pos.X != 10 || pos.Y != 20 {
+		t.Fatalf("expected flushed position (10,20), got %v", c.NodePositions)
+	}
+}
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	srv := New(Config{CacheStore: cache.NewStore(t.TempDir())})
+	ln := listenLocal(t)
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.serve(ctx, ln, handler) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		resCh <- result{body: string(b), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-resCh
+	if res.err != nil {
+		t.Fatalf("in-flight request failed: %v", res.err)
+	}
+	if res.body != "done" {
+		t.Fatalf("expected body 'done', got %q", res.body)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServe_DrainTimeoutStillFlushes(t *testing.T) {
+	dir := t.TempDir()
+	store := cache.NewStore(dir)
+	srv := New(Config{CacheStore: store})
+	srv.shutdownTimeout = 50 * time.Millisecond
+	ln := listenLocal(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.serve(ctx, ln, handler) }()
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	store.SetItems("PVT_proj1", json.RawMessage(`[1]`))
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected drain timeout error, got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	if c := readCacheFile(t, dir, "PVT_proj1"); string(c.Items) != "[1]" {
+		t.Fatalf("expected flushed items [1], got %s", c.Items)
+	}
+}
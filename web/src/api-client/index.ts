@@ -1,3 +1,5 @@
+import type { Dependency, Issue } from "../types/issue";
+
 export class APIError extends Error {
   status: number;
   statusText: string;
@@ -12,18 +14,63 @@ export class APIError extends Error {
   }
 }
 
+const SESSION_TOKEN_HEADER = "X-Treefier-Token";
+const SESSION_TOKEN_STORAGE_KEY = "gh-issue-treefier:session-token";
+const PROJECT_HEADER = "X-Treefier-Project";
+
+// CLI が起動時に URL に付与したセッショントークンを読み取る。
+// フィルタ操作でクエリが書き換えられても使えるよう sessionStorage にも保存しておく。
+const readSessionToken = (): string | null => {
+  if (typeof window === "undefined") {
+    return null;
+  }
+  const token = new URLSearchParams(window.location.search).get("token");
+  if (token) {
+    window.sessionStorage.setItem(SESSION_TOKEN_STORAGE_KEY, token);
+    return token;
+  }
+  return window.sessionStorage.getItem(SESSION_TOKEN_STORAGE_KEY);
+};
+
+const sessionToken = readSessionToken();
+
+// 表示中のプロジェクト。サーバーは変更をこのプロジェクトの取り消し履歴に記録する
+let currentProjectId = "";
+
+export const setCurrentProject = (projectId: string): void => {
+  currentProjectId = projectId;
+};
+
+const withSessionToken = (options?: RequestInit): RequestInit | undefined => {
+  if (!sessionToken && !currentProjectId) {
+    return options;
+  }
+  const headers = new Headers(options?.headers);
+  if (sessionToken) {
+    headers.set(SESSION_TOKEN_HEADER, sessionToken);
+  }
+  if (currentProjectId) {
+    headers.set(PROJECT_HEADER, currentProjectId);
+  }
+  return { ...options, headers };
+};
+
+const toAPIError = async (response: Response): Promise<APIError> => {
+  const text = await response.text();
+  let body: unknown = text;
+  try {
+    body = JSON.parse(text);
+  } catch {
+    // keep as text
+  }
+  return new APIError(response.status, response.statusText, body);
+};
+
 const request = async <T>(url: string, options?: RequestInit): Promise<T> => {
-  const response = await fetch(url, options);
+  const response = await fetch(url, withSessionToken(options));
 
   if (!response.ok) {
-    const text = await response.text();
-    let body: unknown = text;
-    try {
-      body = JSON.parse(text);
-    } catch {
-      // keep as text
-    }
-    throw new APIError(response.status, response.statusText, body);
+    throw await toAPIError(response);
   }
 
   const text = await response.text();
@@ -80,11 +127,127 @@ export const graphql = <T = unknown>(
   });
 };
 
+export interface ConsoleConfig {
+  readOnly: boolean;
+  // true の場合、書き込みは GitHub に送られずジャーナルに記録される
+  dryRun: boolean;
+}
+
+export const getConfig = (): Promise<ConsoleConfig> => {
+  return request<ConsoleConfig>("/api/config");
+};
+
 export interface CacheData {
+  // 内容が変わるたびに増える番号。書き込み時に渡すと、その間に他のタブや sync コマンドが
+  // 書き込んでいた場合に 412 で拒否される
+  revision: number;
   items: unknown[] | null;
   nodePositions: Record<string, { x: number; y: number }>;
 }
 
+export interface HistoryStep {
+  id: number;
+  time: string;
+  label: string;
+}
+
+const historyURL = (action: string, projectId: string): string =>
+  projectId
+    ? `/api/history/${action}?projectId=${encodeURIComponent(projectId)}`
+    : `/api/history/${action}`;
+
+// プロジェクトで最後に行った変更を取り消す。取り消せる変更がない場合は 409 になる
+export const historyUndo = (
+  projectId: string,
+): Promise<{ step: HistoryStep }> => {
+  return request<{ step: HistoryStep }>(historyURL("undo", projectId), {
+    method: "POST",
+  });
+};
+
+// プロジェクトで最後に取り消した変更をやり直す。やり直せる変更がない場合は 409 になる
+export const historyRedo = (
+  projectId: string,
+): Promise<{ step: HistoryStep }> => {
+  return request<{ step: HistoryStep }>(historyURL("redo", projectId), {
+    method: "POST",
+  });
+};
+
+// プロジェクトの全 Issue と依存関係を取得する。サーバーは取得した items をキャッシュにも書き込む
+export const getProjectGraph = (
+  projectId: string,
+): Promise<{ issues: Issue[]; dependencies: Dependency[] }> => {
+  return request<{ issues: Issue[]; dependencies: Dependency[] }>(
+    `/api/projects/${projectId}/graph`,
+  );
+};
+
+export interface RateLimitResource {
+  limit: number;
+  remaining: number;
+  used: number;
+  reset: string;
+  lastCost?: number;
+  updatedAt: string;
+}
+
+export interface RateLimitHost {
+  resources: Record<string, RateLimitResource>;
+  // 二次レート制限が解除される時刻。制限中でなければ undefined
+  blockedUntil?: string;
+}
+
+// GitHub API ホストごとのレート制限の残量を取得する
+export const getRateLimit = (): Promise<{
+  hosts: Record<string, RateLimitHost>;
+}> => {
+  return request<{ hosts: Record<string, RateLimitHost> }>(
+    "/api/github/rate-limit",
+  );
+};
+
+export type BatchOp =
+  | "addBlockedBy"
+  | "removeBlockedBy"
+  | "addSubIssue"
+  | "removeSubIssue"
+  | "updateProjectV2ItemFieldValue"
+  | "clearProjectV2ItemFieldValue";
+
+export interface BatchOperation {
+  op: BatchOp;
+  // 各 mutation の input と同じ形
+  input: Record<string, unknown>;
+}
+
+export interface BatchResult {
+  index: number;
+  op: BatchOp;
+  status: "succeeded" | "failed" | "skipped" | "rolled_back" | "rollback_failed";
+  error?: string;
+  rollbackError?: string;
+}
+
+export interface BatchResponse {
+  results: BatchResult[];
+  succeeded: number;
+  failed: number;
+  rolledBack: number;
+}
+
+// 依存関係とフィールドの変更をまとめて送る。atomic の場合、失敗があれば成功した変更を可能な範囲で元に戻す
+export const batch = (
+  operations: BatchOperation[],
+  options: { atomic?: boolean; concurrency?: number } = {},
+): Promise<BatchResponse> => {
+  return request<BatchResponse>("/api/batch", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ operations, ...options }),
+  });
+};
+
 export const cacheFlush = (): Promise<void> => {
   return request<void>("/api/cache/flush", { method: "POST" });
 };
@@ -93,30 +256,134 @@ export const cacheGet = (projectId: string): Promise<CacheData> => {
   return request<CacheData>(`/api/cache/${projectId}`);
 };
 
+// キャッシュへの書き込みを送り、書き込み後のリビジョンを返す。
+// revision を渡すと If-Match を付け、リビジョンが変わっていれば 412 の APIError になる
+const cacheWrite = async (
+  url: string,
+  options: RequestInit,
+  revision?: number,
+): Promise<number | null> => {
+  const headers = new Headers(options.headers);
+  if (revision !== undefined) {
+    headers.set("If-Match", `"${revision}"`);
+  }
+  const response = await fetch(url, withSessionToken({ ...options, headers }));
+  if (!response.ok) {
+    throw await toAPIError(response);
+  }
+  return parseRevision(response.headers.get("ETag"));
+};
+
+const parseRevision = (etag: string | null): number | null => {
+  const match = etag?.match(/^(?:W\/)?"(\d+)"$/);
+  return match ? Number(match[1]) : null;
+};
+
 export const cachePutItems = (
   projectId: string,
   items: unknown[],
-): Promise<void> => {
-  return request<void>(`/api/cache/${projectId}/items`, {
-    method: "PUT",
-    headers: { "Content-Type": "application/json" },
-    body: JSON.stringify(items),
-  });
+  revision?: number,
+): Promise<number | null> => {
+  return cacheWrite(
+    `/api/cache/${projectId}/items`,
+    {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(items),
+    },
+    revision,
+  );
 };
 
-export const cacheDeleteItems = (projectId: string): Promise<void> => {
-  return request<void>(`/api/cache/${projectId}/items`, {
-    method: "DELETE",
-  });
+export const cacheDeleteItems = (
+  projectId: string,
+  revision?: number,
+): Promise<number | null> => {
+  return cacheWrite(
+    `/api/cache/${projectId}/items`,
+    { method: "DELETE" },
+    revision,
+  );
 };
 
 export const cachePutNodePositions = (
   projectId: string,
   positions: Record<string, { x: number; y: number }>,
-): Promise<void> => {
-  return request<void>(`/api/cache/${projectId}/node-positions`, {
-    method: "PUT",
-    headers: { "Content-Type": "application/json" },
-    body: JSON.stringify(positions),
-  });
+  revision?: number,
+): Promise<number | null> => {
+  return cacheWrite(
+    `/api/cache/${projectId}/node-positions`,
+    {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(positions),
+    },
+    revision,
+  );
+};
+
+export interface CacheChange {
+  projectId: string;
+  // 変更後のリビジョン
+  revision: number;
+  // true の場合、items の内容が変わった
+  items?: boolean;
+  // 座標が変わったノード
+  nodePositions?: Record<string, { x: number; y: number }>;
+}
+
+export interface CacheChangeHandlers {
+  onChange: (change: CacheChange) => void;
+  // 接続が切れて通知を取りこぼした可能性がある場合に、再接続後に呼ばれる
+  onReset?: () => void;
+}
+
+// ブラウザのオリジンあたりの同時接続数は限られるため、EventSource はプロジェクトごとに 1 本を共有する
+const eventStreams = new Map<
+  string,
+  { source: EventSource; handlers: Set<CacheChangeHandlers> }
+>();
+
+// プロジェクトのキャッシュの変更（バックグラウンド更新・sync コマンド・他のタブ）を
+// /api/events の Server-Sent Events で購読する。戻り値の関数で購読をやめる
+export const subscribeCacheChanges = (
+  projectId: string,
+  handlers: CacheChangeHandlers,
+): (() => void) => {
+  if (typeof EventSource === "undefined") {
+    return () => {};
+  }
+  let stream = eventStreams.get(projectId);
+  if (!stream) {
+    const params = new URLSearchParams({ projectId });
+    if (sessionToken) {
+      params.set("token", sessionToken);
+    }
+    const source = new EventSource(`/api/events?${params}`);
+    const created = { source, handlers: new Set<CacheChangeHandlers>() };
+    let opened = false;
+    source.addEventListener("change", (event) => {
+      const change = JSON.parse((event as MessageEvent).data) as CacheChange;
+      for (const h of created.handlers) h.onChange(change);
+    });
+    // EventSource は切断されると自動で再接続する。2 回目以降の接続は取りこぼしがあり得る
+    source.addEventListener("open", () => {
+      if (opened) {
+        for (const h of created.handlers) h.onReset?.();
+      }
+      opened = true;
+    });
+    eventStreams.set(projectId, created);
+    stream = created;
+  }
+  stream.handlers.add(handlers);
+
+  const current = stream;
+  return () => {
+    current.handlers.delete(handlers);
+    if (current.handlers.size === 0) {
+      current.source.close();
+      eventStreams.delete(projectId);
+    }
+  };
 };
@@ -1,12 +1,20 @@
 import { Box, Typography } from "@mui/material";
 import { useCallback, useEffect, useMemo, useRef, useState } from "react";
-import { cacheFlush } from "../api-client";
+import {
+  APIError,
+  cacheFlush,
+  historyRedo,
+  historyUndo,
+  setCurrentProject,
+} from "../api-client";
+import { useConsoleConfig } from "../hooks/use-console-config";
 import { useFilterQueryParams } from "../hooks/use-filter-query-params";
 import { useIssueMutations } from "../hooks/use-issue-mutations";
 import { useOptimisticIssues } from "../hooks/use-optimistic-issues";
 import { usePendingNodePositions } from "../hooks/use-pending-node-positions";
 import { buildIssueId, useProjectIssues } from "../hooks/use-project-issues";
 import { useProjectFields } from "../hooks/use-projects";
+import { invalidateCache } from "../lib/cache";
 import type { Dependency, DependencyType, Issue } from "../types/issue";
 import { FilterPanel, type FilterValues } from "./filter-panel";
 import { IssueCreateForm } from "./issue-create-form";
@@ -16,6 +24,8 @@ import { ItemSearchForm } from "./item-search-form";
 
 export const IssueDashboard = () => {
   const { initialFilters, syncToUrl } = useFilterQueryParams();
+  // 読み取り専用モードでは編集用のハンドラを渡さず、編集操作の UI を表示しない
+  const { readOnly, dryRun } = useConsoleConfig();
 
   const [filters, setFilters] = useState<FilterValues>({
     owner: "",
@@ -86,6 +96,53 @@ export const IssueDashboard = () => {
 
   const { fields: projectFields } = useProjectFields(filters.projectId);
 
+  // 変更はプロジェクトごとの履歴に記録されるため、送信時に表示中のプロジェクトを伝える
+  useEffect(() => {
+    setCurrentProject(filters.projectId);
+  }, [filters.projectId]);
+
+  // Cmd+Z / Ctrl+Z で最後の依存関係・フィールドの変更を取り消し、Shift 付きでやり直す。
+  // 履歴はサーバー側にあるため、実行後はプロジェクトを取得し直す
+  const handleHistory = useCallback(
+    async (action: "undo" | "redo") => {
+      try {
+        await (action === "undo"
+          ? historyUndo(filters.projectId)
+          : historyRedo(filters.projectId));
+        if (filters.projectId) {
+          await invalidateCache(filters.projectId);
+        }
+        refetch();
+      } catch (err) {
+        if (err instanceof APIError && err.status === 409) return;
+        const message = err instanceof Error ? err.message : String(err);
+        setMutationError(
+          `${action === "undo" ? "取り消し" : "やり直し"}に失敗しました: ${message}`,
+        );
+      }
+    },
+    [filters.projectId, refetch],
+  );
+
+  useEffect(() => {
+    if (readOnly || dryRun) return;
+    const handler = (e: KeyboardEvent) => {
+      if (!(e.metaKey || e.ctrlKey) || e.key.toLowerCase() !== "z") return;
+      // 入力欄ではブラウザ標準の取り消しを使う
+      const target = e.target as HTMLElement | null;
+      if (
+        target?.isContentEditable ||
+        target?.tagName === "INPUT" ||
+        target?.tagName === "TEXTAREA"
+      )
+        return;
+      e.preventDefault();
+      handleHistory(e.shiftKey ? "redo" : "undo");
+    };
+    window.addEventListener("keydown", handler);
+    return () => window.removeEventListener("keydown", handler);
+  }, [handleHistory, readOnly, dryRun]);
+
   const mutations = useIssueMutations(filters.projectId);
 
   const { allIssues, addOptimisticIssue, updateOptimisticIssue } =
@@ -452,6 +509,16 @@ export const IssueDashboard = () => {
 
           {hasQuery && !loading && allIssues.length > 0 && (
             <>
+              {dryRun && (
+                <Typography
+                  variant="body2"
+                  color="warning.main"
+                  sx={{ position: "absolute", top: 8, left: 8, zIndex: 10 }}
+                >
+                  ドライラン中: 変更は GitHub に送信されません（gh
+                  issue-treefier apply で反映）
+                </Typography>
+              )}
               {isRevalidating && (
                 <Typography
                   variant="body2"
@@ -467,13 +534,18 @@ export const IssueDashboard = () => {
                 projectId={filters.projectId}
                 projectFields={projectFields}
                 pendingNodePositions={pendingNodePositions}
-                onEdgeDelete={handleEdgeDelete}
-                onEdgeAdd={handleEdgeAdd}
-                onCreateIssue={handleCreateIssue}
-                onAddIssue={handleAddIssue}
-                onAddPR={handleAddPR}
-                onEditIssue={handleEditIssueFromContext}
-                onDeleteIssue={handleDeleteIssueFromContext}
+                onEdgeDelete={readOnly ? undefined : handleEdgeDelete}
+                onEdgeAdd={readOnly ? undefined : handleEdgeAdd}
+                onCreateIssue={readOnly ? undefined : handleCreateIssue}
+                onAddIssue={readOnly ? undefined : handleAddIssue}
+                onAddPR={readOnly ? undefined : handleAddPR}
+                onEditIssue={
+                  readOnly ? undefined : handleEditIssueFromContext
+                }
+                onDeleteIssue={
+                  readOnly ? undefined : handleDeleteIssueFromContext
+                }
+                readOnly={readOnly}
               />
             </>
           )}
@@ -526,9 +598,9 @@ export const IssueDashboard = () => {
           <IssueDetail
             issue={selectedIssue}
             onClose={() => setSelectedIssueId(null)}
-            onAddSubIssue={handleAddSubIssue}
-            onAddBlockedBy={handleAddBlockedBy}
-            onUpdate={handleIssueUpdate}
+            onAddSubIssue={readOnly ? undefined : handleAddSubIssue}
+            onAddBlockedBy={readOnly ? undefined : handleAddBlockedBy}
+            onUpdate={readOnly ? undefined : handleIssueUpdate}
             projectId={filters.projectId}
             projectFields={projectFields}
           />
@@ -290,28 +290,30 @@ export const IssueDetail = ({
           onBodyChange={setEditBody}
           onAssigneesChange={setEditAssignees}
           onFieldValuesChange={setEditFieldValues}
-          disabled={editSubmitting}
+          disabled={editSubmitting || !onUpdate}
           state={editState}
           onStateChange={setEditState}
         />
-        <Stack direction="row-reverse" gap={1}>
-          <Button
-            type="submit"
-            variant="contained"
-            color="success"
-            disabled={editSubmitting || !editTitle.trim()}
-          >
-            {editSubmitting ? "保存中..." : "保存"}
-          </Button>
-          <Button
-            type="button"
-            variant="text"
-            onClick={onClose}
-            disabled={editSubmitting}
-          >
-            キャンセル
-          </Button>
-        </Stack>
+        {onUpdate && (
+          <Stack direction="row-reverse" gap={1}>
+            <Button
+              type="submit"
+              variant="contained"
+              color="success"
+              disabled={editSubmitting || !editTitle.trim()}
+            >
+              {editSubmitting ? "保存中..." : "保存"}
+            </Button>
+            <Button
+              type="button"
+              variant="text"
+              onClick={onClose}
+              disabled={editSubmitting}
+            >
+              キャンセル
+            </Button>
+          </Stack>
+        )}
       </Box>
 
       {onAddSubIssue && (
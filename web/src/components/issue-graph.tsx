@@ -23,6 +23,7 @@ import {
 } from "@xyflow/react";
 import ELK, { type ElkNode } from "elkjs/lib/elk.bundled.js";
 import { useCallback, useEffect, useMemo, useRef, useState } from "react";
+import { subscribeCacheChanges } from "../api-client";
 // TODO: issue-graph.tsx が getNodePositions/setNodePositions を直接操作している。
 // usePendingNodePositions フックと同じキャッシュを独立して操作しているため、
 // 将来整合性が崩れるリスクがある。キャッシュ操作をフック経由に統一すべき。
@@ -407,6 +408,8 @@ export interface IssueGraphProps {
   onAddPR?: (flowPosition: { x: number; y: number }) => void;
   onEditIssue?: (issue: Issue) => void;
   onDeleteIssue?: (issue: Issue) => void;
+  /** true の場合、依存関係の追加やイシューの作成などの編集操作を表示しない */
+  readOnly?: boolean;
 }
 
 export const IssueGraph = (props: IssueGraphProps) => {
@@ -427,6 +430,7 @@ const IssueGraphInner = ({
   onAddPR,
   onEditIssue,
   onDeleteIssue,
+  readOnly = false,
 }: IssueGraphProps) => {
   const [layoutedNodes, setLayoutedNodes] = useState<Node[] | null>(null);
 
@@ -578,6 +582,7 @@ const IssueGraphReady = ({
   }, [projectId, initialNodes, setNodes]);
 
   const saveTimerRef = useRef<ReturnType<typeof setTimeout> | null>(null);
+  const movedNodeIdsRef = useRef(new Set<string>());
   const nodesRef = useRef(nodes);
   nodesRef.current = nodes;
 
@@ -585,15 +590,22 @@ const IssueGraphReady = ({
     (changes: Parameters<typeof onNodesChangeOriginal>[0]) => {
       onNodesChangeOriginal(changes);
 
-      const hasDragEnd = changes.some(
+      const dragEnded = changes.filter(
         (c) => c.type === "position" && c.dragging === false,
       );
-      if (!hasDragEnd) return;
+      if (dragEnded.length === 0) return;
+      for (const c of dragEnded) {
+        if (c.type === "position") movedNodeIdsRef.current.add(c.id);
+      }
 
+      // 動かしたノードだけを保存し、他のタブで動かしたノードの座標を上書きしない
       if (saveTimerRef.current) clearTimeout(saveTimerRef.current);
       saveTimerRef.current = setTimeout(() => {
+        const moved = movedNodeIdsRef.current;
+        movedNodeIdsRef.current = new Set();
         const positions: Record<string, { x: number; y: number }> = {};
         for (const node of nodesRef.current) {
+          if (!moved.has(node.id)) continue;
           positions[node.id] = { x: node.position.x, y: node.position.y };
         }
         setNodePositions(projectId, positions);
@@ -602,6 +614,27 @@ const IssueGraphReady = ({
     [onNodesChangeOriginal, projectId],
   );
 
+  // 他のタブで動かしたノードの座標を反映する。ドラッグ中のノードはそのままにする
+  useEffect(() => {
+    if (!projectId) return;
+    return subscribeCacheChanges(projectId, {
+      onChange: (change) => {
+        const moved = change.nodePositions;
+        if (!moved) return;
+        setNodes((prev) =>
+          prev.map((node) => {
+            const pos = moved[node.id];
+            if (!pos || node.dragging) return node;
+            if (pos.x === node.position.x && pos.y === node.position.y) {
+              return node;
+            }
+            return { ...node, position: pos };
+          }),
+        );
+      },
+    });
+  }, [projectId, setNodes]);
+
   const [selectedCount, setSelectedCount] = useState(0);
 
   const handleSelectionChange = useCallback(
@@ -811,30 +844,34 @@ const IssueGraphReady = ({
   return (
     <div style={{ width: "100%", height: "100%" }}>
       <div style={graphStyles.modeBar}>
-        <button
-          type="button"
-          style={{
-            ...graphStyles.modeButton,
-            ...(connectionMode === "sub_issue"
-              ? graphStyles.modeButtonActiveSubIssue
-              : {}),
-          }}
-          onClick={() => setConnectionMode("sub_issue")}
-        >
-          Sub-Issue
-        </button>
-        <button
-          type="button"
-          style={{
-            ...graphStyles.modeButton,
-            ...(connectionMode === "blocked_by"
-              ? graphStyles.modeButtonActiveBlockedBy
-              : {}),
-          }}
-          onClick={() => setConnectionMode("blocked_by")}
-        >
-          Blocked By
-        </button>
+        {!readOnly && (
+          <>
+            <button
+              type="button"
+              style={{
+                ...graphStyles.modeButton,
+                ...(connectionMode === "sub_issue"
+                  ? graphStyles.modeButtonActiveSubIssue
+                  : {}),
+              }}
+              onClick={() => setConnectionMode("sub_issue")}
+            >
+              Sub-Issue
+            </button>
+            <button
+              type="button"
+              style={{
+                ...graphStyles.modeButton,
+                ...(connectionMode === "blocked_by"
+                  ? graphStyles.modeButtonActiveBlockedBy
+                  : {}),
+              }}
+              onClick={() => setConnectionMode("blocked_by")}
+            >
+              Blocked By
+            </button>
+          </>
+        )}
 
         {selectableFields.length > 0 && (
           <div style={{ marginLeft: 8 }}>
@@ -886,10 +923,11 @@ const IssueGraphReady = ({
         onNodesChange={onNodesChange}
         onSelectionChange={handleSelectionChange}
         onNodeContextMenu={handleNodeContextMenu}
-        onPaneContextMenu={handlePaneContextMenu}
+        onPaneContextMenu={readOnly ? undefined : handlePaneContextMenu}
         onPaneClick={handlePaneClick}
         onEdgeClick={handleEdgeClick}
         onConnect={handleConnect}
+        nodesConnectable={!readOnly}
         connectionLineStyle={connectionLineStyle}
         selectionOnDrag
         selectionMode={SelectionMode.Partial}
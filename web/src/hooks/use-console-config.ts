@@ -0,0 +1,19 @@
+import { useEffect, useState } from "react";
+import { type ConsoleConfig, getConfig } from "../api-client";
+
+// サーバーが起動時のフラグから返すコンソールの設定。
+// 取得できるまでは編集可能として扱う（書き込みの拒否はサーバー側で行われる）。
+export const useConsoleConfig = (): ConsoleConfig => {
+  const [config, setConfig] = useState<ConsoleConfig>({
+    readOnly: false,
+    dryRun: false,
+  });
+
+  useEffect(() => {
+    getConfig()
+      .then(setConfig)
+      .catch(() => {});
+  }, []);
+
+  return config;
+};
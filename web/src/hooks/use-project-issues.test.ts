@@ -1,6 +1,7 @@
 // @vitest-environment jsdom
 import { renderHook, waitFor } from "@testing-library/react";
 import { beforeEach, describe, expect, it, vi } from "vitest";
+import { getCachedItems } from "../lib/cache";
 import { buildIssueId, useProjectIssues } from "./use-project-issues";
 
 const mockFetch = vi.fn();
@@ -8,7 +9,6 @@ vi.stubGlobal("fetch", mockFetch);
 
 vi.mock("../lib/cache", () => ({
   getCachedItems: vi.fn().mockResolvedValue(null),
-  setCachedItems: vi.fn().mockResolvedValue(undefined),
   invalidateCache: vi.fn(),
 }));
 
@@ -25,17 +25,15 @@ const jsonResponse = (body: unknown, status = 200, statusText = "OK") =>
     headers: { "Content-Type": "application/json" },
   });
 
-const makeGraphQLResponse = (items: unknown[]) =>
-  jsonResponse({
-    data: {
-      node: {
-        items: {
-          pageInfo: { hasNextPage: false, endCursor: null },
-          nodes: items,
-        },
-      },
-    },
+// キャッシュした items を返し、サーバーからの取得は完了させない（キャッシュ側の変換を検証する）
+const mockCachedItems = (items: unknown[]) => {
+  vi.mocked(getCachedItems).mockResolvedValueOnce({
+    projectId: "PVT_1",
+    items: items as never,
+    cachedAt: Date.now(),
   });
+  mockFetch.mockReturnValueOnce(new Promise(() => {}));
+};
 
 const makeItem = (
   overrides: {
@@ -120,20 +118,18 @@ describe("buildIssueId", () => {
 
 describe("useProjectIssues", () => {
   it("fetches and parses project items", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          number: 1,
-          title: "Bug fix",
-          state: "OPEN",
-          body: "Fix the login bug",
-          labels: [{ name: "bug", color: "d73a4a" }],
-          assignees: [
-            { login: "alice", avatarUrl: "https://example.com/alice.png" },
-          ],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        number: 1,
+        title: "Bug fix",
+        state: "OPEN",
+        body: "Fix the login bug",
+        labels: [{ name: "bug", color: "d73a4a" }],
+        assignees: [
+          { login: "alice", avatarUrl: "https://example.com/alice.png" },
+        ],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -163,12 +159,10 @@ describe("useProjectIssues", () => {
   });
 
   it("filters out DraftIssues (content === null)", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({ number: 1 }),
-        makeItem({ id: "PVTI_2", contentNull: true }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({ number: 1 }),
+      makeItem({ id: "PVTI_2", contentNull: true }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -182,12 +176,10 @@ describe("useProjectIssues", () => {
   });
 
   it("filters out DraftIssues (content === empty object)", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({ number: 1 }),
-        { id: "PVTI_DRAFT", content: {}, fieldValues: { nodes: [] } },
-      ]),
-    );
+    mockCachedItems([
+      makeItem({ number: 1 }),
+      { id: "PVTI_DRAFT", content: {}, fieldValues: { nodes: [] } },
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -201,9 +193,7 @@ describe("useProjectIssues", () => {
   });
 
   it("handles null body", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([makeItem({ body: null })]),
-    );
+    mockCachedItems([makeItem({ body: null })]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -224,14 +214,12 @@ describe("useProjectIssues", () => {
   });
 
   it("builds dependency edges from subIssues", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          number: 1,
-          subIssues: [{ number: 10 }, { number: 11 }],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        number: 1,
+        subIssues: [{ number: 10 }, { number: 11 }],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -248,16 +236,14 @@ describe("useProjectIssues", () => {
   });
 
   it("handles cross-repo sub-issues", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          number: 1,
-          owner: "org",
-          repo: "frontend",
-          subIssues: [{ number: 5, owner: "org", repo: "backend" }],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        number: 1,
+        owner: "org",
+        repo: "frontend",
+        subIssues: [{ number: 5, owner: "org", repo: "backend" }],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -277,14 +263,12 @@ describe("useProjectIssues", () => {
   });
 
   it("builds blocked_by edges from blockedBy field", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          number: 5,
-          blockedBy: [{ number: 3 }],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        number: 5,
+        blockedBy: [{ number: 3 }],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -300,14 +284,12 @@ describe("useProjectIssues", () => {
   });
 
   it("builds blocked_by edges from blocking field", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          number: 3,
-          blocking: [{ number: 5 }],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        number: 3,
+        blocking: [{ number: 5 }],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -323,20 +305,18 @@ describe("useProjectIssues", () => {
   });
 
   it("deduplicates blocked_by edges from both blockedBy and blocking", async () => {
-    mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({
-          id: "PVTI_1",
-          number: 5,
-          blockedBy: [{ number: 3 }],
-        }),
-        makeItem({
-          id: "PVTI_2",
-          number: 3,
-          blocking: [{ number: 5 }],
-        }),
-      ]),
-    );
+    mockCachedItems([
+      makeItem({
+        id: "PVTI_1",
+        number: 5,
+        blockedBy: [{ number: 3 }],
+      }),
+      makeItem({
+        id: "PVTI_2",
+        number: 3,
+        blocking: [{ number: 5 }],
+      }),
+    ]);
 
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1" }),
@@ -354,14 +334,113 @@ describe("useProjectIssues", () => {
     ]);
   });
 
-  it("filters issues by state option", async () => {
+  it("fetches the assembled graph from the server", async () => {
+    const issue = {
+      id: "owner/repo#1",
+      itemId: "PVTI_1",
+      number: 1,
+      owner: "owner",
+      repo: "repo",
+      title: "Epic",
+      state: "open",
+      body: "",
+      labels: [],
+      assignees: [],
+      url: "https://github.com/owner/repo/issues/1",
+      fieldValues: { F_1: "OPT_1" },
+    };
+    const dependency = {
+      source: "owner/repo#1",
+      target: "owner/repo#2",
+      type: "sub_issue",
+    };
     mockFetch.mockResolvedValueOnce(
-      makeGraphQLResponse([
-        makeItem({ number: 1, state: "OPEN" }),
-        makeItem({ id: "PVTI_2", number: 2, state: "CLOSED" }),
-      ]),
+      jsonResponse({ issues: [issue], dependencies: [dependency] }),
+    );
+
+    const { result } = renderHook(() =>
+      useProjectIssues({ projectId: "PVT_1" }),
+    );
+
+    await waitFor(() => {
+      expect(result.current.issues).toHaveLength(1);
+    });
+
+    expect(mockFetch.mock.calls[0][0]).toBe("/api/projects/PVT_1/graph");
+    expect(result.current.issues).toEqual([issue]);
+    expect(result.current.dependencies).toEqual([dependency]);
+  });
+
+  it("replaces cached items with the fetched graph", async () => {
+    vi.mocked(getCachedItems).mockResolvedValueOnce({
+      projectId: "PVT_1",
+      items: [makeItem({ number: 1, title: "Cached" })] as never,
+      cachedAt: Date.now(),
+    });
+    mockFetch.mockResolvedValueOnce(
+      jsonResponse({
+        issues: [
+          {
+            id: "owner/repo#1",
+            number: 1,
+            owner: "owner",
+            repo: "repo",
+            title: "Fresh",
+            state: "open",
+            body: "",
+            labels: [],
+            assignees: [],
+            url: "https://github.com/owner/repo/issues/1",
+            fieldValues: {},
+          },
+        ],
+        dependencies: [],
+      }),
+    );
+
+    const { result } = renderHook(() =>
+      useProjectIssues({ projectId: "PVT_1" }),
     );
 
+    await waitFor(() => {
+      expect(result.current.issues[0]?.title).toBe("Fresh");
+    });
+    expect(result.current.isRevalidating).toBe(false);
+  });
+
+  it("filters issues by field values", async () => {
+    mockCachedItems([
+      makeItem({
+        number: 1,
+        fieldValues: [{ field: { id: "F_1" }, optionId: "OPT_1" }],
+      }),
+      makeItem({
+        id: "PVTI_2",
+        number: 2,
+        fieldValues: [{ field: { id: "F_1" }, optionId: "OPT_2" }],
+      }),
+    ]);
+
+    const { result } = renderHook(() =>
+      useProjectIssues({
+        projectId: "PVT_1",
+        fieldFilters: { F_1: "OPT_2" },
+      }),
+    );
+
+    await waitFor(() => {
+      expect(result.current.issues).toHaveLength(1);
+    });
+
+    expect(result.current.issues[0].number).toBe(2);
+  });
+
+  it("filters issues by state option", async () => {
+    mockCachedItems([
+      makeItem({ number: 1, state: "OPEN" }),
+      makeItem({ id: "PVTI_2", number: 2, state: "CLOSED" }),
+    ]);
+
     const { result } = renderHook(() =>
       useProjectIssues({ projectId: "PVT_1", state: "open" }),
     );
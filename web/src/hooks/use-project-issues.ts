@@ -1,6 +1,6 @@
 import { useCallback, useEffect, useMemo, useRef, useState } from "react";
-import { graphql } from "../api-client";
-import { getCachedItems, setCachedItems } from "../lib/cache";
+import { getProjectGraph, subscribeCacheChanges } from "../api-client";
+import { getCachedItems } from "../lib/cache";
 import type { GitHubProjectV2Item } from "../types/github";
 import type { Dependency, Issue } from "../types/issue";
 
@@ -19,55 +19,13 @@ export interface UseProjectIssuesResult {
   refetch: () => void;
 }
 
-const PROJECT_ITEMS_QUERY = `
-  query($projectId: ID!, $cursor: String) {
-    node(id: $projectId) {
-      ... on ProjectV2 {
-        items(first: 100, after: $cursor) {
-          pageInfo { hasNextPage endCursor }
-          nodes {
-            id
-            content {
-              ... on Issue {
-                number title state body url
-                repository { owner { login } name }
-                labels(first: 20) { nodes { name color } }
-                assignees(first: 10) { nodes { login avatarUrl } }
-                subIssues(first: 50) {
-                  nodes { number repository { owner { login } name } }
-                }
-                blockedBy(first: 50) {
-                  nodes { number repository { owner { login } name } }
-                }
-                blocking(first: 50) {
-                  nodes { number repository { owner { login } name } }
-                }
-              }
-            }
-            fieldValues(first: 20) {
-              nodes {
-                ... on ProjectV2ItemFieldSingleSelectValue { field { ... on ProjectV2FieldCommon { id } } optionId }
-                ... on ProjectV2ItemFieldIterationValue { field { ... on ProjectV2FieldCommon { id } } iterationId }
-              }
-            }
-          }
-        }
-      }
-    }
-  }
-`;
-
-interface ProjectItemsGraphQLResponse {
-  data: {
-    node: {
-      items: {
-        pageInfo: { hasNextPage: boolean; endCursor: string | null };
-        nodes: GitHubProjectV2Item[];
-      };
-    };
-  };
+interface ProjectGraph {
+  issues: Issue[];
+  dependencies: Dependency[];
 }
 
+const EMPTY_GRAPH: ProjectGraph = { issues: [], dependencies: [] };
+
 /**
  * owner/repo#number 形式の複合 ID を生成する。
  */
@@ -97,8 +55,9 @@ const isIssueContent = (
   content != null && "number" in content;
 
 /**
- * GraphQL ProjectV2 Items から内部の Issue 型に変換する。
+ * キャッシュした ProjectV2 Items から内部の Issue 型に変換する。
  * DraftIssue (content が空オブジェクトまたは null) は除外する。
+ * サーバーの /api/projects/{id}/graph (github.ParseProjectItems) と同じ変換。
  */
 const parseProjectItems = (items: GitHubProjectV2Item[]): Issue[] =>
   items
@@ -139,7 +98,7 @@ const parseProjectItems = (items: GitHubProjectV2Item[]): Issue[] =>
     });
 
 /**
- * GraphQL ProjectV2 Items から subIssues / blockedBy / blocking を使って依存関係を構築する。
+ * キャッシュした ProjectV2 Items から subIssues / blockedBy / blocking を使って依存関係を構築する。
  * blockedBy / blocking は双方向からパースし、重複は Set で排除する。
  */
 const parseProjectDependencies = (
@@ -202,47 +161,17 @@ const parseProjectDependencies = (
  * field フィルタに一致するか判定する。
  */
 const matchesFieldFilters = (
-  item: GitHubProjectV2Item,
+  issue: Issue,
   fieldFilters: Record<string, string>,
-): boolean => {
-  for (const [fieldId, value] of Object.entries(fieldFilters)) {
-    if (!value) continue;
-    const match = item.fieldValues.nodes.some(
-      (fv) =>
-        fv.field?.id === fieldId &&
-        (fv.optionId === value || fv.iterationId === value),
-    );
-    if (!match) return false;
-  }
-  return true;
-};
-
-const fetchAllItems = async (
-  projectId: string,
-): Promise<GitHubProjectV2Item[]> => {
-  const allItems: GitHubProjectV2Item[] = [];
-  let cursor: string | null = null;
-  let hasNextPage = true;
-
-  while (hasNextPage) {
-    const res: ProjectItemsGraphQLResponse =
-      await graphql<ProjectItemsGraphQLResponse>(PROJECT_ITEMS_QUERY, {
-        projectId,
-        cursor,
-      });
-    const page = res.data.node.items;
-    allItems.push(...page.nodes);
-    cursor = page.pageInfo.endCursor;
-    hasNextPage = page.pageInfo.hasNextPage;
-  }
-
-  return allItems;
-};
+): boolean =>
+  Object.entries(fieldFilters).every(
+    ([fieldId, value]) => !value || issue.fieldValues[fieldId] === value,
+  );
 
 export const useProjectIssues = (
   options: UseProjectIssuesOptions,
 ): UseProjectIssuesResult => {
-  const [rawItems, setRawItems] = useState<GitHubProjectV2Item[]>([]);
+  const [graph, setGraph] = useState<ProjectGraph>(EMPTY_GRAPH);
   const [loading, setLoading] = useState(false);
   const [isRevalidating, setIsRevalidating] = useState(false);
   const [error, setError] = useState<Error | null>(null);
@@ -271,7 +200,10 @@ export const useProjectIssues = (
           const cached = await getCachedItems(options.projectId);
           if (cached) {
             if (!cancelled) {
-              setRawItems(cached.items);
+              setGraph({
+                issues: parseProjectItems(cached.items),
+                dependencies: parseProjectDependencies(cached.items),
+              });
               setIsRevalidating(true);
             }
           } else {
@@ -283,12 +215,11 @@ export const useProjectIssues = (
           if (!cancelled) setIsRevalidating(true);
         }
 
-        // API からフェッチ
-        const items = await fetchAllItems(options.projectId);
+        // サーバーが前回の同期以降の変更を GitHub から取り込んで組み立てる（items はサーバーがキャッシュに書き込む）
+        const fetched = await getProjectGraph(options.projectId);
         if (cancelled) return;
 
-        setRawItems(items);
-        await setCachedItems(options.projectId, items);
+        setGraph(fetched);
       } catch (err) {
         if (!cancelled) {
           setError(err instanceof Error ? err : new Error(String(err)));
@@ -307,28 +238,53 @@ export const useProjectIssues = (
     };
   }, [options.projectId, refetchKey]);
 
-  // フィルタ適用は useMemo で rawItems から導出
+  // バックグラウンド更新・sync コマンド・他のタブがキャッシュの items を書き換えたら読み直す
+  useEffect(() => {
+    if (!options.projectId) return;
+
+    let cancelled = false;
+    const reload = async () => {
+      const cached = await getCachedItems(options.projectId);
+      if (cancelled || !cached) return;
+      setGraph({
+        issues: parseProjectItems(cached.items),
+        dependencies: parseProjectDependencies(cached.items),
+      });
+    };
+    const unsubscribe = subscribeCacheChanges(options.projectId, {
+      onChange: (change) => {
+        if (change.items) reload();
+      },
+      onReset: reload,
+    });
+    return () => {
+      cancelled = true;
+      unsubscribe();
+    };
+  }, [options.projectId]);
+
+  // フィルタ適用は useMemo で全 Issue から導出
   const issues = useMemo(() => {
     const ff = options.fieldFilters;
-    const filtered =
+    let filtered =
       ff && Object.keys(ff).length > 0
-        ? rawItems.filter((item) => matchesFieldFilters(item, ff))
-        : rawItems;
-
-    let parsed = parseProjectItems(filtered);
+        ? graph.issues.filter((issue) => matchesFieldFilters(issue, ff))
+        : graph.issues;
 
     if (options.state && options.state !== "all") {
-      parsed = parsed.filter((i) => i.state === options.state);
+      filtered = filtered.filter((i) => i.state === options.state);
     }
 
-    return parsed;
-  }, [rawItems, options.state, options.fieldFilters]);
-
-  // 依存関係は全 rawItems から導出
-  const dependencies = useMemo(
-    () => parseProjectDependencies(rawItems),
-    [rawItems],
-  );
-
-  return { issues, dependencies, loading, isRevalidating, error, refetch };
+    return filtered;
+  }, [graph.issues, options.state, options.fieldFilters]);
+
+  // 依存関係はフィルタに関係なく全件を返す
+  return {
+    issues,
+    dependencies: graph.dependencies,
+    loading,
+    isRevalidating,
+    error,
+    refetch,
+  };
 };
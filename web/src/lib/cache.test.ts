@@ -4,7 +4,6 @@ import {
   getCachedItems,
   getNodePositions,
   invalidateCache,
-  setCachedItems,
   setNodePositions,
 } from "./cache";
 
@@ -46,10 +45,27 @@ const mockFetchOk = (body: unknown, status = 200) => {
   );
 };
 
-const mockFetchNoContent = () => {
-  fetchMock.mockResolvedValueOnce(new Response(null, { status: 204 }));
+const mockFetchNoContent = (revision?: number) => {
+  fetchMock.mockResolvedValueOnce(
+    new Response(null, {
+      status: 204,
+      headers: revision === undefined ? {} : { ETag: `"${revision}"` },
+    }),
+  );
+};
+
+const mockFetchConflict = (revision: number) => {
+  fetchMock.mockResolvedValueOnce(
+    new Response(JSON.stringify({ error: { code: "precondition_failed" } }), {
+      status: 412,
+      headers: { ETag: `"${revision}"` },
+    }),
+  );
 };
 
+const ifMatchOf = (call: unknown[]): string | null =>
+  new Headers((call[1] as RequestInit).headers).get("If-Match");
+
 const mockFetchError = () => {
   fetchMock.mockRejectedValueOnce(new Error("network error"));
 };
@@ -62,17 +78,9 @@ describe("cache (server-backed)", () => {
     expect(fetchMock).toHaveBeenCalledWith("/api/cache/project-1", undefined);
   });
 
-  it("round-trips get/set", async () => {
+  it("returns cached items", async () => {
     const items = [makeItem(1), makeItem(2)];
-    mockFetchNoContent(); // setCachedItems PUT
-    await setCachedItems("project-1", items);
-
-    expect(fetchMock).toHaveBeenCalledWith(
-      "/api/cache/project-1/items",
-      expect.objectContaining({ method: "PUT" }),
-    );
-
-    mockFetchOk({ items, nodePositions: {} }); // getCachedItems GET
+    mockFetchOk({ items, nodePositions: {} });
     const result = await getCachedItems("project-1");
     expect(result).not.toBeNull();
     expect(result?.projectId).toBe("project-1");
@@ -134,3 +142,45 @@ describe("nodePositions (server-backed)", () => {
     expect(result).toBeNull();
   });
 });
+
+describe("revisions", () => {
+  it("sends the revision it read as If-Match", async () => {
+    mockFetchOk({
+      revision: 3,
+      items: null,
+      nodePositions: { n1: { x: 1, y: 1 } },
+    });
+    await getNodePositions("project-rev");
+
+    mockFetchNoContent(4);
+    await setNodePositions("project-rev", { n1: { x: 2, y: 2 } });
+    expect(ifMatchOf(fetchMock.mock.calls[1])).toBe('"3"');
+
+    // 書き込み後は ETag のリビジョンを使う
+    mockFetchNoContent(5);
+    await setNodePositions("project-rev", { n1: { x: 3, y: 3 } });
+    expect(ifMatchOf(fetchMock.mock.calls[2])).toBe('"4"');
+  });
+
+  it("reloads and retries node positions once on 412", async () => {
+    mockFetchOk({ revision: 1, items: null, nodePositions: {} });
+    await getNodePositions("project-conflict");
+
+    const positions = { n1: { x: 5, y: 5 } };
+    mockFetchConflict(2);
+    mockFetchOk({
+      revision: 2,
+      items: null,
+      nodePositions: { n2: { x: 0, y: 0 } },
+    });
+    mockFetchNoContent(3);
+    await setNodePositions("project-conflict", positions);
+
+    expect(fetchMock).toHaveBeenCalledTimes(4);
+    expect(fetchMock.mock.calls[2][0]).toBe("/api/cache/project-conflict");
+    expect(ifMatchOf(fetchMock.mock.calls[3])).toBe('"2"');
+    expect(fetchMock.mock.calls[3][1]).toEqual(
+      expect.objectContaining({ body: JSON.stringify(positions) }),
+    );
+  });
+});
@@ -1,8 +1,8 @@
 import {
+  APIError,
   type CacheData,
   cacheDeleteItems,
   cacheGet,
-  cachePutItems,
   cachePutNodePositions,
 } from "../api-client";
 import type { GitHubProjectV2Item } from "../types/github";
@@ -18,11 +18,34 @@ export interface SavedNodePositions {
   positions: Record<string, { x: number; y: number }>;
 }
 
+// プロジェクトごとに、このタブが最後に読み書きしたキャッシュのリビジョン。
+// 書き込み時に If-Match で送り、他のタブや sync コマンドの書き込みを黙って上書きしないようにする
+const revisions = new Map<string, number>();
+
+const loadCache = async (projectId: string): Promise<CacheData> => {
+  const data = await cacheGet(projectId);
+  if (typeof data.revision === "number") {
+    revisions.set(projectId, data.revision);
+  }
+  return data;
+};
+
+const rememberRevision = (projectId: string, revision: number | null) => {
+  if (revision === null) {
+    revisions.delete(projectId);
+  } else {
+    revisions.set(projectId, revision);
+  }
+};
+
+const isConflict = (err: unknown): boolean =>
+  err instanceof APIError && err.status === 412;
+
 export const getCachedItems = async (
   projectId: string,
 ): Promise<CacheEntry | null> => {
   try {
-    const data: CacheData = await cacheGet(projectId);
+    const data: CacheData = await loadCache(projectId);
     if (!data.items) return null;
     return {
       projectId,
@@ -34,20 +57,10 @@ export const getCachedItems = async (
   }
 };
 
-export const setCachedItems = async (
-  projectId: string,
-  items: GitHubProjectV2Item[],
-): Promise<void> => {
-  try {
-    await cachePutItems(projectId, items);
-  } catch {
-    // no-op
-  }
-};
-
 export const invalidateCache = async (projectId: string): Promise<void> => {
   try {
-    await cacheDeleteItems(projectId);
+    // 無効化は他の書き込みより優先してよいので、リビジョンは確かめない
+    rememberRevision(projectId, await cacheDeleteItems(projectId));
   } catch {
     // no-op
   }
@@ -57,7 +70,7 @@ export const getNodePositions = async (
   projectId: string,
 ): Promise<SavedNodePositions | null> => {
   try {
-    const data: CacheData = await cacheGet(projectId);
+    const data: CacheData = await loadCache(projectId);
     if (!data.nodePositions || Object.keys(data.nodePositions).length === 0) {
       return null;
     }
@@ -72,8 +85,29 @@ export const setNodePositions = async (
   positions: Record<string, { x: number; y: number }>,
 ): Promise<void> => {
   try {
-    await cachePutNodePositions(projectId, positions);
-  } catch {
-    // no-op
+    rememberRevision(
+      projectId,
+      await cachePutNodePositions(
+        projectId,
+        positions,
+        revisions.get(projectId),
+      ),
+    );
+  } catch (err) {
+    if (!isConflict(err)) return;
+    // 他の書き込みがあった場合は最新を読み直してから、動かしたノードだけをもう一度マージする
+    try {
+      await loadCache(projectId);
+      rememberRevision(
+        projectId,
+        await cachePutNodePositions(
+          projectId,
+          positions,
+          revisions.get(projectId),
+        ),
+      );
+    } catch {
+      revisions.delete(projectId);
+    }
   }
 };